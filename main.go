@@ -1,18 +1,46 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"crypto/hmac"
+	"crypto/md5"
+	crand "crypto/rand"
 	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/csv"
 	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
 	"fmt"
 	"image/color"
 	"io"
+	"maps"
+	"math"
+	"math/rand"
+	"mime"
+	"net"
 	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -21,9 +49,27 @@ import (
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"github.com/robertkrimen/otto"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/net/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
 )
 
 // --- ESTRUCTURAS POSTMAN (Simplificado v2.1) ---
@@ -38,1658 +84,8688 @@ type RequestDetails struct {
 	Auth      string
 }
 
+// Los structs de Postman solo interpretan los campos que usa la app; el resto (auth, variable, event,
+// formdata, la URL estructurada, ...) se guarda en Extra y se vuelve a escribir al exportar
+
 type PostmanCollection struct {
-	Info struct {
-		Name string `json:"name"`
-	} `json:"info"`
+	Info  PostmanInfo   `json:"info"`
 	Items []PostmanItem `json:"item"`
+	Extra PostmanExtra  `json:"-"`
+}
+
+type PostmanInfo struct {
+	Name   string       `json:"name"`
+	Schema string       `json:"schema,omitempty"`
+	Extra  PostmanExtra `json:"-"`
 }
 
+// PostmanSchemaV21 es el schema declarado al exportar colecciones que no traían uno
+const PostmanSchemaV21 = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
 type PostmanItem struct {
 	Name    string          `json:"name"`
 	Request *PostmanRequest `json:"request,omitempty"` // Si es nil, es carpeta
 	Items   []PostmanItem   `json:"item,omitempty"`    // Sub-items (carpetas)
+	Extra   PostmanExtra    `json:"-"`
 }
 
-type PostmanRequest struct {
-	Method string `json:"method"`
-	Url    struct {
-		Raw string `json:"raw"`
-	} `json:"url"`
-	Header []struct {
-		Key   string `json:"key"`
-		Value string `json:"value"`
-	} `json:"header"`
-	Body struct {
-		Mode string `json:"mode"`
-		Raw  string `json:"raw"`
-	} `json:"body"`
+// PostmanExtra son los campos JSON de un objeto de Postman que no tienen campo en el struct
+type PostmanExtra map[string]json.RawMessage
+
+// unmarshalPostman decodifica data en known (un puntero a un tipo sin métodos JSON, para no recursar)
+// y retorna los campos que no están entre knownKeys
+func unmarshalPostman(data []byte, known any, knownKeys ...string) (PostmanExtra, error) {
+	if err := json.Unmarshal(data, known); err != nil {
+		return nil, err
+	}
+	var extra PostmanExtra
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return nil, err
+	}
+	for _, key := range knownKeys {
+		delete(extra, key)
+	}
+	if len(extra) == 0 {
+		return nil, nil
+	}
+	return extra, nil
 }
 
-// --- ESTRUCTURAS BENCHMARK ---
+// marshalPostman codifica known y le agrega los campos de extra que known no escribió
+func marshalPostman(known any, extra PostmanExtra) ([]byte, error) {
+	data, err := json.Marshal(known)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+	fields := PostmanExtra{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range extra {
+		if _, ok := fields[key]; !ok {
+			fields[key] = value
+		}
+	}
+	return json.Marshal(fields)
+}
 
-type BenchmarkResult struct {
-	Seq       int     // Número de secuencia
-	Timestamp string  // Hora de la petición (Eje X)
-	Duration  float64 // ms
-	Status    int
+func (c *PostmanCollection) UnmarshalJSON(data []byte) (err error) {
+	type plain PostmanCollection
+	c.Extra, err = unmarshalPostman(data, (*plain)(c), "info", "item")
+	return err
 }
 
-type RequestConfig struct {
-	URL             string
-	Method          string
-	Headers         string
-	Body            string
-	ContentType     string
-	User, Secret    string
-	Count           int
-	Duration        int // Duración en segundos (0 = usar Count)
-	ConcurrentUsers int // Número de usuarios concurrentes
+func (c PostmanCollection) MarshalJSON() ([]byte, error) {
+	type plain PostmanCollection
+	return marshalPostman(plain(c), c.Extra)
 }
 
-type BenchmarkStats struct {
-	Avg, Min, Max, P90, P95, P99 float64
-	Success, Total, ErrorRate    int
-	RequestsPerSecond            float64
-	TotalDuration                float64
+func (i *PostmanInfo) UnmarshalJSON(data []byte) (err error) {
+	type plain PostmanInfo
+	i.Extra, err = unmarshalPostman(data, (*plain)(i), "name", "schema")
+	return err
 }
 
-// --- CHART WIDGET RESPONSIVO (CORE VISUAL) ---
+func (i PostmanInfo) MarshalJSON() ([]byte, error) {
+	type plain PostmanInfo
+	return marshalPostman(plain(i), i.Extra)
+}
 
-const MaxVisiblePointsNormal = 10   // Límite óptimo de puntos en vista normal
-const MaxVisiblePointsRealTime = 50 // Límite en vista tiempo real
-const FullScreenThreshold = 15      // Cambiar a pantalla completa después de este número de puntos
+func (it *PostmanItem) UnmarshalJSON(data []byte) (err error) {
+	type plain PostmanItem
+	it.Extra, err = unmarshalPostman(data, (*plain)(it), "name", "request", "item")
+	return err
+}
 
-// Modos de vista del gráfico
-type ViewMode int
+func (it PostmanItem) MarshalJSON() ([]byte, error) {
+	type plain PostmanItem
+	return marshalPostman(plain(it), it.Extra)
+}
 
-const (
-	ViewModeNormal ViewMode = iota
-	ViewModeRealTime
-	ViewModeFullScreen
-)
+// filterPostmanItems retorna los items cuyo nombre contiene query (sin distinguir mayúsculas).
+// Una carpeta que coincide conserva todo su contenido; si no coincide, se conserva solo
+// cuando algún descendiente coincide y únicamente con esos descendientes.
+func filterPostmanItems(items []PostmanItem, query string) []PostmanItem {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return items
+	}
+	var out []PostmanItem
+	for _, item := range items {
+		if strings.Contains(strings.ToLower(item.Name), query) {
+			out = append(out, item)
+			continue
+		}
+		if children := filterPostmanItems(item.Items, query); len(children) > 0 {
+			folder := item
+			folder.Items = children
+			out = append(out, folder)
+		}
+	}
+	return out
+}
 
-// PointInfo contiene información de un punto del gráfico
-type PointInfo struct {
-	X, Y      float32
-	Result    BenchmarkResult
-	ExtraData string // Información adicional calculada
+// PostmanFolder es una carpeta de la colección identificada por los índices desde la raíz
+// (los nombres pueden repetirse); Path nil es la raíz
+type PostmanFolder struct {
+	Label string
+	Path  []int
 }
 
-type ChartWidget struct {
-	widget.BaseWidget
-	Data             []BenchmarkResult
-	tooltip          *widget.Label
-	tooltipBg        *canvas.Rectangle
-	tooltipContainer *fyne.Container
-	points           []PointInfo // Información de puntos para hover
-	lastMousePos     fyne.Position
-	hoverTimeout     *time.Timer
-	viewMode         ViewMode
-	startTime        time.Time
-	lastUpdateTime   time.Time
-	parent           *fyne.Container // Referencia al contenedor padre para cambio de modo
+// postmanFolders lista la raíz y todas las carpetas de items en orden de aparición
+func postmanFolders(items []PostmanItem) []PostmanFolder {
+	folders := []PostmanFolder{{Label: "(raíz)"}}
+	var walk func([]PostmanItem, string, []int)
+	walk = func(items []PostmanItem, prefix string, path []int) {
+		for i, item := range items {
+			if item.Request != nil {
+				continue
+			}
+			label := prefix + item.Name
+			itemPath := append(append([]int(nil), path...), i)
+			folders = append(folders, PostmanFolder{Label: label, Path: itemPath})
+			walk(item.Items, label+" / ", itemPath)
+		}
+	}
+	walk(items, "", nil)
+	return folders
 }
 
-func NewChartWidget() *ChartWidget {
-	c := &ChartWidget{}
-	c.ExtendBaseWidget(c)
-	c.viewMode = ViewModeNormal
-	c.startTime = time.Now()
+// insertPostmanItem agrega item al final de la carpeta indicada por path y retorna la nueva colección.
+// Copia cada nivel que recorre, así los slices compartidos con la colección importada no se modifican
+func insertPostmanItem(items []PostmanItem, path []int, item PostmanItem) []PostmanItem {
+	out := append([]PostmanItem(nil), items...)
+	if len(path) == 0 {
+		return append(out, item)
+	}
+	folder := out[path[0]]
+	folder.Items = insertPostmanItem(folder.Items, path[1:], item)
+	out[path[0]] = folder
+	return out
+}
 
-	// Crear tooltip
-	c.tooltip = widget.NewLabel("")
-	c.tooltip.TextStyle = fyne.TextStyle{Bold: true}
-	c.tooltip.Alignment = fyne.TextAlignLeading
-	c.tooltip.Wrapping = fyne.TextWrapWord
+// postmanTreeIDs retorna los IDs del árbol (ver processItems) de cada nivel de path, desde la raíz
+func postmanTreeIDs(items []PostmanItem, path []int) []string {
+	var ids []string
+	id := ""
+	for _, i := range path {
+		if i < 0 || i >= len(items) {
+			break
+		}
+		if id != "" {
+			id += "/"
+		}
+		id += items[i].Name + strconv.Itoa(i)
+		ids = append(ids, id)
+		items = items[i].Items
+	}
+	return ids
+}
 
-	c.tooltipBg = canvas.NewRectangle(color.NRGBA{R: 50, G: 50, B: 50, A: 240})
-	c.tooltipContainer = container.NewStack(c.tooltipBg, container.NewPadded(c.tooltip))
-	c.tooltipContainer.Hide()
+type PostmanRequest struct {
+	Method string          `json:"method"`
+	Url    PostmanURL      `json:"url"`
+	Header []PostmanHeader `json:"header"`
+	Body   PostmanBody     `json:"body"`
+	Extra  PostmanExtra    `json:"-"`
+}
 
-	return c
+// PostmanURL acepta la URL como string o como objeto; el objeto conserva host, path, query y variables
+type PostmanURL struct {
+	Raw   string       `json:"raw"`
+	Extra PostmanExtra `json:"-"`
 }
 
-func (c *ChartWidget) SetData(d []BenchmarkResult) {
-	c.Data = d
-	c.points = nil // Reset puntos para recalcular
-	c.lastUpdateTime = time.Now()
+// postmanURLParts son los campos de PostmanURL derivados de Raw, que dejan de valer si Raw cambia
+var postmanURLParts = []string{"protocol", "host", "port", "path", "query", "hash"}
 
-	// Cambiar modo de vista automáticamente basado en cantidad de datos
-	if len(d) >= FullScreenThreshold && c.viewMode == ViewModeNormal {
-		c.viewMode = ViewModeRealTime
-		// Si hay muchos puntos, sugerir pantalla completa
-		if len(d) >= 30 {
-			c.viewMode = ViewModeFullScreen
-		}
+type PostmanBody struct {
+	Mode  string       `json:"mode"`
+	Raw   string       `json:"raw"`
+	Extra PostmanExtra `json:"-"` // formdata, urlencoded, options, ...
+}
+
+type PostmanHeader struct {
+	Key      string       `json:"key"`
+	Value    string       `json:"value"`
+	Disabled bool         `json:"disabled,omitempty"`
+	Extra    PostmanExtra `json:"-"`
+}
+
+func (r *PostmanRequest) UnmarshalJSON(data []byte) (err error) {
+	// Forma abreviada de Postman: la request es solo la URL
+	var rawURL string
+	if json.Unmarshal(data, &rawURL) == nil {
+		*r = PostmanRequest{Method: http.MethodGet, Url: PostmanURL{Raw: rawURL}}
+		return nil
 	}
+	type plain PostmanRequest
+	r.Extra, err = unmarshalPostman(data, (*plain)(r), "method", "url", "header", "body")
+	return err
+}
 
-	c.Refresh() // Fuerza el repintado
+func (r PostmanRequest) MarshalJSON() ([]byte, error) {
+	type plain PostmanRequest
+	return marshalPostman(plain(r), r.Extra)
 }
 
-// SetViewMode permite cambiar el modo de vista manualmente
-func (c *ChartWidget) SetViewMode(mode ViewMode) {
-	c.viewMode = mode
-	c.Refresh()
+func (u *PostmanURL) UnmarshalJSON(data []byte) (err error) {
+	var rawURL string
+	if json.Unmarshal(data, &rawURL) == nil {
+		*u = PostmanURL{Raw: rawURL}
+		return nil
+	}
+	type plain PostmanURL
+	u.Extra, err = unmarshalPostman(data, (*plain)(u), "raw")
+	return err
 }
 
-// GetViewMode retorna el modo actual
-func (c *ChartWidget) GetViewMode() ViewMode {
-	return c.viewMode
+func (u PostmanURL) MarshalJSON() ([]byte, error) {
+	type plain PostmanURL
+	return marshalPostman(plain(u), u.Extra)
 }
 
-func (c *ChartWidget) CreateRenderer() fyne.WidgetRenderer {
-	return &chartRenderer{chart: c}
+func (b *PostmanBody) UnmarshalJSON(data []byte) (err error) {
+	type plain PostmanBody
+	b.Extra, err = unmarshalPostman(data, (*plain)(b), "mode", "raw")
+	return err
 }
 
-// Implementar desktop.Hoverable para detectar movimiento del mouse
-func (c *ChartWidget) MouseIn(event *desktop.MouseEvent) {
-	c.updateTooltip(event.Position)
+func (b PostmanBody) MarshalJSON() ([]byte, error) {
+	type plain PostmanBody
+	return marshalPostman(plain(b), b.Extra)
 }
 
-func (c *ChartWidget) MouseMoved(event *desktop.MouseEvent) {
-	c.updateTooltip(event.Position)
+func (h *PostmanHeader) UnmarshalJSON(data []byte) (err error) {
+	type plain PostmanHeader
+	h.Extra, err = unmarshalPostman(data, (*plain)(h), "key", "value", "disabled")
+	return err
 }
 
-func (c *ChartWidget) MouseOut() {
-	c.hideTooltip()
+func (h PostmanHeader) MarshalJSON() ([]byte, error) {
+	type plain PostmanHeader
+	return marshalPostman(plain(h), h.Extra)
 }
 
-// Actualizar tooltip basado en la posición del mouse
-func (c *ChartWidget) updateTooltip(pos fyne.Position) {
-	c.lastMousePos = pos
+// applyToPostmanRequest vuelca los valores del formulario en la request (los headers deshabilitados se conservan).
+// Los campos que la app no edita (auth, variables, descripción de cada header, ...) se mantienen.
+func applyToPostmanRequest(r *PostmanRequest, method, url string, headers []HeaderRow, body string) {
+	r.Method = method
+	if url != r.Url.Raw {
+		// Las partes estructuradas describen la URL anterior; Postman las reconstruye desde raw
+		extra := PostmanExtra{}
+		for key, value := range r.Url.Extra {
+			if !slices.Contains(postmanURLParts, key) {
+				extra[key] = value
+			}
+		}
+		r.Url = PostmanURL{Raw: url, Extra: extra}
+	}
+	previous := r.Header
+	r.Header = nil
+	for _, h := range headers {
+		header := PostmanHeader{Key: h.Key, Value: h.Value, Disabled: !h.Enabled}
+		// Cada header anterior aporta sus campos extra a lo sumo una vez (las claves pueden repetirse)
+		for i, p := range previous {
+			if p.Extra != nil && strings.EqualFold(p.Key, h.Key) {
+				header.Extra = p.Extra
+				previous = slices.Delete(slices.Clone(previous), i, i+1)
+				break
+			}
+		}
+		r.Header = append(r.Header, header)
+	}
+	r.Body.Raw = body
+	if body != "" && r.Body.Mode == "" {
+		r.Body.Mode = "raw"
+	}
+}
 
-	// Cancelar timeout anterior si existe
-	if c.hoverTimeout != nil {
-		c.hoverTimeout.Stop()
+// --- TABLA DE HEADERS ---
+
+// HeaderRow es un header de la tabla; los deshabilitados se conservan pero no se envían
+type HeaderRow struct {
+	Key, Value string
+	Enabled    bool
+}
+
+// parseHeaderRows interpreta headers "Clave: Valor" por línea; las líneas que empiezan
+// con "#" se cargan deshabilitadas
+func parseHeaderRows(text string) []HeaderRow {
+	var rows []HeaderRow
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		enabled := !strings.HasPrefix(line, "#")
+		line = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[0]) != "" {
+			rows = append(rows, HeaderRow{Key: strings.TrimSpace(parts[0]), Value: strings.TrimSpace(parts[1]), Enabled: enabled})
+		}
 	}
+	return rows
+}
 
-	// Buscar punto cercano
-	for _, point := range c.points {
-		// Verificar si el mouse está cerca del punto (radio de 15px)
-		dx := pos.X - point.X
-		dy := pos.Y - point.Y
-		distance := dx*dx + dy*dy
+// formatHeaderRows arma el texto "Clave: Valor" por línea. Con includeDisabled los headers
+// deshabilitados se escriben comentados con "#"; si no, se omiten (texto para RequestConfig.Headers)
+func formatHeaderRows(rows []HeaderRow, includeDisabled bool) string {
+	var lines []string
+	for _, h := range rows {
+		if h.Key == "" {
+			continue
+		}
+		line := fmt.Sprintf("%s: %s", h.Key, h.Value)
+		if !h.Enabled {
+			if !includeDisabled {
+				continue
+			}
+			line = "# " + line
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
 
-		if distance <= 225 { // 15px de radio (15^2 = 225)
-			c.showTooltip(point, pos)
-			return
+// splitContentType separa el header Content-Type habilitado de las filas (se edita en el
+// selector de Content-Type) y retorna las filas restantes y su valor ("" si no estaba)
+func splitContentType(rows []HeaderRow) ([]HeaderRow, string) {
+	var out []HeaderRow
+	contentType := ""
+	for _, h := range rows {
+		if h.Enabled && strings.EqualFold(h.Key, "Content-Type") {
+			contentType = h.Value
+			continue
 		}
+		out = append(out, h)
 	}
+	return out, contentType
+}
 
-	// Si no hay punto cercano, ocultar después de un delay
-	c.hoverTimeout = time.AfterFunc(100*time.Millisecond, func() {
-		fyne.Do(func() {
-			c.hideTooltip()
-		})
+// withContentType agrega el Content-Type elegido a las filas si no hay un header habilitado que lo defina
+func withContentType(rows []HeaderRow, contentType string) []HeaderRow {
+	if contentType == "" {
+		return rows
+	}
+	if _, existing := splitContentType(rows); existing != "" {
+		return rows
+	}
+	return append(rows, HeaderRow{Key: "Content-Type", Value: contentType, Enabled: true})
+}
+
+// HeaderTable es el editor de headers: una fila clave/valor con check de habilitado por header
+type HeaderTable struct {
+	rows      []HeaderRow
+	rowsBox   *fyne.Container
+	container *fyne.Container
+}
+
+func NewHeaderTable() *HeaderTable {
+	t := &HeaderTable{rowsBox: container.NewVBox()}
+	addBtn := widget.NewButtonWithIcon("Agregar header", theme.ContentAddIcon(), func() {
+		t.SetRows(append(t.Rows(), HeaderRow{Enabled: true}))
 	})
+	t.container = container.NewVBox(t.rowsBox, addBtn)
+	return t
 }
 
-func (c *ChartWidget) showTooltip(point PointInfo, mousePos fyne.Position) {
-	if c.tooltip == nil {
-		return
+// Container retorna el objeto a ubicar en la interfaz
+func (t *HeaderTable) Container() fyne.CanvasObject {
+	return t.container
+}
+
+// Rows retorna una copia de todas las filas (habilitadas y deshabilitadas)
+func (t *HeaderTable) Rows() []HeaderRow {
+	return append([]HeaderRow(nil), t.rows...)
+}
+
+// EnabledText retorna los headers habilitados como "Clave: Valor" por línea
+func (t *HeaderTable) EnabledText() string {
+	return formatHeaderRows(t.rows, false)
+}
+
+// SetRows reemplaza las filas de la tabla
+func (t *HeaderTable) SetRows(rows []HeaderRow) {
+	t.rows = append([]HeaderRow(nil), rows...)
+	t.rowsBox.Objects = nil
+	for i := range t.rows {
+		check := widget.NewCheck("", func(enabled bool) { t.rows[i].Enabled = enabled })
+		check.SetChecked(t.rows[i].Enabled)
+		keyEntry := widget.NewEntry()
+		keyEntry.SetPlaceHolder("Header")
+		keyEntry.SetText(t.rows[i].Key)
+		keyEntry.OnChanged = func(s string) { t.rows[i].Key = strings.TrimSpace(s) }
+		valueEntry := widget.NewEntry()
+		valueEntry.SetPlaceHolder("Valor")
+		valueEntry.SetText(t.rows[i].Value)
+		valueEntry.OnChanged = func(s string) { t.rows[i].Value = strings.TrimSpace(s) }
+		removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+			rows := t.Rows()
+			t.SetRows(append(rows[:i], rows[i+1:]...))
+		})
+		t.rowsBox.Add(container.NewBorder(nil, nil, check, removeBtn, container.NewGridWithColumns(2, keyEntry, valueEntry)))
 	}
+	t.rowsBox.Refresh()
+}
 
-	// Usar fyne.Do para asegurar que la actualización ocurra en el hilo principal
-	fyne.Do(func() {
-		// Formatear texto del tooltip
-		tooltipText := fmt.Sprintf("Seq: %d\nHora: %s\nLatencia: %.1f ms\nStatus: %d%s",
-			point.Result.Seq, point.Result.Timestamp, point.Result.Duration, point.Result.Status, point.ExtraData)
+// --- SELECTOR DE MÉTODO ---
 
-		c.tooltip.SetText(tooltipText)
+// MethodOther es la opción del selector que habilita un método escrito a mano (ej. PURGE, LINK)
+const MethodOther = "Otro..."
 
-		// Calcular posición del tooltip (offset para no cubrir el punto)
-		tooltipX := mousePos.X + 15
-		tooltipY := mousePos.Y - 10
+// CommonMethods son los métodos que ofrece la lista del selector
+var CommonMethods = []string{"GET", "POST", "PUT", "DELETE"}
 
-		// Ajustar si se sale del widget
-		if tooltipX+150 > c.Size().Width {
-			tooltipX = mousePos.X - 165
-		}
-		if tooltipY < 0 {
-			tooltipY = mousePos.Y + 20
+// isHTTPToken indica si method es un token HTTP válido (RFC 9110): letras, dígitos y !#$%&'*+-.^_`|~
+func isHTTPToken(method string) bool {
+	if method == "" {
+		return false
+	}
+	for _, c := range method {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", c):
+		default:
+			return false
 		}
+	}
+	return true
+}
 
-		// Redimensionar y posicionar
-		c.tooltipContainer.Resize(fyne.NewSize(150, 80))
-		c.tooltipContainer.Move(fyne.NewPos(tooltipX, tooltipY))
-		c.tooltipContainer.Show()
-		c.Refresh()
-	})
+// MethodPicker es el selector de método: los métodos comunes en una lista y, con MethodOther,
+// un campo de texto para cualquier otro verbo
+type MethodPicker struct {
+	Select    *widget.Select
+	Entry     *widget.Entry
+	container *fyne.Container
 }
 
-func (c *ChartWidget) hideTooltip() {
-	fyne.Do(func() {
-		if c.tooltipContainer != nil {
-			c.tooltipContainer.Hide()
-			c.Refresh()
+func NewMethodPicker() *MethodPicker {
+	p := &MethodPicker{Entry: widget.NewEntry()}
+	p.Entry.SetPlaceHolder("PURGE")
+	p.Entry.Hide()
+	p.Select = widget.NewSelect(append(append([]string(nil), CommonMethods...), MethodOther), func(s string) {
+		if s == MethodOther {
+			p.Entry.Show()
+		} else {
+			p.Entry.Hide()
 		}
 	})
+	p.Select.SetSelected(CommonMethods[0])
+	p.container = container.NewHBox(p.Select, container.NewGridWrap(fyne.NewSize(100, p.Entry.MinSize().Height), p.Entry))
+	return p
 }
 
-type chartRenderer struct {
-	chart   *ChartWidget
-	objects []fyne.CanvasObject
+// Container retorna el objeto a ubicar en la interfaz
+func (p *MethodPicker) Container() fyne.CanvasObject {
+	return p.container
 }
 
-func (r *chartRenderer) MinSize() fyne.Size {
-	return fyne.NewSize(100, 100) // Tamaño mínimo
+// Method retorna el método elegido; con MethodOther, el escrito en el campo (en mayúsculas)
+func (p *MethodPicker) Method() string {
+	if p.Select.Selected == MethodOther {
+		return strings.ToUpper(strings.TrimSpace(p.Entry.Text))
+	}
+	return p.Select.Selected
 }
 
-func (r *chartRenderer) Layout(size fyne.Size) {
-	// Este método se llama automáticamente cuando la ventana cambia de tamaño
-	// Aquí regeneramos el gráfico para que ocupe todo el espacio 'size'
-	r.objects = r.generateChartObjects(size)
+// SetMethod elige method en la lista o, si no es uno de los comunes, lo escribe en el campo libre
+func (p *MethodPicker) SetMethod(method string) {
+	method = normalizeMethod(method)
+	for _, m := range CommonMethods {
+		if m == method {
+			p.Select.SetSelected(m)
+			return
+		}
+	}
+	p.Entry.SetText(method)
+	p.Select.SetSelected(MethodOther)
 }
 
-func (r *chartRenderer) Refresh() {
-	// Llamado cuando cambian los datos
-	r.Layout(r.chart.Size())
-	canvas.Refresh(r.chart)
+// PostmanTree es el árbol de la colección con navegación por teclado: las flechas mueven el foco
+// (comportamiento de widget.Tree) y Enter/Espacio cargan el item enfocado en el formulario
+type PostmanTree struct {
+	widget.Tree
 }
 
-func (r *chartRenderer) Objects() []fyne.CanvasObject {
-	// Agregar tooltip a los objetos si existe
-	if r.chart.tooltipContainer != nil {
-		return append(r.objects, r.chart.tooltipContainer)
-	}
-	return r.objects
+func NewPostmanTree(childUIDs func(widget.TreeNodeID) []widget.TreeNodeID, isBranch func(widget.TreeNodeID) bool,
+	create func(bool) fyne.CanvasObject, update func(widget.TreeNodeID, bool, fyne.CanvasObject)) *PostmanTree {
+	t := &PostmanTree{}
+	t.ChildUIDs = childUIDs
+	t.IsBranch = isBranch
+	t.CreateNode = create
+	t.UpdateNode = update
+	t.ExtendBaseWidget(t)
+	return t
 }
 
-func (r *chartRenderer) Destroy() {
-	// No usamos el receptor 'r' aquí, lo cual es aceptable ya que el método debe existir.
+// TypedKey agrega Enter a las teclas de widget.Tree. Se deselecciona antes para que Enter sobre el
+// item ya seleccionado vuelva a disparar OnSelected y recargue el formulario
+func (t *PostmanTree) TypedKey(event *fyne.KeyEvent) {
+	switch event.Name {
+	case fyne.KeyReturn, fyne.KeyEnter, fyne.KeySpace:
+		t.UnselectAll()
+		t.Tree.TypedKey(&fyne.KeyEvent{Name: fyne.KeySpace})
+	default:
+		t.Tree.TypedKey(event)
+	}
 }
 
-// Lógica de dibujo matemático puro (Ahora con múltiples modos de vista)
-func (r *chartRenderer) generateChartObjects(size fyne.Size) []fyne.CanvasObject {
-	// Determinar qué datos mostrar según el modo de vista
-	data := r.chart.Data
-	maxPoints := MaxVisiblePointsNormal
+// --- REDACCIÓN DE SECRETOS ---
 
-	switch r.chart.viewMode {
-	case ViewModeNormal:
-		maxPoints = MaxVisiblePointsNormal
-		if len(data) > maxPoints {
-			// Mostrar solo los últimos N puntos
-			data = data[len(data)-maxPoints:]
+// RedactedValue reemplaza a los secretos en lo que se exporta (sin caracteres que haya que escapar en una URL)
+const RedactedValue = "REDACTED"
+
+// sensitiveNameParts marcan como secreto a un header o parámetro de query cuyo nombre (en minúsculas) los contiene
+var sensitiveNameParts = []string{"authorization", "cookie", "token", "secret", "password", "passwd", "api-key", "api_key", "apikey", "signature"}
+
+// isSensitiveName indica si el valor de un header o parámetro con ese nombre es un secreto
+func isSensitiveName(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, part := range sensitiveNameParts {
+		if strings.Contains(name, part) {
+			return true
 		}
-	case ViewModeRealTime:
-		maxPoints = MaxVisiblePointsRealTime
-		if len(data) > maxPoints {
-			// En vista tiempo real, muestrear puntos para mantener fluidez
-			step := len(data) / maxPoints
-			sampledData := make([]BenchmarkResult, 0, maxPoints)
-			for i := 0; i < len(data); i += step {
-				sampledData = append(sampledData, data[i])
-			}
-			// Siempre incluir el último punto
-			if len(sampledData) < len(data) {
-				sampledData = append(sampledData, data[len(data)-1])
-			}
-			data = sampledData
+	}
+	return false
+}
+
+// redactHeaderRows retorna una copia de rows con el valor de los headers sensibles reemplazado
+// y si hubo algo que ocultar
+func redactHeaderRows(rows []HeaderRow) ([]HeaderRow, bool) {
+	out := make([]HeaderRow, len(rows))
+	redacted := false
+	for i, h := range rows {
+		if h.Value != "" && isSensitiveName(h.Key) {
+			h.Value = RedactedValue
+			redacted = true
 		}
-	case ViewModeFullScreen:
-		maxPoints = MaxVisiblePointsRealTime * 2
-		if len(data) > maxPoints {
-			// En pantalla completa, más puntos pero con muestreo inteligente
-			step := len(data) / maxPoints
-			sampledData := make([]BenchmarkResult, 0, maxPoints)
-			for i := 0; i < len(data); i += step {
-				sampledData = append(sampledData, data[i])
+		out[i] = h
+	}
+	return out, redacted
+}
+
+// redactHTTPHeader retorna una copia de h con el valor de los headers sensibles reemplazado
+func redactHTTPHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for key, values := range out {
+		if isSensitiveName(key) {
+			for i := range values {
+				values[i] = RedactedValue
 			}
-			data = sampledData
 		}
 	}
+	return out
+}
 
-	objs := []fyne.CanvasObject{}
-
-	// Ajustar padding según el modo de vista - padding mayor para evitar solapamiento de ejes
-	// Padding izquierdo más grande para el eje rojo, derecho para el amarillo
-	paddingLeft := float32(70)  // Espacio para eje rojo a la izquierda
-	paddingRight := float32(60) // Espacio para eje amarillo a la derecha
-	paddingTop := float32(40)
-	paddingBottom := float32(40)
-
-	if r.chart.viewMode == ViewModeFullScreen {
-		paddingLeft = float32(50)
-		paddingRight = float32(50)
-		paddingTop = float32(30)
-		paddingBottom = float32(30)
+// redactURL oculta el password de la URL y los parámetros de query sensibles (ej. ?api_key=...).
+// Las URLs sin secretos (o que no se pueden parsear) se retornan sin cambios.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
 	}
-
-	bg := canvas.NewRectangle(color.NRGBA{R: 30, G: 30, B: 35, A: 255})
-	bg.Resize(size)
-	objs = append(objs, bg)
-
-	if len(data) < 2 {
-		modeText := "normal"
-		if r.chart.viewMode == ViewModeRealTime {
-			modeText = "tiempo real"
-		} else if r.chart.viewMode == ViewModeFullScreen {
-			modeText = "pantalla completa"
+	redacted := false
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), RedactedValue)
+		redacted = true
+	}
+	query := u.Query()
+	for key, values := range query {
+		if isSensitiveName(key) {
+			for i := range values {
+				values[i] = RedactedValue
+			}
+			redacted = true
 		}
-		txt := canvas.NewText(fmt.Sprintf("Ejecuta un test para ver el gráfico (modo: %s)", modeText), color.Gray{Y: 150})
-		txt.TextSize = 14
-		txt.Alignment = fyne.TextAlignCenter
-		txt.Move(fyne.NewPos(size.Width/2-150, size.Height/2))
-		objs = append(objs, txt)
-		return objs
 	}
+	if !redacted {
+		return rawURL
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
 
-	// Calcular dimensiones del área del gráfico con paddings asimétricos
-	graphW := size.Width - paddingLeft - paddingRight
-	graphH := size.Height - paddingTop - paddingBottom
+// redactSecrets retorna una copia de cfg con el password de la URL y los headers y parámetros de
+// query sensibles reemplazados. Es la capa que usa la exportación a Postman para no filtrar credenciales.
+func redactSecrets(cfg RequestConfig) RequestConfig {
+	cfg.URL = redactURL(cfg.URL)
+	if rows, redacted := redactHeaderRows(parseHeaderRows(cfg.Headers)); redacted {
+		cfg.Headers = formatHeaderRows(rows, true)
+	}
+	return cfg
+}
 
-	// --- Cálculos para múltiples métricas ---
-	// Calcular rangos Y para tiempo de respuesta
-	maxDur := 0.0
-	minDur := 999999.0
+// postmanAuthPublicKeys son los atributos de un auth de Postman que no son secretos (nombres y ubicaciones)
+var postmanAuthPublicKeys = []string{"in", "key", "username", "addTokenTo", "headerPrefix", "tokenType", "algorithm"}
 
-	for _, d := range data {
-		if d.Duration > maxDur {
-			maxDur = d.Duration
+// redactPostmanAttrs oculta el value de los atributos {key, value} de la lista raw que cumplen secret
+func redactPostmanAttrs(raw json.RawMessage, secret func(key string) bool) (json.RawMessage, bool) {
+	var attrs []map[string]any
+	if json.Unmarshal(raw, &attrs) != nil {
+		return raw, false
+	}
+	redacted := false
+	for _, attr := range attrs {
+		key, _ := attr["key"].(string)
+		if value, ok := attr["value"]; ok && value != "" && secret(key) {
+			attr["value"] = RedactedValue
+			redacted = true
 		}
-		if d.Duration < minDur {
-			minDur = d.Duration
+	}
+	if !redacted {
+		return raw, false
+	}
+	out, err := json.Marshal(attrs)
+	return out, err == nil
+}
+
+// redactPostmanExtra oculta los secretos de los campos que se conservan sin interpretar: los valores
+// del auth (token, password, ...) y las variables con nombre sensible. Retorna una copia de extra.
+func redactPostmanExtra(extra PostmanExtra) (PostmanExtra, bool) {
+	out := maps.Clone(extra)
+	found := false
+	if raw, ok := extra["auth"]; ok {
+		var auth map[string]json.RawMessage
+		if json.Unmarshal(raw, &auth) == nil {
+			redacted := false
+			for method, attrs := range auth {
+				if method == "type" {
+					continue
+				}
+				data, r := redactPostmanAttrs(attrs, func(key string) bool { return !slices.Contains(postmanAuthPublicKeys, key) })
+				auth[method] = data
+				redacted = redacted || r
+			}
+			if data, err := json.Marshal(auth); redacted && err == nil {
+				out["auth"] = data
+				found = true
+			}
 		}
 	}
-	if maxDur == 0 {
-		maxDur = 100
+	if raw, ok := extra["variable"]; ok {
+		if data, redacted := redactPostmanAttrs(raw, isSensitiveName); redacted {
+			out["variable"] = data
+			found = true
+		}
 	}
-	maxDur *= 1.2
+	return out, found
+}
 
-	// Calcular estadísticas para las líneas adicionales
-	var errorCount float64
-	var totalDuration float64
-	for _, d := range data {
-		totalDuration += d.Duration
-		if d.Status >= 400 || d.Status == 0 {
-			errorCount++
+// redactPostmanCollection retorna una copia de c sin secretos (ver redactPostmanItems) y si hubo algo que ocultar
+func redactPostmanCollection(c PostmanCollection) (PostmanCollection, bool) {
+	var inExtra, inItems bool
+	c.Extra, inExtra = redactPostmanExtra(c.Extra)
+	c.Items, inItems = redactPostmanItems(c.Items)
+	return c, inExtra || inItems
+}
+
+// redactPostmanItems aplica redactSecrets a la URL y los headers de cada request de la colección, y oculta
+// los secretos del auth y las variables de items y requests.
+// Retorna una copia (las requests originales no se modifican) y si hubo algo que ocultar.
+func redactPostmanItems(items []PostmanItem) ([]PostmanItem, bool) {
+	out := make([]PostmanItem, len(items))
+	found := false
+	for i, item := range items {
+		var redacted bool
+		item.Extra, redacted = redactPostmanExtra(item.Extra)
+		found = found || redacted
+		if item.Request != nil {
+			if extra, redacted := redactPostmanExtra(item.Request.Extra); redacted {
+				req := *item.Request
+				req.Extra = extra
+				item.Request = &req
+				found = true
+			}
+			rows := make([]HeaderRow, len(item.Request.Header))
+			for j, h := range item.Request.Header {
+				rows[j] = HeaderRow{Key: h.Key, Value: h.Value, Enabled: !h.Disabled}
+			}
+			headers := formatHeaderRows(rows, true)
+			clean := redactSecrets(RequestConfig{URL: item.Request.Url.Raw, Headers: headers})
+			if clean.URL != item.Request.Url.Raw || clean.Headers != headers {
+				req := *item.Request
+				applyToPostmanRequest(&req, req.Method, clean.URL, parseHeaderRows(clean.Headers), req.Body.Raw)
+				item.Request = &req
+				found = true
+			}
 		}
-	} // Escalas para múltiples métricas
-	xStep := graphW / float32(len(data)-1)
-	yScale := graphH / float32(maxDur)
+		if len(item.Items) > 0 {
+			var redacted bool
+			item.Items, redacted = redactPostmanItems(item.Items)
+			found = found || redacted
+		}
+		out[i] = item
+	}
+	return out, found
+}
 
-	// --- Ejes y Etiquetas ---
-	axisColor := color.NRGBA{R: 100, G: 100, B: 100, A: 255}
+// --- ESTRUCTURAS BENCHMARK ---
 
-	// Eje X (Base)
-	xAxis := canvas.NewLine(axisColor)
-	xAxis.StrokeWidth = 2
-	xAxis.Position1 = fyne.NewPos(paddingLeft, size.Height-paddingBottom)
-	xAxis.Position2 = fyne.NewPos(size.Width-paddingRight, size.Height-paddingBottom)
-	objs = append(objs, xAxis)
+// CountMode define cómo se interpreta RequestConfig.Count
+type CountMode int
 
-	// Eje Y (Vertical - Central para tiempo de respuesta)
-	yAxis := canvas.NewLine(axisColor)
-	yAxis.StrokeWidth = 2
-	yAxis.Position1 = fyne.NewPos(paddingLeft, paddingTop)
-	yAxis.Position2 = fyne.NewPos(paddingLeft, size.Height-paddingBottom)
-	objs = append(objs, yAxis)
+const (
+	CountModeTotal   CountMode = iota // Count es el total entre todos los usuarios
+	CountModePerUser                  // Count es la cantidad de requests de cada usuario
+)
 
-	// Etiquetas Eje Y para tiempo (lado izquierdo central)
-	drawYLabel := func(val float64, yPos float32, label string) {
-		lbl := canvas.NewText(label, axisColor)
-		lbl.TextSize = 9
-		lbl.Alignment = fyne.TextAlignTrailing
-		lbl.Move(fyne.NewPos(paddingLeft-35, yPos-6))
-		// Línea guía
-		grid := canvas.NewLine(color.NRGBA{R: 60, G: 60, B: 60, A: 100})
-		grid.Position1 = fyne.NewPos(paddingLeft, yPos)
-		grid.Position2 = fyne.NewPos(size.Width-paddingRight, yPos)
-		objs = append(objs, lbl, grid)
-	}
+type BenchmarkResult struct {
+	Seq         int       // Número de secuencia
+	Timestamp   string    // Hora de la petición (Eje X)
+	StartedAt   time.Time // Instante exacto de inicio (exportación a InfluxDB)
+	Duration    float64   // ms
+	Status      int
+	Endpoint    string // "MÉTODO URL" en modo multi-endpoint ("" = endpoint principal)
+	URL         string // URL usada por la request cuando se prueba una lista de URLs ("" = URL principal)
+	ErrorKind   string // Tipo de error si la request falló ("timeout", "connect_timeout", "connection", "schema"; "" = sin error)
+	ErrorDetail string // Primer error de validación del JSON Schema (ErrorKind "schema")
+
+	// Desglose de la latencia capturado con httptrace (ms, 0 si la conexión se reutilizó)
+	DNSMs, ConnectMs, TLSMs, TTFBMs float64
+	TTLBMs                          float64 // Hasta leer el último byte del body (Duration solo cubre los headers)
+	RetryAfterMs                    float64 // Espera pedida por Retry-After y respetada antes de la próxima request (0 = sin espera)
+	BodyFile                        string  // Archivo de la carpeta de bodies enviado en la request ("" = sin carpeta)
+}
 
-	drawYLabel(maxDur, paddingTop, fmt.Sprintf("%.0fms", maxDur))
-	drawYLabel(maxDur/2, paddingTop+graphH/2, fmt.Sprintf("%.0fms", maxDur/2))
-	drawYLabel(0, size.Height-paddingBottom, "0ms")
+// WeightedEndpoint es un endpoint con su peso relativo dentro de un test de tráfico mixto.
+// Los campos vacíos de Config (headers, auth, content-type) se heredan de la configuración principal.
+type WeightedEndpoint struct {
+	Weight int
+	Config RequestConfig
+}
 
-	// --- Ejes Y adicionales con colores (amarillo y rojo) ---
+// EndpointStats agrupa las métricas de un endpoint en modo multi-endpoint
+type EndpointStats struct {
+	Name          string
+	Total         int
+	Success       int
+	Avg, Min, Max float64
+	P95           float64
+	ErrorRate     float64 // % de requests que cuentan como error (ver countsAsError)
+}
 
-	// Calcular máximos para requests/sec y error rate
-	maxRequestsPerSec := 120.0 // Máximo esperado
-	maxErrorRate := 100.0      // Porcentaje
+type RequestConfig struct {
+	URL                    string
+	Method                 string
+	Headers                string
+	Body                   string
+	ContentType            string
+	User, Secret           string
+	AuthType               string // AuthTypeHMAC (por defecto, usa User/Secret) o AuthTypeNTLM
+	NTLMDomain             string
+	NTLMUser               string
+	NTLMPassword           string
+	UserAgent              string // User-Agent enviado ("" = DefaultUserAgent); un header User-Agent lo reemplaza
+	AbortOnFirstError      bool   // Modo debug: detener el test en la primera respuesta no exitosa
+	SeparateRateLimited    bool   // Contar los 429 aparte (RateLimited) en lugar de como errores
+	HonorRetryAfter        bool   // Ante un 429/503 con Retry-After, el usuario espera ese tiempo (hasta MaxRetryAfter) antes de seguir
+	Count                  int
+	CountMode              CountMode          // Total o por usuario (solo en modo por cantidad)
+	TagRequests            bool               // Agregar X-Request-Seq y X-Run-Id a cada request
+	TimeoutSeconds         int                // Timeout de cada request en segundos (0 = DefaultRequestTimeout)
+	ConnectTimeoutMs       int                // Timeout para establecer la conexión TCP en ms (0 = el del transport por defecto)
+	UnixSocketPath         string             // Socket Unix al que se conectan las requests; la URL solo aporta path y Host ("" = TCP)
+	ResponseSchema         string             // JSON Schema que deben cumplir las respuestas exitosas ("" = sin validar)
+	MaxRetainedResults     int                // Resultados completos conservados (los más recientes; 0 = todos). Las estadísticas cubren siempre todos
+	DisableRedirects       bool               // No seguir redirects (se registra la respuesta 3xx)
+	GRPCMethod             string             // Método gRPC "paquete.Servicio/Metodo" (solo con URLs grpc:// o grpcs://)
+	Duration               int                // Duración en segundos (0 = usar Count)
+	ConcurrentUsers        int                // Número de usuarios concurrentes
+	LogFile                string             // Ruta del archivo de log ("" = sin log)
+	Note                   string             // Nota libre que describe la ejecución (ej. "baseline antes del cambio de caché")
+	LogBodies              bool               // Incluir el body de la respuesta en el log
+	LogBodyMaxBytes        int                // Tamaño máximo del body registrado (0 = DefaultLogBodyMaxBytes)
+	SlowThresholdMs        int                // SLA de latencia en ms (0 = sin umbral)
+	MaxBodyCaptureBytes    int                // Máximo de bytes del body capturados en request única (0 = DefaultMaxBodyCaptureBytes)
+	UseCookieJar           bool               // Cada usuario mantiene sus cookies entre requests
+	BodyFile               string             // Archivo cuyo contenido se envía como body ("" = usar Body)
+	BodyDir                string             // Carpeta con un body por archivo; cada request envía el siguiente (tiene prioridad sobre Body y BodyFile)
+	RandomizeBodies        bool               // Elegir de BodyDir al azar en cada request en lugar de round-robin
+	AllowBodyAllMethods    bool               // Enviar el body también en GET/HEAD/DELETE
+	SuccessStatusMin       int                // Menor status considerado exitoso (0 = DefaultSuccessStatusMin)
+	SuccessStatusMax       int                // Mayor status considerado exitoso (0 = DefaultSuccessStatusMax)
+	StopIfErrorRateExceeds float64            // Abortar si el error rate (%) de la ventana móvil lo supera (0 = desactivado)
+	ErrorRateWindow        int                // Tamaño de la ventana móvil del circuit breaker (0 = DefaultErrorRateWindow)
+	Endpoints              []WeightedEndpoint // Endpoints ponderados (vacío = usar solo URL/Method)
+	Scenario               []RequestConfig    // Pasos que cada usuario ejecuta en orden (ej. importados de un HAR); tiene prioridad sobre Endpoints y URLs
+	Percentiles            []float64          // Percentiles a calcular, en % (vacío = DefaultPercentiles)
+	RequestDeadlineMs      int                // Deadline duro por request en ms (0 = solo el timeout del cliente)
+	MaxRunDurationSeconds  int                // Tope de tiempo de toda la ejecución, también en modo por cantidad (0 = sin tope)
+	TargetRPS              int                // Ritmo máximo de requests por segundo entre todos los usuarios (0 = sin límite)
+	SLAP95Ms               float64            // SLA del P95 en ms; si se supera el test se marca como fallido (0 = sin SLA)
+	URLs                   []string           // Lista de URLs a repartir entre las requests (vacío = usar URL; se ignora con Endpoints)
+	RandomizeURLs          bool               // Elegir de URLs al azar en cada request en lugar de round-robin
+	ForceHTTP1             bool               // No negociar HTTP/2 (ALPN) aunque el servidor lo soporte
+	WarmupSeconds          int                // Modo por tiempo: las requests de los primeros N segundos no cuentan (0 = sin calentamiento)
+	PreRequest             PreRequestHook     // Hook que ajusta headers y body antes de cada request (nil = sin hook)
+	PreRequestScript       string             // JavaScript que corre antes de cada request ("" = sin script; PreRequest tiene prioridad)
+	DelayMs                int                // Solo en pasos de escenario: espera antes de ejecutar el paso (0 = sin espera)
+	Condition              *StepCondition     // Solo en pasos de escenario: el paso se ejecuta si se cumple (nil = siempre)
+}
 
-	// Eje Y para Requests/second (amarillo - derecha)
-	requestsAxisColor := color.NRGBA{R: 255, G: 193, B: 7, A: 255}
-	requestsAxisX := size.Width - paddingRight
-	requestsAxis := canvas.NewLine(requestsAxisColor)
-	requestsAxis.StrokeWidth = 2
-	requestsAxis.Position1 = fyne.NewPos(requestsAxisX, paddingTop)
-	requestsAxis.Position2 = fyne.NewPos(requestsAxisX, size.Height-paddingBottom)
-	objs = append(objs, requestsAxis)
+// StepCondition ejecuta un paso del escenario solo si un paso anterior de la misma vuelta respondió con Status
+type StepCondition struct {
+	Step   int // Índice (desde 0) del paso anterior evaluado
+	Status int // Status esperado; un paso omitido o con error de conexión cuenta como 0
+}
 
-	// Etiquetas para requests/sec (amarillo - a la derecha del eje)
-	drawRequestsLabel := func(val float64, yPos float32, label string) {
-		lbl := canvas.NewText(label, requestsAxisColor)
-		lbl.TextSize = 9
-		lbl.Alignment = fyne.TextAlignLeading
-		lbl.Move(fyne.NewPos(requestsAxisX+5, yPos-6))
-		objs = append(objs, lbl)
+// parseStepCondition interpreta "paso=status" (pasos numerados desde 1, ej. "2=200") para el paso index.
+// El paso referido debe ser anterior; "" = sin condición
+func parseStepCondition(text string, index int) (*StepCondition, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+	var step, status int
+	if n, err := fmt.Sscanf(strings.ReplaceAll(text, " ", ""), "%d=%d", &step, &status); err != nil || n != 2 {
+		return nil, fmt.Errorf("condición %q: formato esperado 'paso=status' (ej. 2=200)", text)
 	}
+	if step < 1 || step > index {
+		return nil, fmt.Errorf("condición %q: debe referirse a un paso anterior (1 a %d)", text, index)
+	}
+	return &StepCondition{Step: step - 1, Status: status}, nil
+}
 
-	drawRequestsLabel(maxRequestsPerSec, paddingTop, "120 req/s")
-	drawRequestsLabel(maxRequestsPerSec/2, paddingTop+graphH/2, "60")
-	drawRequestsLabel(0, size.Height-paddingBottom, "0")
+// Allows indica si el paso puede ejecutarse según los status de la vuelta actual (nil = siempre)
+func (c *StepCondition) Allows(statuses []int) bool {
+	return c == nil || c.Step < len(statuses) && statuses[c.Step] == c.Status
+}
 
-	// Eje Y para Error rate (rojo - extremo izquierdo)
-	errorAxisColor := color.NRGBA{R: 237, G: 28, B: 36, A: 255}
-	// Posición fija: 15px desde el borde izquierdo (siempre dentro del espacio reservado)
-	errorAxisX := float32(15)
-	errorAxis := canvas.NewLine(errorAxisColor)
-	errorAxis.StrokeWidth = 2
-	errorAxis.Position1 = fyne.NewPos(errorAxisX, paddingTop)
-	errorAxis.Position2 = fyne.NewPos(errorAxisX, size.Height-paddingBottom)
-	objs = append(objs, errorAxis)
+type BenchmarkStats struct {
+	Avg, Min, Max                               float64
+	Success, Total, ErrorRate                   int
+	RequestsPerSecond                           float64
+	SteadyRequestsPerSecond                     float64 // Throughput con todos los usuarios activos: sin arranque, calentamiento ni vaciado final (0 = sin calcular)
+	TotalDuration                               float64
+	SlowThresholdMs                             int  // SLA usado para contar requests lentas (0 = sin umbral)
+	SlowCount                                   int  // Requests que superaron el SLA
+	Aborted                                     bool // El test se detuvo automáticamente (circuit breaker)
+	AbortReason                                 string
+	Endpoints                                   []EndpointStats     // Estadísticas por endpoint (solo en modo multi-endpoint)
+	ConnLimitHit                                bool                // El SO rechazó conexiones por límite de descriptores (too many open files)
+	LogError                                    string              // Primer error al escribir el log de requests ("" = log completo)
+	AvgDNSMs, AvgConnectMs, AvgTLSMs, AvgTTFBMs float64             // Promedios del desglose de latencia (DNS, TCP y TLS solo sobre las requests que abrieron conexión)
+	NewConnections                              int                 // Requests que abrieron una conexión nueva (base de AvgConnectMs)
+	AvgTTLBMs                                   float64             // Promedio del tiempo hasta el último byte
+	PercentileValues                            map[float64]float64 `json:"-"` // Percentil (ej. 99.9) -> duración en ms
+	TimeoutCount                                int                 // Requests cortadas por timeout o deadline
+	ConnectTimeoutCount                         int                 // Requests que no lograron conectar dentro de ConnectTimeoutMs
+	RateLimited                                 int                 // Respuestas 429 contadas aparte (solo con SeparateRateLimited); no suman al error rate
+	Throttled                                   int                 // Respuestas cuyo Retry-After se respetó (solo con HonorRetryAfter)
+	FirstFailure                                *SingleResponse     `json:"-"` // Respuesta que detuvo el test en modo AbortOnFirstError
+	ScenarioSteps                               []SingleResponse    `json:"-"` // Última request y respuesta de cada paso del escenario
+	SLAP95Ms                                    float64             // SLA del P95 configurado (0 = sin SLA)
+	P95Ms                                       float64             // P95 medido, calculado siempre que haya SLA
+	SLABreached                                 bool                // El P95 superó el SLA
+	RecentAvg, RecentMin, RecentMax             float64             // Sobre las últimas RecentStatsWindow requests (solo en estadísticas parciales)
+	InFlight                                    int                 `json:"-"` // Requests en curso al enviar la actualización (solo en estadísticas parciales)
+	Note                                        string              // Nota de la ejecución (RequestConfig.Note), se conserva con los resultados exportados
+}
 
-	// Etiquetas para error rate (rojo - a la derecha del eje rojo)
-	drawErrorLabel := func(val float64, yPos float32, label string) {
-		lbl := canvas.NewText(label, errorAxisColor)
-		lbl.TextSize = 8
-		lbl.Alignment = fyne.TextAlignLeading
-		lbl.Move(fyne.NewPos(errorAxisX+3, yPos-6))
-		objs = append(objs, lbl)
+// MarshalJSON serializa las estadísticas con los percentiles indexados por su etiqueta (ej. "P99.9"),
+// ya que JSON no admite claves numéricas
+func (s BenchmarkStats) MarshalJSON() ([]byte, error) {
+	type plainStats BenchmarkStats
+	percentiles := make(map[string]float64, len(s.PercentileValues))
+	for p, v := range s.PercentileValues {
+		percentiles[formatPercentileLabel(p)] = v
 	}
+	return json.Marshal(struct {
+		plainStats
+		Percentiles map[string]float64
+	}{plainStats(s), percentiles})
+}
 
-	drawErrorLabel(100, paddingTop, "100%")
-	drawErrorLabel(50, paddingTop+graphH/2, "50")
-	drawErrorLabel(0, size.Height-paddingBottom, "0")
+// --- CHART WIDGET RESPONSIVO (CORE VISUAL) ---
 
-	// Escalas para cada métrica
-	requestsScale := graphH / float32(maxRequestsPerSec)
-	errorScale := graphH / float32(maxErrorRate)
+const MaxVisiblePointsNormal = 10   // Límite óptimo de puntos en vista normal
+const MaxVisiblePointsRealTime = 50 // Límite en vista tiempo real
+const FullScreenThreshold = 15      // Cambiar a pantalla completa después de este número de puntos
+const DefaultHoverRadius = 15       // Radio en px para detectar el punto bajo el mouse
+const DefaultRollingWindow = 50     // Requests sobre las que se calcula la serie de P95 móvil
+const DefaultChartErrorWindow = 20  // Requests de la ventana del error rate del gráfico ("Error rate últimas N")
 
-	// --- LÍNEAS DE DATOS MÚLTIPLES ---
+// Modos de vista del gráfico
+type ViewMode int
 
-	// Limpiar puntos para el hover
-	r.chart.points = nil
+const (
+	ViewModeNormal ViewMode = iota
+	ViewModeRealTime
+	ViewModeFullScreen
+)
 
-	// Colores basados en la imagen de referencia
-	responseTimeColor := color.NRGBA{R: 0, G: 162, B: 232, A: 255} // Azul (Avg response)
-	requestsSecColor := color.NRGBA{R: 255, G: 193, B: 7, A: 255}  // Amarillo (Requests/second)
-	errorRateColor := color.NRGBA{R: 237, G: 28, B: 36, A: 255}    // Rojo (Error rate)
+// PointInfo contiene información de un punto del gráfico
+// Los textos de tooltip y detalle se generan solo al usarse, no en cada redibujado
+type PointInfo struct {
+	X, Y           float32
+	Result         BenchmarkResult
+	Series         ChartSeries
+	Index          int     // Posición del punto dentro de los datos visibles
+	RequestsPerSec float64 // Métricas calculadas para el punto
+	ErrorRate      float64
+	Errors         int     // Errores acumulados hasta este punto (o dentro de la ventana)
+	ErrorSample    int     // Requests sobre las que se calculó el error rate
+	RollingP95     float64 // P95 de las últimas RollingWindow requests (solo con la serie activa)
+	RollingWindow  int
+	Failed         bool // La request cuenta como error (se dibuja con la cruz roja)
+}
 
-	var prevResponsePos, prevRequestsPos, prevErrorPos fyne.Position
+// ChartSeries identifica la línea del gráfico a la que pertenece un punto
+type ChartSeries int
 
-	// Ajustar grosor de línea y tamaño de puntos según el modo
-	lineWidth := float32(2)
-	pointSize := float32(4)
+const (
+	SeriesResponseTime ChartSeries = iota
+	SeriesRequestsSec
+	SeriesErrorRate
+	SeriesRollingP95
+)
 
-	if r.chart.viewMode == ViewModeFullScreen {
-		lineWidth = 3
-		pointSize = 5
-	} else if r.chart.viewMode == ViewModeRealTime {
-		lineWidth = 2.5
-		pointSize = 4.5
+// tooltipText genera el texto del tooltip al pasar el mouse sobre el punto
+func (p PointInfo) tooltipText(decimals int) string {
+	base := fmt.Sprintf("Seq: %d\nHora: %s\nLatencia: %s\nStatus: %d",
+		p.Result.Seq, p.Result.Timestamp, formatDuration(p.Result.Duration, decimals), p.Result.Status)
+	if failure := p.failureText(); failure != "" {
+		base = failure + "\n" + base // Primero, para que se vea sin leer el resto
 	}
+	switch p.Series {
+	case SeriesRequestsSec:
+		return base + fmt.Sprintf("\nRequests/sec: %.1f\nError rate: %.1f%%", p.RequestsPerSec, p.ErrorRate)
+	case SeriesErrorRate:
+		return base + fmt.Sprintf("\nError rate: %.1f%%\nErrores: %d de %d\nRequests/sec: %.1f", p.ErrorRate, p.Errors, p.ErrorSample, p.RequestsPerSec)
+	case SeriesRollingP95:
+		return base + fmt.Sprintf("\nP95 móvil (últ. %d): %s", p.RollingWindow, formatDuration(p.RollingP95, decimals))
+	default:
+		return base + fmt.Sprintf("\nRequests/sec: %.1f\nError rate: %.1f%%", p.RequestsPerSec, p.ErrorRate)
+	}
+}
 
-	for i, d := range data {
-		x := paddingLeft + (float32(i) * xStep)
-
-		// Posiciones para cada métrica
-		responseY := (size.Height - paddingBottom) - (float32(d.Duration) * yScale)
-
-		// Calcular requests/second
-		requestsPerSec := float64(i+1) / (float64(i+1) * 0.1)
-		if requestsPerSec > 120 {
-			requestsPerSec = 120
+// failureText describe por qué falló la request del punto ("" si fue exitosa)
+func (p PointInfo) failureText() string {
+	if !p.Failed {
+		return ""
+	}
+	d := p.Result
+	reason := "sin respuesta"
+	if d.Status != 0 {
+		reason = fmt.Sprintf("status %d", d.Status)
+		if text := http.StatusText(d.Status); text != "" {
+			reason += " " + text
 		}
-		// Usar escala específica de requests
-		requestsY := (size.Height - paddingBottom) - (float32(requestsPerSec) * requestsScale)
+	}
+	if d.ErrorKind != "" {
+		reason += " (" + d.ErrorKind + ")"
+	}
+	return "FALLÓ: " + reason
+}
 
-		// Error rate acumulativo
-		errorsUpToNow := float64(0)
-		for j := 0; j <= i; j++ {
-			if data[j].Status >= 400 || data[j].Status == 0 {
-				errorsUpToNow++
-			}
-		}
-		currentErrorRate := (errorsUpToNow / float64(i+1)) * 100
-		// Usar escala específica de error rate
-		errorY := (size.Height - paddingBottom) - (float32(currentErrorRate) * errorScale)
+// detail genera el título y el texto del diálogo de detalle al hacer click sobre el punto
+func (p PointInfo) detail() (string, string) {
+	title, text := p.seriesDetail()
+	if failure := p.failureText(); failure != "" {
+		text = failure + "\n\n" + text
+	}
+	if p.Result.ErrorDetail != "" {
+		text += "\n\nError de schema: " + p.Result.ErrorDetail
+	}
+	return title, text
+}
 
-		// Posiciones actuales
-		responsePos := fyne.NewPos(x, responseY)
-		requestsPos := fyne.NewPos(x, requestsY)
-		errorPos := fyne.NewPos(x, errorY)
+// seriesDetail genera el detalle propio de la serie del punto
+func (p PointInfo) seriesDetail() (string, string) {
+	d := p.Result
+	switch p.Series {
+	case SeriesRequestsSec:
+		return "Detalle - Requests/Second", fmt.Sprintf("DETALLE COMPLETO - Requests/Second\n\nSeq: %d\nHora: %s\nRequests/sec: %.1f\nLatencia: %.2f ms\nStatus: %d\nError rate: %.1f%%",
+			d.Seq, d.Timestamp, p.RequestsPerSec, d.Duration, d.Status, p.ErrorRate)
+	case SeriesErrorRate:
+		return "Detalle - Error Rate", fmt.Sprintf("DETALLE COMPLETO - Error Rate\n\nSeq: %d\nHora: %s\nError rate: %.1f%%\nErrores acumulados: %d de %d\nLatencia: %.2f ms\nStatus: %d",
+			d.Seq, d.Timestamp, p.ErrorRate, p.Errors, p.ErrorSample, d.Duration, d.Status)
+	case SeriesRollingP95:
+		return "Detalle - P95 móvil", fmt.Sprintf("DETALLE COMPLETO - P95 móvil\n\nSeq: %d\nHora: %s\nP95 de las últimas %d requests: %.2f ms\nLatencia de esta request: %.2f ms\nStatus: %d",
+			d.Seq, d.Timestamp, p.RollingWindow, p.RollingP95, d.Duration, d.Status)
+	default:
+		return "Detalle - Avg Response", fmt.Sprintf("DETALLE COMPLETO - Avg Response\n\nSeq: %d\nHora: %s\nLatencia: %.2f ms\nStatus: %d\nRequests/sec: %.1f\nError rate: %.1f%%\nTiempo transcurrido: %.1fs",
+			d.Seq, d.Timestamp, d.Duration, d.Status, p.RequestsPerSec, p.ErrorRate, float64(p.Index+1)*0.1)
+	}
+}
 
-		if i > 0 {
-			// Línea tiempo de respuesta (azul)
-			responseLine := canvas.NewLine(responseTimeColor)
-			responseLine.StrokeWidth = lineWidth
-			responseLine.Position1 = prevResponsePos
-			responseLine.Position2 = responsePos
-			objs = append(objs, responseLine)
+// ChartTheme agrupa los colores con los que se dibuja el gráfico
+type ChartTheme struct {
+	Name         string
+	Background   color.NRGBA
+	Axis         color.NRGBA
+	Grid         color.NRGBA
+	Text         color.NRGBA // Leyenda y mensajes
+	ResponseTime color.NRGBA // Serie Avg. response
+	RequestsSec  color.NRGBA // Serie Requests/second
+	ErrorRate    color.NRGBA // Serie Error rate
+}
 
-			// Línea requests/second (amarillo)
-			requestsLine := canvas.NewLine(requestsSecColor)
-			requestsLine.StrokeWidth = lineWidth
-			requestsLine.Position1 = prevRequestsPos
-			requestsLine.Position2 = requestsPos
-			objs = append(objs, requestsLine)
+// Temas predefinidos del gráfico
+var (
+	DarkChartTheme = ChartTheme{
+		Name:         "Oscuro",
+		Background:   color.NRGBA{R: 30, G: 30, B: 35, A: 255},
+		Axis:         color.NRGBA{R: 100, G: 100, B: 100, A: 255},
+		Grid:         color.NRGBA{R: 60, G: 60, B: 60, A: 100},
+		Text:         color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+		ResponseTime: color.NRGBA{R: 0, G: 162, B: 232, A: 255},
+		RequestsSec:  color.NRGBA{R: 255, G: 193, B: 7, A: 255},
+		ErrorRate:    color.NRGBA{R: 237, G: 28, B: 36, A: 255},
+	}
+	LightChartTheme = ChartTheme{
+		Name:         "Claro",
+		Background:   color.NRGBA{R: 250, G: 250, B: 252, A: 255},
+		Axis:         color.NRGBA{R: 90, G: 90, B: 90, A: 255},
+		Grid:         color.NRGBA{R: 200, G: 200, B: 205, A: 160},
+		Text:         color.NRGBA{R: 30, G: 30, B: 30, A: 255},
+		ResponseTime: color.NRGBA{R: 0, G: 114, B: 189, A: 255},
+		RequestsSec:  color.NRGBA{R: 214, G: 150, B: 0, A: 255},
+		ErrorRate:    color.NRGBA{R: 200, G: 20, B: 30, A: 255},
+	}
+	chartThemePresets = []ChartTheme{DarkChartTheme, LightChartTheme}
+)
 
-			// Línea error rate (rojo)
-			errorLine := canvas.NewLine(errorRateColor)
-			errorLine.StrokeWidth = lineWidth
-			errorLine.Position1 = prevErrorPos
-			errorLine.Position2 = errorPos
-			objs = append(objs, errorLine)
+// Claves de preferencias del tema y del hover del gráfico
+const (
+	chartThemeKey         = "chartTheme"
+	chartColorResponseKey = "chartColorResponse"
+	chartColorRequestsKey = "chartColorRequests"
+	chartColorErrorKey    = "chartColorError"
+	chartHoverRadiusKey   = "chartHoverRadius"
+	chartTooltipsKey      = "chartTooltips"
+	chartRollingWindowKey = "chartRollingWindow"
+)
+
+// chartThemeByName retorna el tema predefinido con ese nombre (Oscuro si no existe)
+func chartThemeByName(name string) ChartTheme {
+	for _, t := range chartThemePresets {
+		if t.Name == name {
+			return t
 		}
+	}
+	return DarkChartTheme
+}
 
-		// Puntos para cada línea (solo en vista normal y tiempo real, no en pantalla completa para mejor rendimiento)
-		if r.chart.viewMode != ViewModeFullScreen {
-			// Punto tiempo de respuesta (azul)
-			responseDot := canvas.NewCircle(responseTimeColor)
-			responseDot.Resize(fyne.NewSize(pointSize, pointSize))
-			responseDot.Move(fyne.NewPos(x-pointSize/2, responseY-pointSize/2))
-			objs = append(objs, responseDot)
+// colorToHex convierte un color a "#RRGGBB"
+func colorToHex(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02X%02X%02X", n.R, n.G, n.B)
+}
 
-			// NO agregar puntos para requests/second (línea amarilla) - solo línea continua
+// parseHexColor interpreta un color "#RRGGBB"
+func parseHexColor(s string) (color.NRGBA, bool) {
+	var r, g, b uint8
+	if len(s) != 7 || s[0] != '#' {
+		return color.NRGBA{}, false
+	}
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b); err != nil {
+		return color.NRGBA{}, false
+	}
+	return color.NRGBA{R: r, G: g, B: b, A: 255}, true
+}
 
-			// Punto error rate (rojo) con etiqueta de porcentaje
-			errorDot := canvas.NewCircle(errorRateColor)
-			errorDot.Resize(fyne.NewSize(pointSize+2, pointSize+2)) // Ligeramente más grande
-			errorDot.Move(fyne.NewPos(x-(pointSize+2)/2, errorY-(pointSize+2)/2))
-			objs = append(objs, errorDot)
+// loadChartTheme lee el tema guardado en preferencias, con los colores de series personalizados
+func loadChartTheme(prefs fyne.Preferences) ChartTheme {
+	t := chartThemeByName(prefs.String(chartThemeKey))
+	if c, ok := parseHexColor(prefs.String(chartColorResponseKey)); ok {
+		t.ResponseTime = c
+	}
+	if c, ok := parseHexColor(prefs.String(chartColorRequestsKey)); ok {
+		t.RequestsSec = c
+	}
+	if c, ok := parseHexColor(prefs.String(chartColorErrorKey)); ok {
+		t.ErrorRate = c
+	}
+	return t
+}
 
-			// Etiqueta de porcentaje en el punto rojo
-			if currentErrorRate > 0 { // Solo mostrar si hay errores
-				errorLabel := canvas.NewText(fmt.Sprintf("%.1f%%", currentErrorRate), errorRateColor)
-				errorLabel.TextSize = 8
-				errorLabel.Alignment = fyne.TextAlignCenter
-				errorLabel.Move(fyne.NewPos(x-12, errorY-18)) // Encima del punto
-				objs = append(objs, errorLabel)
-			}
-		}
-
-		// Etiqueta eje X (adaptada según modo de vista)
-		lblText := fmt.Sprintf("#%d", d.Seq)
-		showLabel := false
+// saveChartTheme guarda el tema y los colores de series en preferencias
+func saveChartTheme(prefs fyne.Preferences, t ChartTheme) {
+	prefs.SetString(chartThemeKey, t.Name)
+	prefs.SetString(chartColorResponseKey, colorToHex(t.ResponseTime))
+	prefs.SetString(chartColorRequestsKey, colorToHex(t.RequestsSec))
+	prefs.SetString(chartColorErrorKey, colorToHex(t.ErrorRate))
+}
 
-		switch r.chart.viewMode {
-		case ViewModeNormal:
-			// En modo normal, mostrar todos si hay pocos, o cada N si hay muchos
-			if len(data) <= 5 {
-				lblText = d.Timestamp
-				showLabel = true
-			} else {
-				showLabel = i%2 == 0 || i == len(data)-1
-			}
-		case ViewModeRealTime:
-			// En tiempo real, mostrar menos etiquetas
-			showLabel = i%5 == 0 || i == len(data)-1
-		case ViewModeFullScreen:
-			// En pantalla completa, mostrar aún menos etiquetas
-			showLabel = i%10 == 0 || i == len(data)-1
-			lblText = d.Timestamp // Mostrar tiempo en lugar de secuencia
-		}
+type ChartWidget struct {
+	widget.BaseWidget
+	Data             []BenchmarkResult
+	tooltip          *widget.Label
+	tooltipBg        *canvas.Rectangle
+	tooltipContainer *fyne.Container
+	points           []PointInfo // Información de puntos para hover
+	lastMousePos     fyne.Position
+	hoverTimeout     *time.Timer
+	viewMode         ViewMode
+	startTime        time.Time
+	lastUpdateTime   time.Time
+	parent           *fyne.Container // Referencia al contenedor padre para cambio de modo
+	slowThresholdMs  float64         // SLA de latencia (0 = sin línea de umbral)
+	latencyGradient  bool            // Colorear la línea de latencia de verde (rápido) a rojo (lento)
+	theme            ChartTheme      // Colores de fondo, ejes y series
+	successCfg       RequestConfig   // Criterio de éxito para el error rate (solo campos SuccessStatus* y SeparateRateLimited)
+	errorRateWindow  int             // Error rate sobre las últimas N requests (0 = acumulado)
+	peakSampling     bool            // Al muestrear, conservar el punto más lento de cada tramo en lugar del primero
+	latencyMetric    LatencyMetric   // Latencia graficada: headers (Duration) o último byte (TTLBMs)
+	xAxisMode        XAxisMode       // Etiquetas del eje X
+	hoverRadius      float32         // Radio en px para detectar el punto bajo el mouse
+	tooltipsDisabled bool            // No mostrar tooltips al pasar el mouse (el click sigue abriendo el detalle)
+	rollingP95       bool            // Dibujar la serie de P95 móvil
+	rollingWindow    int             // Requests de la ventana del P95 móvil
+	latencyDecimals  int             // Decimales de las latencias en ms de los ejes y los tooltips
+}
 
-		if showLabel {
-			xLbl := canvas.NewText(lblText, axisColor)
-			xLbl.TextSize = 9
-			xLbl.Alignment = fyne.TextAlignCenter
-			xLbl.Move(fyne.NewPos(x-15, size.Height-paddingBottom+5))
-			objs = append(objs, xLbl)
-		}
+// LatencyMetric elige qué medida de latencia grafica el ChartWidget
+type LatencyMetric int
 
-		// Botones de detalle para cada métrica (solo en modo normal para mejor rendimiento)
-		if r.chart.viewMode == ViewModeNormal {
-			win := fyne.CurrentApp().Driver().AllWindows()[0]
-
-			// Botón para Avg Response (azul)
-			responseInfoTxt := fmt.Sprintf("DETALLE COMPLETO - Avg Response\n\nSeq: %d\nHora: %s\nLatencia: %.2f ms\nStatus: %d\nRequests/sec: %.1f\nError rate: %.1f%%\nTiempo transcurrido: %.1fs",
-				d.Seq, d.Timestamp, d.Duration, d.Status, requestsPerSec, currentErrorRate, float64(i+1)*0.1)
-			responseBtn := widget.NewButton("", nil)
-			responseBtn.OnTapped = func() { dialog.ShowInformation("Detalle - Avg Response", responseInfoTxt, win) }
-			responseBtn.Resize(fyne.NewSize(15, 15))
-			responseBtn.Move(fyne.NewPos(x-7, responseY-7))
-			responseBtn.Importance = widget.LowImportance
-			objs = append(objs, responseBtn)
-
-			// Botón para Requests/sec (amarillo)
-			requestsInfoTxt := fmt.Sprintf("DETALLE COMPLETO - Requests/Second\n\nSeq: %d\nHora: %s\nRequests/sec: %.1f\nLatencia: %.2f ms\nStatus: %d\nError rate: %.1f%%",
-				d.Seq, d.Timestamp, requestsPerSec, d.Duration, d.Status, currentErrorRate)
-			requestsBtn := widget.NewButton("", nil)
-			requestsBtn.OnTapped = func() { dialog.ShowInformation("Detalle - Requests/Second", requestsInfoTxt, win) }
-			requestsBtn.Resize(fyne.NewSize(15, 15))
-			requestsBtn.Move(fyne.NewPos(x-7, requestsY-7))
-			requestsBtn.Importance = widget.LowImportance
-			objs = append(objs, requestsBtn)
-
-			// Botón para Error rate (rojo)
-			errorInfoTxt := fmt.Sprintf("DETALLE COMPLETO - Error Rate\n\nSeq: %d\nHora: %s\nError rate: %.1f%%\nErrores acumulados: %.0f de %d\nLatencia: %.2f ms\nStatus: %d",
-				d.Seq, d.Timestamp, currentErrorRate, errorsUpToNow, i+1, d.Duration, d.Status)
-			errorBtn := widget.NewButton("", nil)
-			errorBtn.OnTapped = func() { dialog.ShowInformation("Detalle - Error Rate", errorInfoTxt, win) }
-			errorBtn.Resize(fyne.NewSize(15, 15))
-			errorBtn.Move(fyne.NewPos(x-7, errorY-7))
-			errorBtn.Importance = widget.LowImportance
-			objs = append(objs, errorBtn)
-		}
-
-		// Guardar información de todos los puntos para hover (siempre, independientemente del modo)
-		// Punto azul (response time)
-		extraInfo := fmt.Sprintf("\nRequests/sec: %.1f\nError rate: %.1f%%", requestsPerSec, currentErrorRate)
-		pointInfoResponse := PointInfo{
-			X:         x,
-			Y:         responseY,
-			Result:    d,
-			ExtraData: extraInfo,
-		}
-		r.chart.points = append(r.chart.points, pointInfoResponse)
-
-		// Punto amarillo (requests/second)
-		requestsInfo := fmt.Sprintf("\nRequests/sec: %.1f\nLatencia: %.2f ms\nError rate: %.1f%%", requestsPerSec, d.Duration, currentErrorRate)
-		pointInfoRequests := PointInfo{
-			X:         x,
-			Y:         requestsY,
-			Result:    d,
-			ExtraData: requestsInfo,
-		}
-		r.chart.points = append(r.chart.points, pointInfoRequests)
-
-		// Punto rojo (error rate)
-		errorInfo := fmt.Sprintf("\nError rate: %.1f%%\nErrores: %.0f de %d\nRequests/sec: %.1f\nLatencia: %.2f ms", currentErrorRate, errorsUpToNow, i+1, requestsPerSec, d.Duration)
-		pointInfoError := PointInfo{
-			X:         x,
-			Y:         errorY,
-			Result:    d,
-			ExtraData: errorInfo,
-		}
-		r.chart.points = append(r.chart.points, pointInfoError)
+const (
+	LatencyMetricHeaders LatencyMetric = iota // Hasta recibir los headers (Duration)
+	LatencyMetricTTLB                         // Hasta leer el último byte del body
+)
 
-		// Actualizar posiciones previas para las próximas líneas
-		prevResponsePos = responsePos
-		prevRequestsPos = requestsPos
-		prevErrorPos = errorPos
-	}
+// XAxisMode elige las etiquetas del eje X del ChartWidget
+type XAxisMode int
 
-	// Agregar leyenda
-	legendY := paddingTop + 10
-	legendItems := []struct {
-		color color.NRGBA
-		text  string
-	}{
-		{responseTimeColor, "Avg. response"},
-		{requestsSecColor, "Requests/second"},
-		{errorRateColor, "Error rate"},
-	}
+const (
+	XAxisAuto      XAxisMode = iota // Según el modo de vista: #seq u hora
+	XAxisSequence                   // Número de request (#seq)
+	XAxisWallClock                  // Hora de inicio de la request (HH:MM:SS)
+	XAxisElapsed                    // Segundos transcurridos desde el primer resultado
+)
 
-	for i, item := range legendItems {
-		legendX := paddingLeft + float32(i*120)
+func NewChartWidget() *ChartWidget {
+	c := &ChartWidget{}
+	c.ExtendBaseWidget(c)
+	c.viewMode = ViewModeNormal
+	c.startTime = time.Now()
+	c.theme = DarkChartTheme
+	c.hoverRadius = DefaultHoverRadius
+	c.rollingWindow = DefaultRollingWindow
+	c.latencyDecimals = DefaultLatencyDecimals
 
-		// Línea de muestra
-		legendLine := canvas.NewLine(item.color)
-		legendLine.StrokeWidth = 2
-		legendLine.Position1 = fyne.NewPos(legendX, legendY)
-		legendLine.Position2 = fyne.NewPos(legendX+15, legendY)
-		objs = append(objs, legendLine)
+	// Crear tooltip
+	c.tooltip = widget.NewLabel("")
+	c.tooltip.TextStyle = fyne.TextStyle{Bold: true}
+	c.tooltip.Alignment = fyne.TextAlignLeading
+	c.tooltip.Wrapping = fyne.TextWrapWord
 
-		// Texto de leyenda
-		legendText := canvas.NewText(item.text, color.White)
-		legendText.TextSize = 9
-		legendText.Move(fyne.NewPos(legendX+20, legendY-6))
-		objs = append(objs, legendText)
-	}
+	c.tooltipBg = canvas.NewRectangle(color.NRGBA{R: 50, G: 50, B: 50, A: 240})
+	c.tooltipContainer = container.NewStack(c.tooltipBg, container.NewPadded(c.tooltip))
+	c.tooltipContainer.Hide()
 
-	return objs
+	return c
 }
 
-// --- LÓGICA DE NEGOCIO (Sin cambios en esta sección) ---
-
-func generateHMACSignature(secretKey, message string) string {
-	h := hmac.New(sha256.New, []byte(secretKey))
-	h.Write([]byte(message))
-	return hex.EncodeToString(h.Sum(nil))
+func (c *ChartWidget) SetData(d []BenchmarkResult) {
+	c.Data = d
+	c.dataChanged()
 }
 
-func runLoadTest(cfg RequestConfig, progress func(float64), cancelChan <-chan bool, realtimeUpdate func([]BenchmarkResult, BenchmarkStats)) ([]BenchmarkResult, BenchmarkStats) {
-	results := make([]BenchmarkResult, 0)
-	resultsMutex := sync.Mutex{}
-
-	successCount := 0
-	var totalDuration float64
-	minDur := 999999.0
-	maxDur := 0.0
-
-	startTime := time.Now()
-	var endTime time.Time
-
-	// Determinar modo: por tiempo o por cantidad
-	useDuration := cfg.Duration > 0
-	if useDuration {
-		endTime = startTime.Add(time.Duration(cfg.Duration) * time.Second)
+// AppendData agrega los resultados nuevos de una actualización en tiempo real a los ya mostrados,
+// conservando como máximo keep (los más recientes; 0 = todos)
+func (c *ChartWidget) AppendData(d []BenchmarkResult, keep int) {
+	c.Data = append(c.Data, d...)
+	if keep > 0 && len(c.Data) >= 2*keep {
+		// Igual que el motor: se descarta de a bloques para no copiar en cada actualización
+		c.Data = c.Data[:copy(c.Data, c.Data[len(c.Data)-keep:])]
 	}
+	c.dataChanged()
+}
 
-	// WaitGroup para sincronizar usuarios concurrentes
-	var wg sync.WaitGroup
-
-	// Función que ejecuta requests para un usuario
-	executeUser := func(userID int) {
-		defer wg.Done()
-
-		client := &http.Client{Timeout: 10 * time.Second}
-		requestCount := 0
-
-		for {
-			// Verificar cancelación
-			select {
-			case <-cancelChan:
-				return
-			default:
-			}
-
-			// Verificar condición de parada ANTES de iniciar cualquier request
-			if useDuration {
-				// Validación estricta: NO iniciar request si el tiempo ha expirado
-				if time.Now().After(endTime) {
-					break
-				}
-			} else {
-				resultsMutex.Lock()
-				currentTotal := len(results)
-				resultsMutex.Unlock()
-
-				if currentTotal >= cfg.Count {
-					break
-				}
-			}
+// dataChanged recalcula los puntos y ajusta el modo de vista tras cambiar los datos
+func (c *ChartWidget) dataChanged() {
+	d := c.Data
+	c.points = nil // Reset puntos para recalcular
+	c.lastUpdateTime = time.Now()
 
-			// Doble verificación para modo por tiempo: asegurar que hay tiempo suficiente
-			// para completar la request (estimado 10 segundos de timeout máximo)
-			if useDuration && time.Now().Add(10*time.Second).After(endTime) {
-				// Si no hay tiempo suficiente para completar la request, terminar
-				break
-			}
+	// Cambiar modo de vista automáticamente basado en cantidad de datos
+	if len(d) >= FullScreenThreshold && c.viewMode == ViewModeNormal {
+		c.viewMode = ViewModeRealTime
+		// Si hay muchos puntos, sugerir pantalla completa
+		if len(d) >= 30 {
+			c.viewMode = ViewModeFullScreen
+		}
+	}
 
-			// Ejecutar request
-			var bodyReader io.Reader
-			if cfg.Body != "" {
-				bodyReader = strings.NewReader(cfg.Body)
-			}
+	c.Refresh() // Fuerza el repintado
+}
 
-			req, err := http.NewRequest(cfg.Method, cfg.URL, bodyReader)
-			if err == nil {
-				timestamp := time.Now().Format(time.RFC3339)
-				req.Header.Set("X-Timestamp", timestamp)
+// SetViewMode permite cambiar el modo de vista manualmente
+func (c *ChartWidget) SetViewMode(mode ViewMode) {
+	c.viewMode = mode
+	c.Refresh()
+}
 
-				if cfg.ContentType != "" {
-					req.Header.Set("Content-Type", cfg.ContentType)
-				}
+// SetTheme cambia los colores del gráfico
+func (c *ChartWidget) SetTheme(t ChartTheme) {
+	c.theme = t
+	c.Refresh()
+}
 
-				if cfg.Headers != "" {
-					for _, line := range strings.Split(cfg.Headers, "\n") {
-						parts := strings.SplitN(line, ":", 2)
-						if len(parts) == 2 {
-							req.Header.Set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
-						}
-					}
-				}
+// Theme retorna el tema actual del gráfico
+func (c *ChartWidget) Theme() ChartTheme {
+	return c.theme
+}
 
-				if cfg.User != "" && cfg.Secret != "" {
-					sig := generateHMACSignature(cfg.Secret, timestamp)
-					req.Header.Set("Authorization", fmt.Sprintf("HMAC %s:%s", cfg.User, sig))
-				}
+// SetSuccessCriteria define qué status cuentan como exitosos al calcular el error rate
+func (c *ChartWidget) SetSuccessCriteria(cfg RequestConfig) {
+	c.successCfg = RequestConfig{SuccessStatusMin: cfg.SuccessStatusMin, SuccessStatusMax: cfg.SuccessStatusMax, SeparateRateLimited: cfg.SeparateRateLimited}
+	c.Refresh()
+}
 
-				start := time.Now()
-				resp, err := client.Do(req)
-				duration := float64(time.Since(start).Milliseconds())
+// SetSlowThreshold define el SLA de latencia a dibujar como línea de umbral (0 = desactivado)
+func (c *ChartWidget) SetSlowThreshold(ms int) {
+	c.slowThresholdMs = float64(ms)
+	c.Refresh()
+}
 
-				status := 0
-				if err == nil {
-					status = resp.StatusCode
-					resp.Body.Close()
-					if status >= 200 && status < 400 {
-						resultsMutex.Lock()
-						successCount++
-						resultsMutex.Unlock()
-					}
-				}
+// SetLatencyGradient alterna entre la línea azul clásica y el gradiente verde-rojo
+func (c *ChartWidget) SetLatencyGradient(enabled bool) {
+	c.latencyGradient = enabled
+	c.Refresh()
+}
 
-				// Guardar resultado de forma segura
-				resultsMutex.Lock()
-				totalDuration += duration
-				if duration < minDur {
-					minDur = duration
-				}
-				if duration > maxDur {
-					maxDur = duration
-				}
+// SetLatencyMetric elige si la línea de latencia muestra el tiempo hasta los headers o hasta el último byte
+func (c *ChartWidget) SetLatencyMetric(m LatencyMetric) {
+	c.latencyMetric = m
+	c.Refresh()
+}
 
-				requestCount++
-				results = append(results, BenchmarkResult{
-					Seq:       len(results) + 1,
-					Timestamp: start.Format("15:04:05"),
-					Duration:  duration,
-					Status:    status,
-				})
+// SetXAxisMode elige las etiquetas del eje X, iguales en todos los modos de vista salvo en XAxisAuto
+func (c *ChartWidget) SetXAxisMode(m XAxisMode) {
+	c.xAxisMode = m
+	c.Refresh()
+}
 
-				currentTotal := len(results)
+// xAxisLabel retorna la etiqueta del eje X de d; first es el primer resultado, referencia del tiempo transcurrido
+func (c *ChartWidget) xAxisLabel(d, first BenchmarkResult) string {
+	switch c.xAxisMode {
+	case XAxisWallClock:
+		return d.Timestamp
+	case XAxisElapsed:
+		if !d.StartedAt.IsZero() && !first.StartedAt.IsZero() {
+			return fmt.Sprintf("%.1fs", d.StartedAt.Sub(first.StartedAt).Seconds())
+		}
+	}
+	return fmt.Sprintf("#%d", d.Seq)
+}
 
-				// Copiar resultados para actualización en tiempo real
-				resultsCopy := make([]BenchmarkResult, len(results))
-				copy(resultsCopy, results)
-				resultsMutex.Unlock()
+// plotData retorna los datos con Duration reemplazada por la métrica elegida. Los modos sin body
+// (WebSocket, gRPC) no tienen TTLB, por eso nunca se grafica menos que Duration.
+func (c *ChartWidget) plotData() []BenchmarkResult {
+	if c.latencyMetric != LatencyMetricTTLB {
+		return c.Data
+	}
+	data := make([]BenchmarkResult, len(c.Data))
+	for i, d := range c.Data {
+		d.Duration = max(d.Duration, d.TTLBMs)
+		data[i] = d
+	}
+	return data
+}
 
-				// Actualizar progreso
-				if progress != nil {
-					var progressValue float64
-					if useDuration {
-						elapsed := time.Since(startTime).Seconds()
-						progressValue = elapsed / float64(cfg.Duration)
-					} else {
-						progressValue = float64(currentTotal) / float64(cfg.Count)
-					}
-					progress(progressValue)
-				}
+// SetPeakSampling elige el muestreo de las vistas tiempo real y pantalla completa: el primer
+// punto de cada tramo o el de mayor latencia, para que los picos no desaparezcan
+func (c *ChartWidget) SetPeakSampling(enabled bool) {
+	c.peakSampling = enabled
+	c.Refresh()
+}
 
-				// Actualizar UI en tiempo real (throttle cada 5 requests)
-				if realtimeUpdate != nil && currentTotal%5 == 0 {
-					// Calcular estadísticas parciales
-					partialStats := BenchmarkStats{
-						Total:         currentTotal,
-						Success:       successCount,
-						Min:           minDur,
-						Max:           maxDur,
-						TotalDuration: totalDuration,
-					}
-					if partialStats.Total > 0 {
-						partialStats.Avg = totalDuration / float64(partialStats.Total)
-						partialStats.ErrorRate = ((partialStats.Total - partialStats.Success) * 100) / partialStats.Total
-						actualDuration := time.Since(startTime).Seconds()
-						partialStats.RequestsPerSecond = float64(partialStats.Total) / actualDuration
-					}
-					realtimeUpdate(resultsCopy, partialStats)
+// downsample toma un punto cada step: el primero de cada tramo o, con peaks, el más lento
+func downsample(data []BenchmarkResult, step int, peaks bool) []BenchmarkResult {
+	sampled := make([]BenchmarkResult, 0, len(data)/step+1)
+	for i := 0; i < len(data); i += step {
+		pick := i
+		if peaks {
+			for j := i + 1; j < i+step && j < len(data); j++ {
+				if data[j].Duration > data[pick].Duration {
+					pick = j
 				}
 			}
-
-			// Pequeña pausa para no saturar
-			time.Sleep(10 * time.Millisecond)
 		}
+		sampled = append(sampled, data[pick])
 	}
+	return sampled
+}
 
-	// Lanzar usuarios concurrentes
-	users := cfg.ConcurrentUsers
-	if users < 1 {
-		users = 1
-	}
+// SetErrorRateWindow calcula la línea de error rate sobre las últimas n requests (0 = acumulado)
+func (c *ChartWidget) SetErrorRateWindow(n int) {
+	c.errorRateWindow = n
+	c.Refresh()
+}
 
-	for i := 0; i < users; i++ {
-		wg.Add(1)
-		go executeUser(i)
-	}
+// SetRollingP95 muestra u oculta la serie del P95 sobre una ventana móvil de requests, la que suelen
+// mostrar los dashboards de SRE: suaviza el ruido de la latencia individual y se compara directo con el SLO
+func (c *ChartWidget) SetRollingP95(enabled bool) {
+	c.rollingP95 = enabled
+	c.Refresh()
+}
 
-	// Esperar a que terminen todos los usuarios
-	wg.Wait()
+// SetLatencyDecimals cambia los decimales de las latencias en ms de los ejes y los tooltips
+func (c *ChartWidget) SetLatencyDecimals(n int) {
+	c.latencyDecimals = n
+	c.Refresh()
+}
 
-	// Calcular percentiles
-	resultsMutex.Lock()
-	durations := make([]float64, len(results))
-	for i, r := range results {
-		durations[i] = r.Duration
+// SetRollingWindow cambia la cantidad de requests de la ventana del P95 móvil (<= 0 = DefaultRollingWindow)
+func (c *ChartWidget) SetRollingWindow(n int) {
+	if n <= 0 {
+		n = DefaultRollingWindow
 	}
-	resultsMutex.Unlock()
+	c.rollingWindow = n
+	c.Refresh()
+}
 
-	// Ordenar para percentiles
-	for i := 0; i < len(durations); i++ {
-		for j := i + 1; j < len(durations); j++ {
-			if durations[i] > durations[j] {
-				durations[i], durations[j] = durations[j], durations[i]
-			}
+// rollingPercentiles retorna, para cada punto de visible, el percentil p (0-1) de las últimas window
+// requests de all que terminan en ese punto. visible es un subconjunto de all (ambos ordenados por Seq),
+// así el valor no depende del muestreo de la vista
+func rollingPercentiles(all, visible []BenchmarkResult, window int, p float64) []float64 {
+	values := make([]float64, len(visible))
+	buf := make([]float64, 0, window)
+	for i, d := range visible {
+		end := sort.Search(len(all), func(j int) bool { return all[j].Seq > d.Seq })
+		buf = buf[:0]
+		for _, a := range all[max(0, end-window):end] {
+			buf = append(buf, a.Duration)
 		}
+		sort.Float64s(buf)
+		values[i] = percentile(buf, p)
 	}
+	return values
+}
 
-	stats := BenchmarkStats{
-		Total:         len(results),
-		Success:       successCount,
-		Min:           minDur,
-		Max:           maxDur,
-		TotalDuration: totalDuration,
+// rollingErrorCounts retorna, para cada punto de visible, los errores entre las últimas window requests
+// de all que terminan en ese punto y cuántas requests abarca la ventana. Como rollingPercentiles, cuenta
+// sobre all por Seq para que el error rate no dependa del muestreo ni del modo de vista
+func rollingErrorCounts(all, visible []BenchmarkResult, window int, cfg RequestConfig) ([]int, []int) {
+	// errorsBefore[k] = errores entre all[:k]
+	errorsBefore := make([]int, len(all)+1)
+	for k, a := range all {
+		errorsBefore[k+1] = errorsBefore[k]
+		if countsAsError(a, cfg) {
+			errorsBefore[k+1]++
+		}
 	}
+	errs := make([]int, len(visible))
+	samples := make([]int, len(visible))
+	for i, d := range visible {
+		end := sort.Search(len(all), func(j int) bool { return all[j].Seq > d.Seq })
+		start := max(0, end-window)
+		errs[i], samples[i] = errorsBefore[end]-errorsBefore[start], end-start
+	}
+	return errs, samples
+}
 
-	if stats.Total > 0 {
-		stats.Avg = totalDuration / float64(stats.Total)
-		stats.ErrorRate = ((stats.Total - stats.Success) * 100) / stats.Total
-
-		// Calcular requests/second basado en tiempo real transcurrido
-		actualDuration := time.Since(startTime).Seconds()
-		stats.RequestsPerSecond = float64(stats.Total) / actualDuration
+// GetViewMode retorna el modo actual
+func (c *ChartWidget) GetViewMode() ViewMode {
+	return c.viewMode
+}
 
-		// Calcular percentiles
-		if len(durations) > 0 {
-			p90Index := int(0.9 * float64(len(durations)))
-			p95Index := int(0.95 * float64(len(durations)))
-			p99Index := int(0.99 * float64(len(durations)))
+func (c *ChartWidget) CreateRenderer() fyne.WidgetRenderer {
+	return &chartRenderer{chart: c}
+}
 
-			if p90Index >= len(durations) {
-				p90Index = len(durations) - 1
-			}
-			if p95Index >= len(durations) {
-				p95Index = len(durations) - 1
-			}
-			if p99Index >= len(durations) {
-				p99Index = len(durations) - 1
-			}
+// Implementar desktop.Hoverable para detectar movimiento del mouse
+func (c *ChartWidget) MouseIn(event *desktop.MouseEvent) {
+	c.updateTooltip(event.Position)
+}
 
-			stats.P90 = durations[p90Index]
-			stats.P95 = durations[p95Index]
-			stats.P99 = durations[p99Index]
-		}
-	} else {
-		stats.Min = 0
+// SetHoverRadius cambia el radio en px dentro del cual el mouse (o el click) toma un punto
+func (c *ChartWidget) SetHoverRadius(px float32) {
+	if px <= 0 {
+		px = DefaultHoverRadius
 	}
-
-	return results, stats
+	c.hoverRadius = px
 }
 
-// parseCurlCommand extrae información de un comando cURL
-func parseCurlCommand(curl string, urlEntry *widget.Entry, methodSelect *widget.Select, headersEntry *widget.Entry, bodyEntry *widget.Entry) {
-	curl = strings.TrimSpace(curl)
-
-	// Normalizar saltos de línea primero para facilitar el parsing
-	curl = strings.ReplaceAll(curl, "\r\n", " ")
-	curl = strings.ReplaceAll(curl, "\n", " ")
-	curl = strings.ReplaceAll(curl, "\\", "")
+// SetTooltipsEnabled activa o desactiva los tooltips (pueden distraer durante una presentación)
+func (c *ChartWidget) SetTooltipsEnabled(enabled bool) {
+	c.tooltipsDisabled = !enabled
+	if !enabled {
+		c.hideTooltip()
+	}
+}
 
-	var foundURL bool
+func (c *ChartWidget) MouseMoved(event *desktop.MouseEvent) {
+	c.updateTooltip(event.Position)
+}
 
-	// Extraer URL - mejorado para manejar URLs con comillas
-	if strings.Contains(curl, "curl") {
-		// Buscar URL entre comillas primero
-		for _, quote := range []string{"'", "\"", ""} {
-			if foundURL {
-				break
-			}
-			if quote != "" {
-				pattern := quote + "http"
-				if idx := strings.Index(curl, pattern); idx != -1 {
-					startPos := idx + len(quote)
-					endIdx := strings.Index(curl[startPos:], quote)
-					if endIdx > 0 {
-						url := curl[startPos : startPos+endIdx]
-						urlEntry.SetText(url)
-						foundURL = true
-					}
-				}
-			} else {
-				// Sin comillas - buscar por espacios
-				parts := strings.Fields(curl)
-				for i, part := range parts {
-					if strings.HasPrefix(part, "http") {
-						urlEntry.SetText(strings.Trim(part, `"'`))
-						foundURL = true
-						break
-					}
-					if part == "curl" && i+1 < len(parts) {
-						nextPart := parts[i+1]
-						if strings.HasPrefix(nextPart, "http") {
-							urlEntry.SetText(strings.Trim(nextPart, `"'`))
-							foundURL = true
-							break
-						}
-					}
-				}
-			}
-		}
-	}
+func (c *ChartWidget) MouseOut() {
+	c.hideTooltip()
+}
 
-	// Extraer método (-X o --request) - SIEMPRE intentar extraer
-	var methodFound bool
-	if idx := strings.Index(curl, "-X "); idx != -1 {
-		fields := strings.Fields(curl[idx:])
-		if len(fields) > 1 {
-			method := strings.ToUpper(strings.Trim(fields[1], `"'`))
-			methodSelect.SetSelected(method)
-			methodFound = true
-		}
+// Tapped muestra el detalle completo del punto más cercano al click (en todos los modos de vista)
+func (c *ChartWidget) Tapped(event *fyne.PointEvent) {
+	point, ok := c.nearestPoint(event.Position)
+	if !ok {
+		return
 	}
+	win := fyne.CurrentApp().Driver().AllWindows()[0]
+	title, text := point.detail()
+	dialog.ShowInformation(title, text, win)
+}
 
-	if !methodFound {
-		if idx := strings.Index(curl, "--request "); idx != -1 {
-			fields := strings.Fields(curl[idx:])
-			if len(fields) > 1 {
-				method := strings.ToUpper(strings.Trim(fields[1], `"'`))
-				methodSelect.SetSelected(method)
-				methodFound = true
-			}
+// nearestPoint busca el punto más cercano a pos dentro de hoverRadius. Donde las series se cruzan
+// hay varios puntos en el radio: gana el de menor distancia, no el primero del recorrido.
+func (c *ChartWidget) nearestPoint(pos fyne.Position) (PointInfo, bool) {
+	best := -1
+	bestDistance := c.hoverRadius * c.hoverRadius
+	for i, point := range c.points {
+		dx := pos.X - point.X
+		dy := pos.Y - point.Y
+		if distance := dx*dx + dy*dy; distance <= bestDistance {
+			best = i
+			bestDistance = distance
 		}
 	}
-
-	// Si no se encontró método explícito pero hay --data o -d, es POST
-	if !methodFound {
-		if strings.Contains(curl, "--data") || strings.Contains(curl, "-d ") {
-			methodSelect.SetSelected("POST")
-		}
+	if best < 0 {
+		return PointInfo{}, false
 	}
+	return c.points[best], true
+}
 
-	// Extraer headers (-H o --header)
-	headers := []string{}
-
-	// Buscar todos los -H y --header
-	searchPatterns := []string{"-H '", `-H "`, "--header '", `--header "`}
-
-	for _, pattern := range searchPatterns {
-		startIdx := 0
-		for {
-			idx := strings.Index(curl[startIdx:], pattern)
-			if idx == -1 {
-				break
-			}
-
-			actualIdx := startIdx + idx
-			startPos := actualIdx + len(pattern)
-
-			// Determinar el delimitador de cierre
-			var endDelimiter string
-			if strings.HasSuffix(pattern, "'") {
-				endDelimiter = "'"
-			} else {
-				endDelimiter = `"`
-			}
-
-			// Buscar el cierre del header
-			endIdx := strings.Index(curl[startPos:], endDelimiter)
-			if endIdx > 0 {
-				headerContent := curl[startPos : startPos+endIdx]
-				if headerContent != "" {
-					headers = append(headers, headerContent)
-				}
-			}
-
-			startIdx = actualIdx + len(pattern) + 1
-			if startIdx >= len(curl) {
-				break
-			}
-		}
+// Actualizar tooltip basado en la posición del mouse
+func (c *ChartWidget) updateTooltip(pos fyne.Position) {
+	c.lastMousePos = pos
+	if c.tooltipsDisabled {
+		return
 	}
 
-	if len(headers) > 0 {
-		headersEntry.SetText(strings.Join(headers, "\n"))
+	// Cancelar timeout anterior si existe
+	if c.hoverTimeout != nil {
+		c.hoverTimeout.Stop()
 	}
 
-	// Extraer body (-d, --data, --data-raw)
-	if idx := strings.Index(curl, "--data-raw '"); idx != -1 {
-		start := idx + len("--data-raw '")
-		end := strings.Index(curl[start:], "'")
-		if end > 0 {
-			bodyEntry.SetText(curl[start : start+end])
-			return
-		}
-	}
-	if idx := strings.Index(curl, `--data-raw "`); idx != -1 {
-		start := idx + len(`--data-raw "`)
-		end := strings.Index(curl[start:], `"`)
-		if end > 0 {
-			bodyEntry.SetText(curl[start : start+end])
-			return
-		}
-	}
-	if idx := strings.Index(curl, "-d '"); idx != -1 {
-		start := idx + len("-d '")
-		end := strings.Index(curl[start:], "'")
-		if end > 0 {
-			bodyEntry.SetText(curl[start : start+end])
-			return
-		}
-	}
-	if idx := strings.Index(curl, `-d "`); idx != -1 {
-		start := idx + len(`-d "`)
-		end := strings.Index(curl[start:], `"`)
-		if end > 0 {
-			bodyEntry.SetText(curl[start : start+end])
-		}
+	// Buscar punto cercano
+	if point, ok := c.nearestPoint(pos); ok {
+		c.showTooltip(point, pos)
+		return
 	}
-} // executeRequest ejecuta un single HTTP request
-func executeRequest(cfg RequestConfig, seq int) BenchmarkResult {
-	client := &http.Client{Timeout: 10 * time.Second}
 
-	var bodyReader io.Reader
-	if cfg.Body != "" {
-		bodyReader = strings.NewReader(cfg.Body)
-	}
+	// Si no hay punto cercano, ocultar después de un delay
+	c.hoverTimeout = time.AfterFunc(100*time.Millisecond, func() {
+		fyne.Do(func() {
+			c.hideTooltip()
+		})
+	})
+}
 
-	req, err := http.NewRequest(cfg.Method, cfg.URL, bodyReader)
-	if err != nil {
-		return BenchmarkResult{Seq: seq, Timestamp: time.Now().Format("15:04:05"), Duration: 0, Status: 0}
+func (c *ChartWidget) showTooltip(point PointInfo, mousePos fyne.Position) {
+	if c.tooltip == nil {
+		return
 	}
 
-	timestamp := time.Now().Format(time.RFC3339)
-	req.Header.Set("X-Timestamp", timestamp)
+	// Usar fyne.Do para asegurar que la actualización ocurra en el hilo principal
+	fyne.Do(func() {
+		c.tooltip.SetText(point.tooltipText(c.latencyDecimals))
+		height := float32(80)
+		if point.Failed {
+			// Borde rojo y una línea más para el motivo de la falla
+			c.tooltipBg.StrokeColor = c.theme.ErrorRate
+			c.tooltipBg.StrokeWidth = 2
+			height += 20
+		} else {
+			c.tooltipBg.StrokeWidth = 0
+		}
+		c.tooltipBg.Refresh()
 
-	if cfg.ContentType != "" {
-		req.Header.Set("Content-Type", cfg.ContentType)
-	}
+		// Calcular posición del tooltip (offset para no cubrir el punto)
+		tooltipX := mousePos.X + 15
+		tooltipY := mousePos.Y - 10
 
-	if cfg.Headers != "" {
-		for _, line := range strings.Split(cfg.Headers, "\n") {
-			parts := strings.SplitN(line, ":", 2)
-			if len(parts) == 2 {
-				req.Header.Set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
-			}
+		// Ajustar si se sale del widget
+		if tooltipX+150 > c.Size().Width {
+			tooltipX = mousePos.X - 165
+		}
+		if tooltipY < 0 {
+			tooltipY = mousePos.Y + 20
 		}
-	}
 
-	if cfg.User != "" && cfg.Secret != "" {
-		sig := generateHMACSignature(cfg.Secret, timestamp)
-		req.Header.Set("Authorization", fmt.Sprintf("HMAC %s:%s", cfg.User, sig))
-	}
+		// Redimensionar y posicionar
+		c.tooltipContainer.Resize(fyne.NewSize(150, height))
+		c.tooltipContainer.Move(fyne.NewPos(tooltipX, tooltipY))
+		c.tooltipContainer.Show()
+		c.Refresh()
+	})
+}
 
-	start := time.Now()
-	resp, err := client.Do(req)
-	duration := float64(time.Since(start).Milliseconds())
+func (c *ChartWidget) hideTooltip() {
+	fyne.Do(func() {
+		if c.tooltipContainer != nil {
+			c.tooltipContainer.Hide()
+			c.Refresh()
+		}
+	})
+}
 
-	status := 0
-	if err == nil {
-		status = resp.StatusCode
-		resp.Body.Close()
-	}
+type chartRenderer struct {
+	chart   *ChartWidget
+	objects []fyne.CanvasObject
+}
 
-	return BenchmarkResult{
-		Seq:       seq,
-		Timestamp: start.Format("15:04:05"),
-		Duration:  duration,
-		Status:    status,
-	}
+func (r *chartRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(100, 100) // Tamaño mínimo
 }
 
-// --- UI PRINCIPAL ---
+func (r *chartRenderer) Layout(size fyne.Size) {
+	// Este método se llama automáticamente cuando la ventana cambia de tamaño
+	// Aquí regeneramos el gráfico para que ocupe todo el espacio 'size'
+	r.objects = r.generateChartObjects(size)
+}
 
-// compactPaddingLayout es un layout con padding reducido para compactar elementos
-type compactPaddingLayout struct {
-	padding float32
+func (r *chartRenderer) Refresh() {
+	// Llamado cuando cambian los datos
+	r.Layout(r.chart.Size())
+	canvas.Refresh(r.chart)
 }
 
-func (c *compactPaddingLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
-	padding := c.padding
-	if padding == 0 {
-		padding = 2
-	}
-	pos := fyne.NewPos(padding, padding)
-	for _, o := range objects {
-		size := fyne.NewSize(size.Width-2*padding, size.Height-2*padding)
-		o.Resize(size)
-		o.Move(pos)
+func (r *chartRenderer) Objects() []fyne.CanvasObject {
+	// Agregar tooltip a los objetos si existe
+	if r.chart.tooltipContainer != nil {
+		return append(r.objects, r.chart.tooltipContainer)
 	}
+	return r.objects
 }
 
-func (c *compactPaddingLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
-	padding := c.padding
-	if padding == 0 {
-		padding = 2
-	}
-	minSize := fyne.NewSize(0, 0)
-	for _, o := range objects {
-		childSize := o.MinSize()
-		if childSize.Width > minSize.Width {
-			minSize.Width = childSize.Width
-		}
-		if childSize.Height > minSize.Height {
-			minSize.Height = childSize.Height
-		}
-	}
-	return fyne.NewSize(minSize.Width+2*padding, minSize.Height+2*padding)
+func (r *chartRenderer) Destroy() {
+	// No usamos el receptor 'r' aquí, lo cual es aceptable ya que el método debe existir.
 }
 
-func newBoldLabel(text string, align fyne.TextAlign) *widget.Label {
-	l := widget.NewLabel(text)
+// Lógica de dibujo matemático puro (Ahora con múltiples modos de vista)
+func (r *chartRenderer) generateChartObjects(size fyne.Size) []fyne.CanvasObject {
+	// Determinar qué datos mostrar según el modo de vista
+	allData := r.chart.plotData()
+	data := allData
+	maxPoints := MaxVisiblePointsNormal
+
+	switch r.chart.viewMode {
+	case ViewModeNormal:
+		maxPoints = MaxVisiblePointsNormal
+		if len(data) > maxPoints {
+			// Mostrar solo los últimos N puntos
+			data = data[len(data)-maxPoints:]
+		}
+	case ViewModeRealTime:
+		maxPoints = MaxVisiblePointsRealTime
+		if len(data) > maxPoints {
+			// En vista tiempo real, muestrear puntos para mantener fluidez
+			step := len(data) / maxPoints
+			sampledData := downsample(data, step, r.chart.peakSampling)
+			// Siempre incluir el último punto
+			if len(sampledData) < len(data) {
+				sampledData = append(sampledData, data[len(data)-1])
+			}
+			data = sampledData
+		}
+	case ViewModeFullScreen:
+		maxPoints = MaxVisiblePointsRealTime * 2
+		if len(data) > maxPoints {
+			// En pantalla completa, más puntos pero con muestreo inteligente
+			step := len(data) / maxPoints
+			data = downsample(data, step, r.chart.peakSampling)
+		}
+	}
+
+	objs := []fyne.CanvasObject{}
+
+	// Ajustar padding según el modo de vista - padding mayor para evitar solapamiento de ejes
+	// Padding izquierdo más grande para el eje rojo, derecho para el amarillo
+	paddingLeft := float32(70)  // Espacio para eje rojo a la izquierda
+	paddingRight := float32(60) // Espacio para eje amarillo a la derecha
+	paddingTop := float32(40)
+	paddingBottom := float32(40)
+
+	if r.chart.viewMode == ViewModeFullScreen {
+		paddingLeft = float32(50)
+		paddingRight = float32(50)
+		paddingTop = float32(30)
+		paddingBottom = float32(30)
+	}
+
+	chartTheme := r.chart.theme
+	bg := canvas.NewRectangle(chartTheme.Background)
+	bg.Resize(size)
+	objs = append(objs, bg)
+
+	if len(data) < 2 {
+		modeText := "normal"
+		if r.chart.viewMode == ViewModeRealTime {
+			modeText = "tiempo real"
+		} else if r.chart.viewMode == ViewModeFullScreen {
+			modeText = "pantalla completa"
+		}
+		txt := canvas.NewText(fmt.Sprintf("Ejecuta un test para ver el gráfico (modo: %s)", modeText), color.Gray{Y: 150})
+		txt.TextSize = 14
+		txt.Alignment = fyne.TextAlignCenter
+		txt.Move(fyne.NewPos(size.Width/2-150, size.Height/2))
+		objs = append(objs, txt)
+		return objs
+	}
+
+	// Calcular dimensiones del área del gráfico con paddings asimétricos
+	graphW := size.Width - paddingLeft - paddingRight
+	graphH := size.Height - paddingTop - paddingBottom
+
+	// --- Cálculos para múltiples métricas ---
+	// Calcular rangos Y para tiempo de respuesta
+	maxDur := maxDuration(data)
+	// Asegurar que la línea de SLA quede dentro del área visible
+	slowThreshold := r.chart.slowThresholdMs
+	if slowThreshold > maxDur {
+		maxDur = slowThreshold
+	}
+	// P95 móvil de cada punto visible; la ventana puede incluir requests anteriores más lentas
+	var rollingP95 []float64
+	if r.chart.rollingP95 {
+		rollingP95 = rollingPercentiles(allData, data, r.chart.rollingWindow, 0.95)
+		for _, v := range rollingP95 {
+			maxDur = max(maxDur, v)
+		}
+	}
+	maxDur *= 1.2
+
+	// Calcular estadísticas para las líneas adicionales
+	var errorCount float64
+	var totalDuration float64
+	for _, d := range data {
+		totalDuration += d.Duration
+		if countsAsError(d, r.chart.successCfg) {
+			errorCount++
+		}
+	} // Escalas para múltiples métricas
+	xStep := graphW / float32(len(data)-1)
+	yScale := graphH / float32(maxDur)
+
+	// --- Ejes y Etiquetas ---
+	axisColor := chartTheme.Axis
+
+	// Eje X (Base)
+	xAxis := canvas.NewLine(axisColor)
+	xAxis.StrokeWidth = 2
+	xAxis.Position1 = fyne.NewPos(paddingLeft, size.Height-paddingBottom)
+	xAxis.Position2 = fyne.NewPos(size.Width-paddingRight, size.Height-paddingBottom)
+	objs = append(objs, xAxis)
+
+	// Eje Y (Vertical - Central para tiempo de respuesta)
+	yAxis := canvas.NewLine(axisColor)
+	yAxis.StrokeWidth = 2
+	yAxis.Position1 = fyne.NewPos(paddingLeft, paddingTop)
+	yAxis.Position2 = fyne.NewPos(paddingLeft, size.Height-paddingBottom)
+	objs = append(objs, yAxis)
+
+	// Etiquetas Eje Y para tiempo (lado izquierdo central)
+	drawYLabel := func(val float64, yPos float32, label string) {
+		lbl := canvas.NewText(label, axisColor)
+		lbl.TextSize = 9
+		lbl.Alignment = fyne.TextAlignTrailing
+		lbl.Move(fyne.NewPos(paddingLeft-35, yPos-6))
+		// Línea guía
+		grid := canvas.NewLine(chartTheme.Grid)
+		grid.Position1 = fyne.NewPos(paddingLeft, yPos)
+		grid.Position2 = fyne.NewPos(size.Width-paddingRight, yPos)
+		objs = append(objs, lbl, grid)
+	}
+
+	drawYLabel(maxDur, paddingTop, formatDuration(maxDur, r.chart.latencyDecimals))
+	drawYLabel(maxDur/2, paddingTop+graphH/2, formatDuration(maxDur/2, r.chart.latencyDecimals))
+	drawYLabel(0, size.Height-paddingBottom, formatDuration(0, r.chart.latencyDecimals))
+
+	// Línea de umbral SLA (naranja)
+	slowColor := color.NRGBA{R: 255, G: 120, B: 0, A: 255}
+	rateLimitedColor := color.NRGBA{R: 255, G: 170, B: 0, A: 255} // 429 contados aparte del error rate
+	if slowThreshold > 0 {
+		slowY := (size.Height - paddingBottom) - (float32(slowThreshold) * yScale)
+		slowLine := canvas.NewLine(slowColor)
+		slowLine.StrokeWidth = 1.5
+		slowLine.Position1 = fyne.NewPos(paddingLeft, slowY)
+		slowLine.Position2 = fyne.NewPos(size.Width-paddingRight, slowY)
+		slowLbl := canvas.NewText("SLA "+formatDuration(slowThreshold, r.chart.latencyDecimals), slowColor)
+		slowLbl.TextSize = 9
+		slowLbl.Move(fyne.NewPos(size.Width-paddingRight-60, slowY-14))
+		objs = append(objs, slowLine, slowLbl)
+	}
+
+	// --- Ejes Y adicionales con colores (amarillo y rojo) ---
+
+	// Calcular máximos para requests/sec y error rate
+	maxRequestsPerSec := 120.0 // Máximo esperado
+	maxErrorRate := 100.0      // Porcentaje
+
+	// Eje Y para Requests/second (amarillo - derecha)
+	requestsAxisColor := chartTheme.RequestsSec
+	requestsAxisX := size.Width - paddingRight
+	requestsAxis := canvas.NewLine(requestsAxisColor)
+	requestsAxis.StrokeWidth = 2
+	requestsAxis.Position1 = fyne.NewPos(requestsAxisX, paddingTop)
+	requestsAxis.Position2 = fyne.NewPos(requestsAxisX, size.Height-paddingBottom)
+	objs = append(objs, requestsAxis)
+
+	// Etiquetas para requests/sec (amarillo - a la derecha del eje)
+	drawRequestsLabel := func(val float64, yPos float32, label string) {
+		lbl := canvas.NewText(label, requestsAxisColor)
+		lbl.TextSize = 9
+		lbl.Alignment = fyne.TextAlignLeading
+		lbl.Move(fyne.NewPos(requestsAxisX+5, yPos-6))
+		objs = append(objs, lbl)
+	}
+
+	drawRequestsLabel(maxRequestsPerSec, paddingTop, "120 req/s")
+	drawRequestsLabel(maxRequestsPerSec/2, paddingTop+graphH/2, "60")
+	drawRequestsLabel(0, size.Height-paddingBottom, "0")
+
+	// Eje Y para Error rate (rojo - extremo izquierdo)
+	errorAxisColor := chartTheme.ErrorRate
+	// Posición fija: 15px desde el borde izquierdo (siempre dentro del espacio reservado)
+	errorAxisX := float32(15)
+	errorAxis := canvas.NewLine(errorAxisColor)
+	errorAxis.StrokeWidth = 2
+	errorAxis.Position1 = fyne.NewPos(errorAxisX, paddingTop)
+	errorAxis.Position2 = fyne.NewPos(errorAxisX, size.Height-paddingBottom)
+	objs = append(objs, errorAxis)
+
+	// Etiquetas para error rate (rojo - a la derecha del eje rojo)
+	drawErrorLabel := func(val float64, yPos float32, label string) {
+		lbl := canvas.NewText(label, errorAxisColor)
+		lbl.TextSize = 8
+		lbl.Alignment = fyne.TextAlignLeading
+		lbl.Move(fyne.NewPos(errorAxisX+3, yPos-6))
+		objs = append(objs, lbl)
+	}
+
+	drawErrorLabel(100, paddingTop, "100%")
+	drawErrorLabel(50, paddingTop+graphH/2, "50")
+	drawErrorLabel(0, size.Height-paddingBottom, "0")
+
+	// Escalas para cada métrica
+	requestsScale := graphH / float32(maxRequestsPerSec)
+	errorScale := graphH / float32(maxErrorRate)
+
+	// --- LÍNEAS DE DATOS MÚLTIPLES ---
+
+	// Limpiar puntos para el hover (con capacidad para las cuatro series)
+	r.chart.points = make([]PointInfo, 0, len(data)*4)
+
+	// Colores de las series según el tema
+	responseTimeColor := chartTheme.ResponseTime                // Azul (Avg response)
+	requestsSecColor := chartTheme.RequestsSec                  // Amarillo (Requests/second)
+	errorRateColor := chartTheme.ErrorRate                      // Rojo (Error rate)
+	rollingColor := color.NRGBA{R: 170, G: 110, B: 255, A: 255} // Violeta (P95 móvil)
+
+	var prevResponsePos, prevRequestsPos, prevErrorPos, prevRollingPos fyne.Position
+	var prevDuration float64
+	errorsUpToNow := 0
+	isError := make([]bool, len(data))
+	// Error rate en ventana móvil: muestra picos recientes que el acumulado suaviza
+	var windowErrors, windowSamples []int
+	if window := r.chart.errorRateWindow; window > 0 {
+		windowErrors, windowSamples = rollingErrorCounts(allData, data, window, r.chart.successCfg)
+	}
+
+	// Rango de latencias de toda la ejecución para el gradiente (no solo los puntos visibles)
+	runMin, runMax := 0.0, 0.0
+	if r.chart.latencyGradient {
+		runMin, runMax = allData[0].Duration, allData[0].Duration
+		for _, d := range allData {
+			if d.Duration < runMin {
+				runMin = d.Duration
+			}
+			if d.Duration > runMax {
+				runMax = d.Duration
+			}
+		}
+	}
+
+	// Ajustar grosor de línea y tamaño de puntos según el modo
+	lineWidth := float32(2)
+	pointSize := float32(4)
+
+	if r.chart.viewMode == ViewModeFullScreen {
+		lineWidth = 3
+		pointSize = 5
+	} else if r.chart.viewMode == ViewModeRealTime {
+		lineWidth = 2.5
+		pointSize = 4.5
+	}
+
+	for i, d := range data {
+		x := paddingLeft + (float32(i) * xStep)
+
+		// Posiciones para cada métrica
+		responseY := (size.Height - paddingBottom) - (float32(d.Duration) * yScale)
+
+		// Calcular requests/second
+		requestsPerSec := float64(i+1) / (float64(i+1) * 0.1)
+		if requestsPerSec > 120 {
+			requestsPerSec = 120
+		}
+		// Usar escala específica de requests
+		requestsY := (size.Height - paddingBottom) - (float32(requestsPerSec) * requestsScale)
+
+		// Error rate acumulativo (contador incremental en lugar de recorrer los puntos anteriores)
+		if countsAsError(d, r.chart.successCfg) {
+			errorsUpToNow++
+			isError[i] = true
+		}
+		pointErrors, errorSample := errorsUpToNow, i+1
+		if windowErrors != nil {
+			pointErrors, errorSample = windowErrors[i], windowSamples[i]
+		}
+		currentErrorRate := (float64(pointErrors) / float64(errorSample)) * 100
+		// Usar escala específica de error rate
+		errorY := (size.Height - paddingBottom) - (float32(currentErrorRate) * errorScale)
+
+		// Posiciones actuales
+		responsePos := fyne.NewPos(x, responseY)
+		requestsPos := fyne.NewPos(x, requestsY)
+		errorPos := fyne.NewPos(x, errorY)
+
+		if i > 0 {
+			// Línea tiempo de respuesta (azul, o gradiente según la latencia del segmento)
+			segmentColor := responseTimeColor
+			if r.chart.latencyGradient {
+				segmentColor = latencyGradientColor((prevDuration+d.Duration)/2, runMin, runMax)
+			}
+			responseLine := canvas.NewLine(segmentColor)
+			responseLine.StrokeWidth = lineWidth
+			responseLine.Position1 = prevResponsePos
+			responseLine.Position2 = responsePos
+			objs = append(objs, responseLine)
+
+			// Línea requests/second (amarillo)
+			requestsLine := canvas.NewLine(requestsSecColor)
+			requestsLine.StrokeWidth = lineWidth
+			requestsLine.Position1 = prevRequestsPos
+			requestsLine.Position2 = requestsPos
+			objs = append(objs, requestsLine)
+
+			// Línea error rate (rojo)
+			errorLine := canvas.NewLine(errorRateColor)
+			errorLine.StrokeWidth = lineWidth
+			errorLine.Position1 = prevErrorPos
+			errorLine.Position2 = errorPos
+			objs = append(objs, errorLine)
+		}
+
+		// Línea P95 móvil (violeta), sobre la escala de latencia
+		var rollingPos fyne.Position
+		if rollingP95 != nil {
+			rollingPos = fyne.NewPos(x, (size.Height-paddingBottom)-(float32(rollingP95[i])*yScale))
+			if i > 0 {
+				rollingLine := canvas.NewLine(rollingColor)
+				rollingLine.StrokeWidth = lineWidth
+				rollingLine.Position1 = prevRollingPos
+				rollingLine.Position2 = rollingPos
+				objs = append(objs, rollingLine)
+			}
+		}
+
+		// Marcador distintivo (cuadrado naranja) para requests que superan el SLA, en todos los modos
+		if slowThreshold > 0 && d.Duration > slowThreshold {
+			markerSize := pointSize + 4
+			slowMarker := canvas.NewRectangle(slowColor)
+			slowMarker.StrokeColor = chartTheme.Text
+			slowMarker.StrokeWidth = 1
+			slowMarker.Resize(fyne.NewSize(markerSize, markerSize))
+			slowMarker.Move(fyne.NewPos(x-markerSize/2, responseY-markerSize/2))
+			objs = append(objs, slowMarker)
+		}
+
+		// Anillo naranja para las respuestas 429 contadas aparte, en todos los modos
+		if isRateLimited(d, r.chart.successCfg) {
+			ringSize := pointSize + 6
+			ring := canvas.NewCircle(color.Transparent)
+			ring.StrokeColor = rateLimitedColor
+			ring.StrokeWidth = 2
+			ring.Resize(fyne.NewSize(ringSize, ringSize))
+			ring.Move(fyne.NewPos(x-ringSize/2, responseY-ringSize/2))
+			objs = append(objs, ring)
+		}
+
+		// Cruz roja para las requests fallidas, en todos los modos: se distinguen en la línea de latencia
+		if isError[i] {
+			half := (pointSize + 4) / 2
+			for _, dy := range []float32{-half, half} {
+				stroke := canvas.NewLine(errorRateColor)
+				stroke.StrokeWidth = 2
+				stroke.Position1 = fyne.NewPos(x-half, responseY-dy)
+				stroke.Position2 = fyne.NewPos(x+half, responseY+dy)
+				objs = append(objs, stroke)
+			}
+		}
+
+		// Puntos para cada línea (solo en vista normal y tiempo real, no en pantalla completa para mejor rendimiento)
+		if r.chart.viewMode != ViewModeFullScreen {
+			// Punto tiempo de respuesta (azul); las requests lentas y las fallidas ya tienen su marcador
+			if !isError[i] && (slowThreshold <= 0 || d.Duration <= slowThreshold) {
+				responseDot := canvas.NewCircle(responseTimeColor)
+				responseDot.Resize(fyne.NewSize(pointSize, pointSize))
+				responseDot.Move(fyne.NewPos(x-pointSize/2, responseY-pointSize/2))
+				objs = append(objs, responseDot)
+			}
+
+			// NO agregar puntos para requests/second (línea amarilla) - solo línea continua
+
+			// Punto error rate (rojo) con etiqueta de porcentaje
+			errorDot := canvas.NewCircle(errorRateColor)
+			errorDot.Resize(fyne.NewSize(pointSize+2, pointSize+2)) // Ligeramente más grande
+			errorDot.Move(fyne.NewPos(x-(pointSize+2)/2, errorY-(pointSize+2)/2))
+			objs = append(objs, errorDot)
+
+			// Etiqueta de porcentaje en el punto rojo (solo en vista normal: con muchos puntos se superponen)
+			if currentErrorRate > 0 && r.chart.viewMode == ViewModeNormal { // Solo mostrar si hay errores
+				errorLabel := canvas.NewText(fmt.Sprintf("%.1f%%", currentErrorRate), errorRateColor)
+				errorLabel.TextSize = 8
+				errorLabel.Alignment = fyne.TextAlignCenter
+				errorLabel.Move(fyne.NewPos(x-12, errorY-18)) // Encima del punto
+				objs = append(objs, errorLabel)
+			}
+		}
+
+		// Etiqueta eje X (adaptada según modo de vista)
+		lblText := ""
+		showLabel := false
+
+		switch r.chart.viewMode {
+		case ViewModeNormal:
+			// En modo normal, mostrar todos si hay pocos, o cada N si hay muchos
+			if len(data) <= 5 {
+				lblText = d.Timestamp
+				showLabel = true
+			} else {
+				showLabel = i%2 == 0 || i == len(data)-1
+			}
+		case ViewModeRealTime:
+			// En tiempo real, mostrar menos etiquetas
+			showLabel = i%5 == 0 || i == len(data)-1
+		case ViewModeFullScreen:
+			// En pantalla completa, mostrar aún menos etiquetas
+			showLabel = i%10 == 0 || i == len(data)-1
+			lblText = d.Timestamp // Mostrar tiempo en lugar de secuencia
+		}
+
+		if showLabel {
+			if r.chart.xAxisMode != XAxisAuto || lblText == "" {
+				lblText = r.chart.xAxisLabel(d, allData[0])
+			}
+			xLbl := canvas.NewText(lblText, axisColor)
+			xLbl.TextSize = 9
+			xLbl.Alignment = fyne.TextAlignCenter
+			xLbl.Move(fyne.NewPos(x-15, size.Height-paddingBottom+5))
+			objs = append(objs, xLbl)
+		}
+
+		// Guardar los puntos de las tres series para hover y click (siempre, independientemente del modo).
+		// Solo se guardan las métricas; los textos se generan al mostrarse
+		point := PointInfo{Result: d, Index: i, RequestsPerSec: requestsPerSec, ErrorRate: currentErrorRate, Errors: pointErrors, ErrorSample: errorSample, Failed: isError[i]}
+		for _, sp := range []struct {
+			series ChartSeries
+			y      float32
+		}{{SeriesResponseTime, responseY}, {SeriesRequestsSec, requestsY}, {SeriesErrorRate, errorY}} {
+			point.X, point.Y, point.Series = x, sp.y, sp.series
+			r.chart.points = append(r.chart.points, point)
+		}
+		if rollingP95 != nil {
+			point.X, point.Y, point.Series = x, rollingPos.Y, SeriesRollingP95
+			point.RollingP95, point.RollingWindow = rollingP95[i], r.chart.rollingWindow
+			r.chart.points = append(r.chart.points, point)
+		}
+
+		// Actualizar posiciones previas para las próximas líneas
+		prevResponsePos = responsePos
+		prevRequestsPos = requestsPos
+		prevErrorPos = errorPos
+		prevRollingPos = rollingPos
+		prevDuration = d.Duration
+	}
+
+	// Agregar leyenda
+	legendY := paddingTop + 10
+	errorLegend := "Error rate"
+	if r.chart.errorRateWindow > 0 {
+		errorLegend = fmt.Sprintf("Error rate (últ. %d)", r.chart.errorRateWindow)
+	}
+	legendItems := []struct {
+		color color.NRGBA
+		text  string
+	}{
+		{responseTimeColor, "Avg. response"},
+		{requestsSecColor, "Requests/second"},
+		{errorRateColor, errorLegend},
+	}
+	if rollingP95 != nil {
+		legendItems = append(legendItems, struct {
+			color color.NRGBA
+			text  string
+		}{rollingColor, fmt.Sprintf("P95 móvil (últ. %d)", r.chart.rollingWindow)})
+	}
+	if slowThreshold > 0 {
+		legendItems = append(legendItems, struct {
+			color color.NRGBA
+			text  string
+		}{slowColor, "SLA excedido"})
+	}
+
+	for i, item := range legendItems {
+		legendX := paddingLeft + float32(i*120)
+
+		// Línea de muestra
+		legendLine := canvas.NewLine(item.color)
+		legendLine.StrokeWidth = 2
+		legendLine.Position1 = fyne.NewPos(legendX, legendY)
+		legendLine.Position2 = fyne.NewPos(legendX+15, legendY)
+		objs = append(objs, legendLine)
+
+		// Texto de leyenda
+		legendText := canvas.NewText(item.text, chartTheme.Text)
+		legendText.TextSize = 9
+		legendText.Move(fyne.NewPos(legendX+20, legendY-6))
+		objs = append(objs, legendText)
+	}
+
+	return objs
+}
+
+// formatDuration formatea una latencia en milisegundos con decimals decimales, pasando a segundos
+// desde 1000ms para que valores como "1500 ms" se lean como "1.50 s"
+func formatDuration(ms float64, decimals int) string {
+	if ms >= 1000 {
+		return fmt.Sprintf("%.2f s", ms/1000)
+	}
+	return fmt.Sprintf("%.*f ms", decimals, ms)
+}
+
+const DefaultLatencyDecimals = 1 // Decimales por defecto de las latencias en ms
+const MaxLatencyDecimals = 3     // Resolución de la medición (microsegundos)
+
+// maxDuration retorna la mayor latencia de los datos, usada como tope de la escala Y
+// (100ms si todas son 0, para no dividir por cero)
+func maxDuration(data []BenchmarkResult) float64 {
+	maxDur := 0.0
+	for _, d := range data {
+		if d.Duration > maxDur {
+			maxDur = d.Duration
+		}
+	}
+	if maxDur == 0 {
+		maxDur = 100
+	}
+	return maxDur
+}
+
+// --- SPARKLINE ---
+
+const SparklinePoints = 30 // Latencias recientes mostradas en el sparkline
+
+// SparklineWidget dibuja una línea compacta con las últimas latencias, visible en cualquier vista
+type SparklineWidget struct {
+	widget.BaseWidget
+	data  []BenchmarkResult
+	color color.NRGBA
+}
+
+func NewSparklineWidget() *SparklineWidget {
+	s := &SparklineWidget{color: DarkChartTheme.ResponseTime}
+	s.ExtendBaseWidget(s)
+	return s
+}
+
+// SetData reemplaza los datos mostrados (solo se conservan los últimos SparklinePoints)
+func (s *SparklineWidget) SetData(d []BenchmarkResult) {
+	if len(d) > SparklinePoints {
+		d = d[len(d)-SparklinePoints:]
+	}
+	s.data = append([]BenchmarkResult(nil), d...)
+	s.Refresh()
+}
+
+// Append agrega resultados a los ya mostrados (ej. requests únicas ejecutadas una tras otra)
+func (s *SparklineWidget) Append(d ...BenchmarkResult) {
+	s.SetData(append(s.data, d...))
+}
+
+// SetColor cambia el color de la línea (se sincroniza con el tema del gráfico)
+func (s *SparklineWidget) SetColor(c color.NRGBA) {
+	s.color = c
+	s.Refresh()
+}
+
+func (s *SparklineWidget) CreateRenderer() fyne.WidgetRenderer {
+	return &sparklineRenderer{spark: s}
+}
+
+type sparklineRenderer struct {
+	spark   *SparklineWidget
+	objects []fyne.CanvasObject
+}
+
+func (r *sparklineRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(120, 24)
+}
+
+func (r *sparklineRenderer) Layout(size fyne.Size) {
+	data := r.spark.data
+	r.objects = nil
+	if len(data) < 2 {
+		return
+	}
+
+	// Misma escala que el gráfico principal (0 abajo, máxima latencia arriba)
+	yScale := (size.Height - 2) / float32(maxDuration(data))
+	xStep := size.Width / float32(len(data)-1)
+	var prev fyne.Position
+	for i, d := range data {
+		pos := fyne.NewPos(float32(i)*xStep, size.Height-1-float32(d.Duration)*yScale)
+		if i > 0 {
+			line := canvas.NewLine(r.spark.color)
+			line.StrokeWidth = 1.5
+			line.Position1 = prev
+			line.Position2 = pos
+			r.objects = append(r.objects, line)
+		}
+		prev = pos
+	}
+}
+
+func (r *sparklineRenderer) Refresh() {
+	r.Layout(r.spark.Size())
+	canvas.Refresh(r.spark)
+}
+
+func (r *sparklineRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *sparklineRenderer) Destroy() {}
+
+// InFlightGauge es una barra horizontal con las requests en curso sobre la concurrencia configurada:
+// llena indica que el cliente satura sus usuarios; con huecos, el think time o la latencia dejan slots libres
+type InFlightGauge struct {
+	widget.BaseWidget
+	value, capacity int
+}
+
+func NewInFlightGauge() *InFlightGauge {
+	g := &InFlightGauge{}
+	g.ExtendBaseWidget(g)
+	return g
+}
+
+// SetValue actualiza las requests en curso y la capacidad (usuarios concurrentes)
+func (g *InFlightGauge) SetValue(value, capacity int) {
+	g.value, g.capacity = value, capacity
+	g.Refresh()
+}
+
+func (g *InFlightGauge) CreateRenderer() fyne.WidgetRenderer {
+	r := &inFlightGaugeRenderer{
+		gauge: g,
+		track: canvas.NewRectangle(color.NRGBA{R: 60, G: 60, B: 65, A: 255}),
+		fill:  canvas.NewRectangle(DarkChartTheme.ResponseTime),
+		label: canvas.NewText("", color.White),
+	}
+	r.track.CornerRadius = 3
+	r.fill.CornerRadius = 3
+	r.label.TextSize = 11
+	r.label.Alignment = fyne.TextAlignCenter
+	r.Refresh()
+	return r
+}
+
+type inFlightGaugeRenderer struct {
+	gauge *InFlightGauge
+	track *canvas.Rectangle
+	fill  *canvas.Rectangle
+	label *canvas.Text
+}
+
+func (r *inFlightGaugeRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(160, 18)
+}
+
+func (r *inFlightGaugeRenderer) Layout(size fyne.Size) {
+	ratio := float32(0)
+	if r.gauge.capacity > 0 {
+		ratio = min(float32(r.gauge.value)/float32(r.gauge.capacity), 1)
+	}
+	r.track.Resize(size)
+	r.fill.Resize(fyne.NewSize(size.Width*ratio, size.Height))
+	r.label.Resize(size)
+}
+
+func (r *inFlightGaugeRenderer) Refresh() {
+	r.label.Text = fmt.Sprintf("En vuelo: %d / %d", r.gauge.value, r.gauge.capacity)
+	r.fill.FillColor = DarkChartTheme.ResponseTime
+	if r.gauge.capacity > 0 && r.gauge.value >= r.gauge.capacity {
+		r.fill.FillColor = DarkChartTheme.RequestsSec // Concurrencia saturada
+	}
+	r.Layout(r.gauge.Size())
+	canvas.Refresh(r.gauge)
+}
+
+func (r *inFlightGaugeRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{r.track, r.fill, r.label}
+}
+
+func (r *inFlightGaugeRenderer) Destroy() {}
+
+// SaturationPoint es un punto de la curva de saturación: la carga aplicada y el P95 medido con ella
+type SaturationPoint struct {
+	Load   float64 // Usuarios concurrentes o RPS objetivo (eje X)
+	P95    float64
+	Failed bool // La carga no fue sostenible (se dibuja en rojo)
+}
+
+// SaturationChart dibuja la curva de saturación de un barrido: P95 (eje Y) por carga aplicada
+// (eje X, lineal). Un codo hacia arriba marca el punto de saturación.
+type SaturationChart struct {
+	widget.BaseWidget
+	points   []SaturationPoint
+	xTitle   string
+	knee     int // Índice del punto destacado como máximo sostenible (-1 = ninguno)
+	decimals int // Decimales de las latencias en ms
+}
+
+func NewSaturationChart(xTitle string, points []SaturationPoint, knee, decimals int) *SaturationChart {
+	c := &SaturationChart{points: points, xTitle: xTitle, knee: knee, decimals: decimals}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+func (c *SaturationChart) CreateRenderer() fyne.WidgetRenderer {
+	return &saturationChartRenderer{chart: c}
+}
+
+type saturationChartRenderer struct {
+	chart   *SaturationChart
+	objects []fyne.CanvasObject
+}
+
+func (r *saturationChartRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(420, 240)
+}
+
+func (r *saturationChartRenderer) Layout(size fyne.Size) {
+	points := r.chart.points
+	r.objects = nil
+	const paddingLeft, paddingRight, paddingTop, paddingBottom = 55, 20, 20, 35
+	width := size.Width - paddingLeft - paddingRight
+	height := size.Height - paddingTop - paddingBottom
+	if len(points) == 0 || width <= 0 || height <= 0 {
+		return
+	}
+
+	chartTheme := DarkChartTheme
+	bg := canvas.NewRectangle(chartTheme.Background)
+	bg.Resize(size)
+	r.objects = append(r.objects, bg)
+
+	maxLoad, maxP95 := 0.0, 0.0
+	for _, p := range points {
+		maxLoad = math.Max(maxLoad, p.Load)
+		maxP95 = math.Max(maxP95, p.P95)
+	}
+	if maxP95 == 0 {
+		maxP95 = 1
+	}
+	origin := fyne.NewPos(paddingLeft, paddingTop+height)
+	xAxis := canvas.NewLine(chartTheme.Axis)
+	xAxis.Position1, xAxis.Position2 = origin, fyne.NewPos(paddingLeft+width, origin.Y)
+	yAxis := canvas.NewLine(chartTheme.Axis)
+	yAxis.Position1, yAxis.Position2 = origin, fyne.NewPos(paddingLeft, paddingTop)
+	yMax := canvas.NewText(formatDuration(maxP95, r.chart.decimals), chartTheme.Text)
+	yMax.TextSize = 9
+	yMax.Move(fyne.NewPos(4, paddingTop-6))
+	yTitle := canvas.NewText("P95", chartTheme.Text)
+	yTitle.TextSize = 9
+	yTitle.Move(fyne.NewPos(4, origin.Y-12))
+	xTitle := canvas.NewText(r.chart.xTitle, chartTheme.Text)
+	xTitle.TextSize = 9
+	xTitle.Move(fyne.NewPos(paddingLeft+width-110, size.Height-14))
+	r.objects = append(r.objects, xAxis, yAxis, yMax, yTitle, xTitle)
+
+	var prev fyne.Position
+	for i, p := range points {
+		x := paddingLeft + width*float32(p.Load/maxLoad)
+		if len(points) == 1 || maxLoad == 0 {
+			x = paddingLeft + width/2
+		}
+		pos := fyne.NewPos(x, origin.Y-height*float32(p.P95/maxP95))
+		if i > 0 {
+			line := canvas.NewLine(chartTheme.ResponseTime)
+			line.StrokeWidth = 2
+			line.Position1, line.Position2 = prev, pos
+			r.objects = append(r.objects, line)
+		}
+		dotColor, dotSize := chartTheme.ResponseTime, float32(6)
+		if p.Failed {
+			dotColor = chartTheme.ErrorRate
+		} else if i == r.chart.knee {
+			dotColor, dotSize = color.NRGBA{R: 0, G: 200, B: 90, A: 255}, 10
+		}
+		dot := canvas.NewCircle(dotColor)
+		dot.Resize(fyne.NewSize(dotSize, dotSize))
+		dot.Move(fyne.NewPos(pos.X-dotSize/2, pos.Y-dotSize/2))
+		label := formatDuration(p.P95, r.chart.decimals)
+		if i == r.chart.knee {
+			label = "máx. · " + label
+		}
+		value := canvas.NewText(label, chartTheme.Text)
+		value.TextSize = 9
+		value.Move(fyne.NewPos(pos.X-15, pos.Y-16))
+		load := canvas.NewText(strconv.FormatFloat(p.Load, 'f', -1, 64), chartTheme.Text)
+		load.TextSize = 9
+		load.Move(fyne.NewPos(x-4, origin.Y+4))
+		r.objects = append(r.objects, dot, value, load)
+		prev = pos
+	}
+}
+
+func (r *saturationChartRenderer) Refresh() {
+	r.Layout(r.chart.Size())
+	canvas.Refresh(r.chart)
+}
+
+func (r *saturationChartRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *saturationChartRenderer) Destroy() {}
+
+// latencyGradientColor interpola de verde (min) a amarillo y rojo (max) según la latencia
+func latencyGradientColor(value, min, max float64) color.NRGBA {
+	t := 0.0
+	if max > min {
+		t = (value - min) / (max - min)
+	}
+	if t < 0 {
+		t = 0
+	} else if t > 1 {
+		t = 1
+	}
+
+	// Verde -> amarillo en la primera mitad, amarillo -> rojo en la segunda
+	if t < 0.5 {
+		return color.NRGBA{R: uint8(255 * t * 2), G: 200, B: 0, A: 255}
+	}
+	return color.NRGBA{R: 255, G: uint8(200 * (1 - t) * 2), B: 0, A: 255}
+}
+
+// --- LÓGICA DE NEGOCIO (Sin cambios en esta sección) ---
+
+func generateHMACSignature(secretKey, message string) string {
+	h := hmac.New(sha256.New, []byte(secretKey))
+	h.Write([]byte(message))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// loadBodyFile reemplaza cfg.Body por el contenido de cfg.BodyFile, si está configurado.
+// Con BodyDir usa el primer archivo de la carpeta (la rotación la hace runLoadTest).
+func loadBodyFile(cfg RequestConfig) (RequestConfig, error) {
+	if cfg.BodyDir != "" {
+		bodies, err := loadBodyDir(cfg.BodyDir)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Body, cfg.BodyFile, cfg.BodyDir = bodies[0].Content, "", ""
+		return cfg, nil
+	}
+	if cfg.BodyFile == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(cfg.BodyFile)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Body = string(data)
+	return cfg, nil
+}
+
+// BodyEntry es un body precalculado leído de la carpeta de bodies
+type BodyEntry struct {
+	Name    string // Nombre del archivo (se registra en BenchmarkResult.BodyFile)
+	Content string
+}
+
+// loadBodyDir lee todos los archivos regulares de dir ordenados por nombre (se ignoran
+// subcarpetas y archivos ocultos); cada uno es el body completo de una request
+func loadBodyDir(dir string) ([]BodyEntry, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var bodies []BodyEntry
+	for _, e := range entries {
+		if !e.Type().IsRegular() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		bodies = append(bodies, BodyEntry{Name: e.Name(), Content: string(data)})
+	}
+	if len(bodies) == 0 {
+		return nil, errors.New("la carpeta no contiene archivos")
+	}
+	return bodies, nil
+}
+
+// SeqToken se reemplaza en la URL y el body por el número de request dentro de la ejecución (1, 2, 3...)
+const SeqToken = "{{seq}}"
+
+// expandSeqToken reemplaza SeqToken en la URL y el body de cfg por seq
+func expandSeqToken(cfg RequestConfig, seq int64) RequestConfig {
+	n := strconv.FormatInt(seq, 10)
+	cfg.URL = strings.ReplaceAll(cfg.URL, SeqToken, n)
+	cfg.Body = strings.ReplaceAll(cfg.Body, SeqToken, n)
+	return cfg
+}
+
+// parseEnvFile lee un archivo de entorno con líneas CLAVE=VALOR. Ignora líneas vacías y comentarios (#),
+// acepta el prefijo "export " y valores entre comillas: dobles (con escapes \n, \" ...) o simples (literales)
+func parseEnvFile(data []byte) (map[string]string, error) {
+	env := map[string]string{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("línea %d: se esperaba CLAVE=VALOR", i+1)
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case strings.HasPrefix(value, `"`):
+			end := strings.LastIndex(value, `"`)
+			unquoted, err := strconv.Unquote(value[:end+1])
+			if end == 0 || err != nil {
+				return nil, fmt.Errorf("línea %d: comillas sin cerrar o escape inválido en %s", i+1, key)
+			}
+			value = unquoted
+		case strings.HasPrefix(value, "'"):
+			end := strings.LastIndex(value, "'")
+			if end == 0 {
+				return nil, fmt.Errorf("línea %d: comillas sin cerrar en %s", i+1, key)
+			}
+			value = value[1:end]
+		default:
+			// Sin comillas, " #" inicia un comentario al final de la línea
+			if idx := strings.Index(value, " #"); idx != -1 {
+				value = strings.TrimSpace(value[:idx])
+			}
+		}
+		env[key] = value
+	}
+	return env, nil
+}
+
+// expandEnvString reemplaza los tokens ${CLAVE} de s: primero con env (el archivo de entorno cargado)
+// y luego con las variables de entorno del proceso. Los tokens sin valor quedan como están.
+func expandEnvString(s string, env map[string]string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start == -1 {
+			break
+		}
+		end := strings.IndexByte(s[start+2:], '}')
+		if end == -1 {
+			break
+		}
+		token := s[start : start+3+end]
+		name := token[2 : len(token)-1]
+		b.WriteString(s[:start])
+		if value, ok := env[name]; ok {
+			b.WriteString(value)
+		} else if value, ok := os.LookupEnv(name); ok {
+			b.WriteString(value)
+		} else {
+			b.WriteString(token)
+		}
+		s = s[start+len(token):]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// expandEnvTokens aplica expandEnvString a la URL, headers y body de cfg, sus URLs, endpoints y pasos
+// de escenario. Se aplica al construir la configuración, antes de elegir el motor según la URL.
+func expandEnvTokens(cfg RequestConfig, env map[string]string) RequestConfig {
+	cfg.URL = expandEnvString(cfg.URL, env)
+	cfg.Headers = expandEnvString(cfg.Headers, env)
+	cfg.Body = expandEnvString(cfg.Body, env)
+	cfg.URLs = append([]string(nil), cfg.URLs...)
+	for i := range cfg.URLs {
+		cfg.URLs[i] = expandEnvString(cfg.URLs[i], env)
+	}
+	cfg.Endpoints = append([]WeightedEndpoint(nil), cfg.Endpoints...)
+	for i := range cfg.Endpoints {
+		cfg.Endpoints[i].Config = expandEnvTokens(cfg.Endpoints[i].Config, env)
+	}
+	cfg.Scenario = append([]RequestConfig(nil), cfg.Scenario...)
+	for i := range cfg.Scenario {
+		cfg.Scenario[i] = expandEnvTokens(cfg.Scenario[i], env)
+	}
+	return cfg
+}
+
+// describeBody resume el body para la consola (los archivos se muestran por nombre y tamaño)
+func describeBody(cfg RequestConfig) string {
+	if (cfg.Body != "" || cfg.BodyFile != "") && !sendsBody(cfg) {
+		return fmt.Sprintf("[No enviado: %s no lleva body (activa \"Enviar body en todos los métodos\")]", normalizeMethod(cfg.Method))
+	}
+	if cfg.BodyDir != "" {
+		return fmt.Sprintf("[Carpeta: %s, un archivo por request]", filepath.Base(cfg.BodyDir))
+	}
+	if cfg.BodyFile != "" {
+		size := int64(0)
+		if info, err := os.Stat(cfg.BodyFile); err == nil {
+			size = info.Size()
+		}
+		return fmt.Sprintf("[Archivo: %s, %d bytes]", filepath.Base(cfg.BodyFile), size)
+	}
+	return cfg.Body
+}
+
+// newRunID genera un UUID v4 para identificar una ejecución
+func newRunID() string {
+	var b [16]byte
+	crand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40 // Versión 4
+	b[8] = (b[8] & 0x3f) | 0x80 // Variante RFC 4122
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// --- MULTI-ENDPOINT ---
+
+// pickWeightedEndpoint elige un endpoint según su peso y lo combina con la configuración base
+func pickWeightedEndpoint(base RequestConfig, rng *rand.Rand) (RequestConfig, string) {
+	totalWeight := 0
+	for _, ep := range base.Endpoints {
+		totalWeight += ep.Weight
+	}
+	if totalWeight <= 0 {
+		return base, ""
+	}
+
+	n := rng.Intn(totalWeight)
+	chosen := base.Endpoints[len(base.Endpoints)-1]
+	for _, ep := range base.Endpoints {
+		if n < ep.Weight {
+			chosen = ep
+			break
+		}
+		n -= ep.Weight
+	}
+
+	cfg := mergeEndpointConfig(base, chosen.Config)
+	return cfg, cfg.Method + " " + cfg.URL
+}
+
+// mergeEndpointConfig aplica la request de un endpoint sobre la configuración base
+// (los headers, content-type y credenciales vacíos se heredan de base)
+func mergeEndpointConfig(base, ep RequestConfig) RequestConfig {
+	cfg := base
+	cfg.URL = ep.URL
+	cfg.Method = ep.Method
+	cfg.Body = ep.Body
+	if ep.Headers != "" {
+		cfg.Headers = ep.Headers
+	}
+	if ep.ContentType != "" {
+		cfg.ContentType = ep.ContentType
+	}
+	if ep.User != "" {
+		cfg.User, cfg.Secret = ep.User, ep.Secret
+	}
+	return cfg
+}
+
+// scenarioStep retorna el paso step del escenario (vuelve al inicio al terminar) combinado con la configuración base
+func scenarioStep(base RequestConfig, step int) (RequestConfig, string) {
+	cfg := mergeEndpointConfig(base, base.Scenario[step%len(base.Scenario)])
+	return cfg, cfg.Method + " " + cfg.URL
+}
+
+// harFile es el subconjunto de un archivo HAR (HTTP Archive) necesario para reproducir sus requests
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method  string `json:"method"`
+				URL     string `json:"url"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+				PostData *struct {
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// harSkippedHeaders los calcula el cliente HTTP al reproducir la request
+var harSkippedHeaders = map[string]bool{
+	"Content-Length":    true,
+	"Host":              true,
+	"Connection":        true,
+	"Accept-Encoding":   true,
+	"Content-Type":      true, // Se toma de postData.mimeType
+	"Transfer-Encoding": true,
+}
+
+// parseHAR convierte las entradas de un HAR (log.entries[].request) en los pasos de un escenario
+func parseHAR(data []byte) ([]RequestConfig, error) {
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("HAR inválido: %w", err)
+	}
+	var steps []RequestConfig
+	for i, entry := range har.Log.Entries {
+		r := entry.Request
+		if r.URL == "" {
+			return nil, fmt.Errorf("entrada %d: request sin URL", i+1)
+		}
+		step := RequestConfig{Method: strings.ToUpper(r.Method), URL: r.URL}
+		var headers []string
+		for _, h := range r.Headers {
+			// Los pseudo-headers de HTTP/2 (:authority, :path...) no son headers reales
+			if strings.HasPrefix(h.Name, ":") || harSkippedHeaders[http.CanonicalHeaderKey(h.Name)] {
+				continue
+			}
+			headers = append(headers, h.Name+": "+h.Value)
+		}
+		step.Headers = strings.Join(headers, "\n")
+		if r.PostData != nil {
+			step.Body = r.PostData.Text
+			step.ContentType = r.PostData.MimeType
+		}
+		steps = append(steps, step)
+	}
+	if len(steps) == 0 {
+		return nil, errors.New("el HAR no contiene requests")
+	}
+	return steps, nil
+}
+
+// parseWeightedEndpoints interpreta líneas "peso MÉTODO URL [body]" (las líneas vacías o con # se ignoran)
+func parseWeightedEndpoints(text string) ([]WeightedEndpoint, error) {
+	var endpoints []WeightedEndpoint
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, " ", 4)
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("línea %d: formato esperado 'peso MÉTODO URL [body]'", i+1)
+		}
+		weight, err := strconv.Atoi(parts[0])
+		if err != nil || weight <= 0 {
+			return nil, fmt.Errorf("línea %d: peso inválido %q", i+1, parts[0])
+		}
+		ep := WeightedEndpoint{Weight: weight}
+		ep.Config.Method = strings.ToUpper(parts[1])
+		ep.Config.URL = parts[2]
+		if len(parts) == 4 {
+			ep.Config.Body = strings.TrimSpace(parts[3])
+		}
+		endpoints = append(endpoints, ep)
+	}
+	return endpoints, nil
+}
+
+// loadURLList lee un archivo con una URL por línea (se ignoran las líneas vacías y las que empiezan con #)
+func loadURLList(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var urls []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	if len(urls) == 0 {
+		return nil, errors.New("el archivo no contiene URLs")
+	}
+	return urls, nil
+}
+
+// pickURL elige la URL de la próxima request: al azar o en round-robin según el contador compartido
+func pickURL(cfg RequestConfig, rng *rand.Rand, next *atomic.Int64) string {
+	if cfg.RandomizeURLs {
+		return cfg.URLs[rng.Intn(len(cfg.URLs))]
+	}
+	return cfg.URLs[(next.Add(1)-1)%int64(len(cfg.URLs))]
+}
+
+// pickBody elige el body de la próxima request con el mismo criterio que pickURL
+func pickBody(bodies []BodyEntry, random bool, rng *rand.Rand, next *atomic.Int64) BodyEntry {
+	if random {
+		return bodies[rng.Intn(len(bodies))]
+	}
+	return bodies[(next.Add(1)-1)%int64(len(bodies))]
+}
+
+// SummaryBucketBounds son los cortes (ms) de la distribución de latencias del resumen final
+var SummaryBucketBounds = []float64{100, 500}
+
+// formatLatencyBuckets resume qué porcentaje de las requests cayó en cada rango de latencia
+// (ej. "<100ms: 82.0%, 100-500ms: 15.0%, >500ms: 3.0%")
+func formatLatencyBuckets(results []BenchmarkResult, bounds []float64) string {
+	if len(results) == 0 || len(bounds) == 0 {
+		return ""
+	}
+	counts := make([]int, len(bounds)+1)
+	for _, r := range results {
+		i := sort.SearchFloat64s(bounds, r.Duration)
+		if i < len(bounds) && r.Duration == bounds[i] {
+			i++ // El límite pertenece al rango superior ("100-500ms" incluye 100)
+		}
+		counts[i]++
+	}
+	parts := make([]string, len(counts))
+	for i, c := range counts {
+		var label string
+		switch {
+		case i == 0:
+			label = fmt.Sprintf("<%.0fms", bounds[0])
+		case i == len(bounds):
+			label = fmt.Sprintf(">%.0fms", bounds[i-1])
+		default:
+			label = fmt.Sprintf("%.0f-%.0fms", bounds[i-1], bounds[i])
+		}
+		parts[i] = fmt.Sprintf("%s: %.1f%%", label, float64(c)/float64(len(results))*100)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// csvResultColumns son las columnas que parseResultsCSV necesita (en cualquier orden; el resto se ignora)
+var csvResultColumns = []string{"seq", "timestamp", "duration", "status"}
+
+// parseResultsCSV lee los resultados de una ejecución anterior desde un CSV con encabezado
+// (Seq, Timestamp, Duration en ms, Status). Las filas mal formadas se saltean y se cuentan en skipped.
+func parseResultsCSV(r io.Reader) (results []BenchmarkResult, skipped int, err error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // Las filas con columnas de menos se cuentan como salteadas
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, 0, fmt.Errorf("CSV vacío o inválido: %w", err)
+	}
+	col := map[string]int{}
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range csvResultColumns {
+		if _, ok := col[name]; !ok {
+			return nil, 0, fmt.Errorf("falta la columna %q (se esperan Seq, Timestamp, Duration y Status)", name)
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); ok {
+				skipped++
+				continue
+			}
+			return nil, 0, err
+		}
+		field := func(name string) string {
+			if i := col[name]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		seq, errSeq := strconv.Atoi(field("seq"))
+		duration, errDuration := strconv.ParseFloat(field("duration"), 64)
+		status, errStatus := strconv.Atoi(field("status"))
+		if errSeq != nil || errDuration != nil || errStatus != nil || duration < 0 {
+			skipped++
+			continue
+		}
+		results = append(results, BenchmarkResult{Seq: seq, Timestamp: field("timestamp"), Duration: duration, Status: status})
+	}
+	return results, skipped, nil
+}
+
+// csvElapsed estima la duración de una ejecución importada a partir de los timestamps HH:MM:SS
+// del primer y último resultado (0 si no se pueden interpretar)
+func csvElapsed(results []BenchmarkResult) time.Duration {
+	if len(results) == 0 {
+		return 0
+	}
+	first, err1 := time.Parse("15:04:05", results[0].Timestamp)
+	last, err2 := time.Parse("15:04:05", results[len(results)-1].Timestamp)
+	if err1 != nil || err2 != nil || last.Before(first) {
+		return 0
+	}
+	// Los timestamps tienen resolución de segundos: se cuenta el segundo del último resultado
+	return last.Sub(first) + time.Second
+}
+
+// InfluxMeasurement es el measurement de los registros exportados en line protocol
+const InfluxMeasurement = "http_request"
+
+// influxTagEscaper escapa los caracteres especiales de los valores de tag del line protocol
+var influxTagEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+
+// toInfluxLineProtocol convierte los resultados a InfluxDB line protocol, un registro por request:
+// http_request,url=<url>[,endpoint=<endpoint>][,body_file=<archivo>][,note=<nota>] duration=<ms>,status=<n>i <timestamp ns>
+func toInfluxLineProtocol(results []BenchmarkResult, note string) string {
+	var b strings.Builder
+	for _, r := range results {
+		b.WriteString(InfluxMeasurement)
+		if r.URL != "" {
+			b.WriteString(",url=" + influxTagEscaper.Replace(redactURL(r.URL)))
+		}
+		if r.Endpoint != "" {
+			b.WriteString(",endpoint=" + influxTagEscaper.Replace(r.Endpoint))
+		}
+		if r.BodyFile != "" {
+			b.WriteString(",body_file=" + influxTagEscaper.Replace(r.BodyFile))
+		}
+		if note != "" {
+			b.WriteString(",note=" + influxTagEscaper.Replace(note))
+		}
+		fmt.Fprintf(&b, " duration=%s,status=%di", strconv.FormatFloat(r.Duration, 'f', -1, 64), r.Status)
+		if !r.StartedAt.IsZero() {
+			fmt.Fprintf(&b, " %d", r.StartedAt.UnixNano())
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// postInfluxLineProtocol envía los registros al endpoint de escritura de InfluxDB
+// (ej. http://localhost:8086/api/v2/write?org=mi-org&bucket=bench&precision=ns).
+// token se envía como "Authorization: Token <token>" si no está vacío.
+func postInfluxLineProtocol(writeURL, token, data string) error {
+	req, err := http.NewRequest(http.MethodPost, writeURL, strings.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Token "+token)
+	}
+	return sendExport(req, "InfluxDB")
+}
+
+// sendExport ejecuta la request de una exportación y convierte las respuestas que no son 2xx en error
+func sendExport(req *http.Request, service string) error {
+	client := &http.Client{Timeout: DefaultRequestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s respondió %s: %s", service, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// DefaultPrometheusJob es el job con el que se agrupan las métricas en el Pushgateway
+const DefaultPrometheusJob = "benchmarkme"
+
+// prometheusMetrics formatea el resumen de una ejecución en el formato de texto de Prometheus.
+// Los percentiles se exponen como benchmark_latency_ms{quantile="0.95"}.
+func prometheusMetrics(stats BenchmarkStats) string {
+	var b strings.Builder
+	gauge := func(name, help string, value float64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", name, help, name, name, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	errorRatio := 0.0
+	if stats.Total > 0 {
+		errorRatio = float64(stats.Total-stats.Success) / float64(stats.Total)
+	}
+	gauge("benchmark_requests_total", "Requests ejecutadas en la última ejecución.", float64(stats.Total))
+	gauge("benchmark_latency_avg_ms", "Latencia promedio en milisegundos.", stats.Avg)
+	gauge("benchmark_error_ratio", "Proporción de requests fallidas (0-1).", errorRatio)
+	gauge("benchmark_requests_per_second", "Requests por segundo.", stats.RequestsPerSecond)
+	if stats.SteadyRequestsPerSecond > 0 {
+		gauge("benchmark_requests_per_second_steady", "Requests por segundo con todos los usuarios activos.", stats.SteadyRequestsPerSecond)
+	}
+	if len(stats.PercentileValues) > 0 {
+		b.WriteString("# HELP benchmark_latency_ms Percentiles de latencia en milisegundos.\n# TYPE benchmark_latency_ms gauge\n")
+		for _, p := range sortedPercentiles(stats.PercentileValues) {
+			// 'g' con 6 cifras: p/100 arrastra ruido de punto flotante (99.9/100 = 0.9990000000000001)
+			fmt.Fprintf(&b, "benchmark_latency_ms{quantile=\"%s\"} %s\n",
+				strconv.FormatFloat(p/100, 'g', 6, 64), strconv.FormatFloat(stats.PercentileValues[p], 'f', -1, 64))
+		}
+	}
+	return b.String()
+}
+
+// PushToPrometheus envía el resumen de la ejecución al Pushgateway en gatewayURL
+// (ej. http://localhost:9091), agrupado bajo job. POST reemplaza solo las métricas enviadas.
+func PushToPrometheus(stats BenchmarkStats, job, gatewayURL string) error {
+	if job == "" {
+		job = DefaultPrometheusJob
+	}
+	target := strings.TrimRight(gatewayURL, "/") + "/metrics/job/" + url.PathEscape(job)
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(prometheusMetrics(stats)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	return sendExport(req, "Pushgateway")
+}
+
+// withPercentiles retorna una copia de stats que incluye además los percentiles pedidos,
+// calculados sobre results si la ejecución no los tenía configurados
+func withPercentiles(stats BenchmarkStats, results []BenchmarkResult, percentiles ...float64) BenchmarkStats {
+	values := make(map[float64]float64, len(stats.PercentileValues)+len(percentiles))
+	for p, v := range stats.PercentileValues {
+		values[p] = v
+	}
+	durations := make([]float64, len(results))
+	for i, r := range results {
+		durations[i] = r.Duration
+	}
+	sort.Float64s(durations)
+	for _, p := range percentiles {
+		if _, ok := values[p]; !ok {
+			values[p] = percentile(durations, p/100)
+		}
+	}
+	stats.PercentileValues = values
+	return stats
+}
+
+// SecondBucket resume las requests iniciadas dentro de un mismo segundo de reloj
+type SecondBucket struct {
+	Second   time.Time
+	Requests int
+	Errors   int
+	AvgMs    float64
+}
+
+// aggregatePerSecond agrupa los resultados por el segundo en que se iniciaron, en orden cronológico.
+// Los segundos sin requests entre el primero y el último se incluyen vacíos para que se noten las pausas.
+// Los errores se cuentan con el mismo criterio que el resumen y el gráfico (countsAsError con cfg).
+func aggregatePerSecond(results []BenchmarkResult, cfg RequestConfig) []SecondBucket {
+	totals := make(map[time.Time]*SecondBucket)
+	var first, last time.Time
+	for _, r := range results {
+		if r.StartedAt.IsZero() {
+			continue
+		}
+		sec := r.StartedAt.Truncate(time.Second)
+		b, ok := totals[sec]
+		if !ok {
+			b = &SecondBucket{Second: sec}
+			totals[sec] = b
+		}
+		b.Requests++
+		b.AvgMs += r.Duration // Suma; se divide al final
+		if countsAsError(r, cfg) {
+			b.Errors++
+		}
+		if first.IsZero() || sec.Before(first) {
+			first = sec
+		}
+		if sec.After(last) {
+			last = sec
+		}
+	}
+	if len(totals) == 0 {
+		return nil
+	}
+	var buckets []SecondBucket
+	for sec := first; !sec.After(last); sec = sec.Add(time.Second) {
+		b := SecondBucket{Second: sec}
+		if t, ok := totals[sec]; ok {
+			b = *t
+			b.AvgMs /= float64(b.Requests)
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets
+}
+
+const TopOutliersCount = 10 // Requests listadas en "Top Outliers"
+
+// topOutliers retorna las n requests más lentas, de mayor a menor duración
+func topOutliers(results []BenchmarkResult, n int) []BenchmarkResult {
+	sorted := append([]BenchmarkResult(nil), results...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Duration > sorted[j].Duration })
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// groupAccumulator agrupa los resultados por endpoint o URL, de a uno por vez y manteniendo el orden de aparición
+type groupAccumulator struct {
+	index  map[string]int
+	out    []EndpointStats   // Avg guarda la suma hasta llamar a stats
+	hist   []map[float64]int // Histograma de duraciones de cada grupo, para su P95
+	errors []int             // Requests que cuentan como error en cada grupo
+}
+
+func (g *groupAccumulator) add(name string, r BenchmarkResult, cfg RequestConfig) {
+	if g.index == nil {
+		g.index = make(map[string]int)
+	}
+	i, ok := g.index[name]
+	if !ok {
+		i = len(g.out)
+		g.index[name] = i
+		g.out = append(g.out, EndpointStats{Name: name, Min: r.Duration, Max: r.Duration})
+		g.hist = append(g.hist, make(map[float64]int))
+		g.errors = append(g.errors, 0)
+	}
+	es := &g.out[i]
+	es.Total++
+	if succeeded(r, cfg) {
+		es.Success++
+	} else if countsAsError(r, cfg) {
+		g.errors[i]++
+	}
+	g.hist[i][histogramKey(r.Duration)]++
+	es.Avg += r.Duration
+	es.Min = min(es.Min, r.Duration)
+	es.Max = max(es.Max, r.Duration)
+}
+
+// stats retorna los grupos en orden de aparición con el promedio, el P95 y el error rate ya calculados
+func (g *groupAccumulator) stats() []EndpointStats {
+	if len(g.out) == 0 {
+		return nil
+	}
+	out := append([]EndpointStats(nil), g.out...)
+	for i := range out {
+		out[i].Avg /= float64(out[i].Total)
+		out[i].P95 = histogramPercentile(g.hist[i], out[i].Total, 0.95)
+		out[i].ErrorRate = float64(g.errors[i]) * 100 / float64(out[i].Total)
+	}
+	return out
+}
+
+// rankEndpoints ordena los grupos del peor al mejor P95 (a igual P95, mayor error rate primero)
+func rankEndpoints(endpoints []EndpointStats) []EndpointStats {
+	ranked := append([]EndpointStats(nil), endpoints...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].P95 != ranked[j].P95 {
+			return ranked[i].P95 > ranked[j].P95
+		}
+		return ranked[i].ErrorRate > ranked[j].ErrorRate
+	})
+	return ranked
+}
+
+// resultAggregate acumula las estadísticas de todos los resultados registrados, para poder
+// descartar los resultados completos más antiguos sin perder precisión (MaxRetainedResults)
+type resultAggregate struct {
+	total, success, slow                    int
+	sumMs, minMs, maxMs                     float64
+	durations                               map[float64]int // Histograma de duraciones redondeadas con histogramKey, para que haya pocos valores distintos
+	dnsMs, connectMs, tlsMs, ttfbMs, ttlbMs float64         // Sumas del desglose de latencia
+	dnsCount, connectCount, tlsCount        int             // Requests con esa fase (una conexión reutilizada no la tiene)
+	timeouts, connectTimeouts               int
+	rateLimited                             int
+	throttled                               int
+	groups                                  groupAccumulator
+	groupKey                                func(BenchmarkResult) string // nil = sin desglose por endpoint/URL
+}
+
+func newResultAggregate(cfg RequestConfig) *resultAggregate {
+	a := &resultAggregate{durations: make(map[float64]int)}
+	if len(cfg.Endpoints) > 0 || len(cfg.Scenario) > 0 {
+		a.groupKey = func(r BenchmarkResult) string { return r.Endpoint }
+	} else if len(cfg.URLs) > 0 {
+		a.groupKey = func(r BenchmarkResult) string { return r.URL }
+	}
+	return a
+}
+
+func (a *resultAggregate) add(r BenchmarkResult, cfg RequestConfig) {
+	a.total++
+	if succeeded(r, cfg) {
+		a.success++
+	} else if isRateLimited(r, cfg) {
+		a.rateLimited++
+	}
+	if r.RetryAfterMs > 0 {
+		a.throttled++
+	}
+	if cfg.SlowThresholdMs > 0 && r.Duration > float64(cfg.SlowThresholdMs) {
+		a.slow++
+	}
+	a.sumMs += r.Duration
+	if a.total == 1 {
+		a.minMs, a.maxMs = r.Duration, r.Duration
+	}
+	a.minMs = min(a.minMs, r.Duration)
+	a.maxMs = max(a.maxMs, r.Duration)
+	a.durations[histogramKey(r.Duration)]++
+	if r.DNSMs > 0 {
+		a.dnsMs += r.DNSMs
+		a.dnsCount++
+	}
+	if r.ConnectMs > 0 {
+		a.connectMs += r.ConnectMs
+		a.connectCount++
+	}
+	if r.TLSMs > 0 {
+		a.tlsMs += r.TLSMs
+		a.tlsCount++
+	}
+	a.ttfbMs += r.TTFBMs
+	a.ttlbMs += r.TTLBMs
+	switch r.ErrorKind {
+	case "timeout":
+		a.timeouts++
+	case "connect_timeout":
+		a.connectTimeouts++
+	}
+	if a.groupKey != nil {
+		a.groups.add(a.groupKey(r), r, cfg)
+	}
+}
+
+// histogramKey redondea ms a 3 cifras significativas: con resolución de microsegundos el histograma
+// sigue teniendo pocas claves y el error de los percentiles queda por debajo del 0,5%
+func histogramKey(ms float64) float64 {
+	if ms <= 0 {
+		return 0
+	}
+	scale := math.Pow(10, 2-math.Floor(math.Log10(ms)))
+	return math.Round(ms*scale) / scale
+}
+
+// percentile retorna el percentil p (0-1) con el mismo criterio que percentile sobre la lista ordenada
+// (con la precisión de histogramKey)
+func (a *resultAggregate) percentile(p float64) float64 {
+	return histogramPercentile(a.durations, a.total, p)
+}
+
+// histogramPercentile calcula el percentil p (0-1) de un histograma de duraciones con total valores
+func histogramPercentile(hist map[float64]int, total int, p float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	values := make([]float64, 0, len(hist))
+	for d := range hist {
+		values = append(values, d)
+	}
+	sort.Float64s(values)
+	idx := min(int(p*float64(total)), total-1)
+	for _, d := range values {
+		idx -= hist[d]
+		if idx < 0 {
+			return d
+		}
+	}
+	return values[len(values)-1]
+}
+
+// counters retorna las estadísticas que se mantienen al día con cada resultado (totales, promedio,
+// mín/máx, lentas, error rate y throughput sobre elapsed). Es O(1): sirve para las actualizaciones parciales.
+func (a *resultAggregate) counters(cfg RequestConfig, elapsed time.Duration) BenchmarkStats {
+	stats := BenchmarkStats{
+		Total:           a.total,
+		Success:         a.success,
+		Min:             a.minMs,
+		Max:             a.maxMs,
+		TotalDuration:   a.sumMs,
+		SlowThresholdMs: cfg.SlowThresholdMs,
+		SlowCount:       a.slow,
+		RateLimited:     a.rateLimited,
+		Throttled:       a.throttled,
+	}
+	if a.total > 0 {
+		stats.Avg = a.sumMs / float64(a.total)
+		stats.ErrorRate = ((a.total - a.success - a.rateLimited) * 100) / a.total
+		if elapsed > 0 {
+			stats.RequestsPerSecond = float64(a.total) / elapsed.Seconds()
+		}
+	}
+	return stats
+}
+
+// apply completa stats con el desglose de latencia, timeouts, grupos y percentiles acumulados
+func (a *resultAggregate) apply(stats *BenchmarkStats, cfg RequestConfig) {
+	if a.total == 0 {
+		return
+	}
+	n := float64(a.total)
+	// DNS, TCP y TLS solo ocurren al abrir una conexión: se promedian sobre las requests que los tuvieron
+	stats.AvgDNSMs = averageOver(a.dnsMs, a.dnsCount)
+	stats.AvgConnectMs = averageOver(a.connectMs, a.connectCount)
+	stats.AvgTLSMs = averageOver(a.tlsMs, a.tlsCount)
+	stats.NewConnections = a.connectCount
+	stats.AvgTTFBMs = a.ttfbMs / n
+	stats.AvgTTLBMs = a.ttlbMs / n
+	stats.TimeoutCount = a.timeouts
+	stats.ConnectTimeoutCount = a.connectTimeouts
+	stats.Endpoints = a.groups.stats()
+	stats.PercentileValues = percentilesFrom(cfg.Percentiles, a.percentile)
+	checkP95SLA(stats, a.percentile(0.95), cfg)
+}
+
+// averageOver retorna sum/count, o 0 sin valores
+func averageOver(sum float64, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
+// --- PARÁMETROS DEL MOTOR ---
+
+const SafeConcurrentUsers = 1000 // Por encima de este valor se pide confirmación antes de ejecutar
+const MaxConcurrentUsers = 10000 // Límite absoluto de usuarios concurrentes (goroutines + conexiones)
+const MaxTargetRPS = 1000000     // Límite de requests/s configurable (un tick del ritmo global cada 1 µs)
+
+const TitleUpdateInterval = 500 * time.Millisecond // Intervalo mínimo entre cambios del título con el progreso
+
+const DefaultSafeTotalRequests = 1000000 // Por encima de este total se pide confirmación (configurable en Ajustes; 0 = nunca)
+
+const DefaultErrorRateWindow = 20 // Requests consideradas por defecto en la ventana del circuit breaker
+
+// DefaultPercentiles son los percentiles calculados si el usuario no elige otros
+var DefaultPercentiles = []float64{90, 95, 99}
+
+// MinPercentileSamples es la cantidad mínima de requests para mostrar percentiles;
+// con menos muestras un P99 es simplemente el máximo y resulta engañoso
+const MinPercentileSamples = 20
+
+// DefaultMaxRetainedResults limita los resultados completos en memoria en tests largos;
+// por encima se conservan los más recientes y las estadísticas se siguen acumulando
+const DefaultMaxRetainedResults = 200000
+
+// --- CRITERIO DE ÉXITO ---
+
+// Rango de status considerado exitoso por defecto (2xx y 3xx)
+const (
+	DefaultSuccessStatusMin = 200
+	DefaultSuccessStatusMax = 399
+)
+
+// successStatusRange retorna el rango de status exitosos configurado (o el rango por defecto)
+func successStatusRange(cfg RequestConfig) (int, int) {
+	min, max := cfg.SuccessStatusMin, cfg.SuccessStatusMax
+	if min <= 0 {
+		min = DefaultSuccessStatusMin
+	}
+	if max <= 0 {
+		max = DefaultSuccessStatusMax
+	}
+	return min, max
+}
+
+// parseSuccessStatusRange lee el rango de status exitoso del formulario. Un campo vacío usa el valor
+// por defecto (0); los demás deben ser status HTTP (100-599) y el mínimo no puede superar al máximo.
+func parseSuccessStatusRange(minText, maxText string) (int, int, error) {
+	var bounds [2]int
+	for i, text := range []string{minText, maxText} {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if _, err := fmt.Sscanf(text, "%d", &bounds[i]); err != nil || bounds[i] < 100 || bounds[i] > 599 {
+			return 0, 0, fmt.Errorf("status exitoso inválido: %q (debe estar entre 100 y 599)", text)
+		}
+	}
+	if lo, hi := successStatusRange(RequestConfig{SuccessStatusMin: bounds[0], SuccessStatusMax: bounds[1]}); lo > hi {
+		return 0, 0, fmt.Errorf("rango de status exitoso inválido: %d > %d", lo, hi)
+	}
+	return bounds[0], bounds[1], nil
+}
+
+// isSuccess es el único criterio de éxito de una request: lo usan el motor, las estadísticas
+// y el error rate del gráfico para que siempre coincidan (status 0 = error de conexión)
+func isSuccess(status int, cfg RequestConfig) bool {
+	min, max := successStatusRange(cfg)
+	return status >= min && status <= max
+}
+
+// succeeded aplica isSuccess a un resultado; una respuesta que no cumple el JSON Schema falla aunque su status sea exitoso
+func succeeded(r BenchmarkResult, cfg RequestConfig) bool {
+	return r.ErrorKind == "" && isSuccess(r.Status, cfg)
+}
+
+// isRateLimited indica si r es un 429 que cfg cuenta aparte (SeparateRateLimited) en lugar de como error
+func isRateLimited(r BenchmarkResult, cfg RequestConfig) bool {
+	return cfg.SeparateRateLimited && r.ErrorKind == "" && r.Status == http.StatusTooManyRequests
+}
+
+// countsAsError indica si r suma al error rate: no fue exitoso y no es un 429 contado aparte
+func countsAsError(r BenchmarkResult, cfg RequestConfig) bool {
+	return !succeeded(r, cfg) && !isRateLimited(r, cfg)
+}
+
+// --- RETRY-AFTER ---
+
+const MaxRetryAfter = 30 * time.Second // Tope de la espera pedida por un Retry-After (HonorRetryAfter)
+
+// retryAfterDelay retorna la espera pedida por el header Retry-After de una respuesta 429 o 503, en segundos
+// o como fecha HTTP, limitada a MaxRetryAfter (ok = false si el status no aplica o el header falta o es inválido)
+func retryAfterDelay(status int, header string, now time.Time) (time.Duration, bool) {
+	if status != http.StatusTooManyRequests && status != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, false
+	}
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(header); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(header); err == nil {
+		delay = at.Sub(now)
+	} else {
+		return 0, false
+	}
+	return min(max(delay, 0), MaxRetryAfter), true
+}
+
+// --- VALIDACIÓN DE RESPUESTAS (JSON SCHEMA) ---
+
+// compileResponseSchema compila el JSON Schema de las respuestas (nil si text está vacío)
+func compileResponseSchema(text string) (*jsonschema.Schema, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(text))
+	if err != nil {
+		return nil, fmt.Errorf("el schema no es JSON válido: %w", err)
+	}
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("urn:benchmarkme:response-schema", doc); err != nil {
+		return nil, err
+	}
+	return c.Compile("urn:benchmarkme:response-schema")
+}
+
+// checkResponseSchema lee el body completo, lo valida contra schema y lo deja disponible para
+// volver a leerse. Retorna el primer error de validación ("" = la respuesta cumple el schema).
+func checkResponseSchema(schema *jsonschema.Schema, resp *http.Response) string {
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Sprintf("error al leer el body: %v", err)
+	}
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Sprintf("el body no es JSON válido: %v", err)
+	}
+	err = schema.Validate(inst)
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return ""
+	}
+	// El error raíz solo resume; el primer detalle concreto está en la primera hoja
+	for len(ve.Causes) > 0 {
+		ve = ve.Causes[0]
+	}
+	return ve.Error()
+}
+
+// --- LOG DE REQUESTS ---
+
+const DefaultLogBodyMaxBytes = 4096 // Tamaño por defecto del body capturado en el log
+
+// LogEntry es una línea del archivo de log (formato JSON Lines)
+type LogEntry struct {
+	Timestamp       string              `json:"timestamp"`
+	Seq             int                 `json:"seq"`
+	User            int                 `json:"user"`
+	Method          string              `json:"method"`
+	URL             string              `json:"url"`
+	Status          int                 `json:"status"`
+	DurationMs      float64             `json:"duration_ms"`
+	Error           string              `json:"error,omitempty"`
+	ErrorKind       string              `json:"error_kind,omitempty"`
+	RequestHeaders  map[string][]string `json:"request_headers,omitempty"`
+	ResponseHeaders map[string][]string `json:"response_headers,omitempty"`
+	ResponseBody    string              `json:"response_body,omitempty"`
+	BodyTruncated   bool                `json:"body_truncated,omitempty"`
+}
+
+// requestLogger escribe entradas de log con I/O bufferizado y es seguro para usar
+// desde varios usuarios concurrentes
+type requestLogger struct {
+	mu   sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+	enc  *json.Encoder
+	err  error // Primer error de escritura; a partir de él no se escribe más
+}
+
+// openLogFile abre el archivo de log en modo append, creándolo si no existe
+func openLogFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+}
+
+func newRequestLogger(path string) (*requestLogger, error) {
+	f, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	return &requestLogger{file: f, w: w, enc: json.NewEncoder(w)}, nil
+}
+
+// Log agrega una entrada; un error de escritura se conserva y Close lo retorna
+func (l *requestLogger) Log(entry LogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err == nil {
+		l.err = l.enc.Encode(entry)
+	}
+}
+
+// Close vacía el buffer y cierra el archivo. Retorna el primer error de escritura, vaciado o cierre.
+func (l *requestLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err == nil {
+		l.err = l.w.Flush()
+	}
+	if closeErr := l.file.Close(); l.err == nil {
+		l.err = closeErr
+	}
+	return l.err
+}
+
+// readLoggedBody lee como máximo maxBytes del body, indicando si fue truncado
+func readLoggedBody(body io.Reader, maxBytes int) (string, bool) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultLogBodyMaxBytes
+	}
+	data, _ := io.ReadAll(io.LimitReader(body, int64(maxBytes)+1))
+	if len(data) > maxBytes {
+		return string(data[:maxBytes]), true
+	}
+	return string(data), false
+}
+
+func runLoadTest(cfg RequestConfig, progress func(float64), cancelChan <-chan bool, realtimeUpdate func([]BenchmarkResult, BenchmarkStats)) ([]BenchmarkResult, BenchmarkStats) {
+	// Leer los bodies desde la carpeta una sola vez; cada request envía uno de ellos
+	var bodies []BodyEntry
+	if cfg.BodyDir != "" {
+		var err error
+		if bodies, err = loadBodyDir(cfg.BodyDir); err != nil {
+			return nil, BenchmarkStats{Aborted: true, AbortReason: fmt.Sprintf("carpeta de bodies: %v", err)}
+		}
+		cfg.Body, cfg.BodyFile, cfg.BodyDir = bodies[0].Content, "", ""
+	}
+	// Leer el body desde archivo una sola vez; se reutiliza en todas las requests
+	cfg, err := loadBodyFile(cfg)
+	if err != nil {
+		return nil, BenchmarkStats{Aborted: true, AbortReason: fmt.Sprintf("archivo de body: %v", err)}
+	}
+	schema, _ := compileResponseSchema(cfg.ResponseSchema) // Ya validado antes de ejecutar
+	// Compilar el script una sola vez; buildRequest lo ejecuta antes de cada request
+	if cfg.PreRequest == nil {
+		hook, err := compilePreRequestScript(cfg.PreRequestScript)
+		if err != nil {
+			return nil, BenchmarkStats{Aborted: true, AbortReason: fmt.Sprintf("script pre-request: %v", err)}
+		}
+		cfg.PreRequest = hook
+	}
+
+	results := make([]BenchmarkResult, 0)
+	resultsMutex := sync.Mutex{}
+	recorded := 0 // Resultados registrados; puede superar len(results) con MaxRetainedResults
+	aggregate := newResultAggregate(cfg)
+	var pending []BenchmarkResult // Resultados aún no enviados a realtimeUpdate
+
+	connLimitHit := false
+
+	startTime := time.Now()
+	var endTime time.Time
+
+	// Determinar modo: por tiempo o por cantidad
+	useDuration := cfg.Duration > 0
+	if useDuration {
+		endTime = startTime.Add(time.Duration(cfg.Duration) * time.Second)
+	}
+
+	// Abrir log de requests si está configurado (se cierra al terminar o cancelar)
+	var logger *requestLogger
+	if cfg.LogFile != "" {
+		if logger, err = newRequestLogger(cfg.LogFile); err != nil {
+			return nil, BenchmarkStats{Aborted: true, AbortReason: fmt.Sprintf("log de requests: %v", err)}
+		}
+	}
+
+	// Total de requests esperado (para el progreso en modo por cantidad)
+	targetTotal := cfg.Count
+	if cfg.CountMode == CountModePerUser && cfg.ConcurrentUsers > 1 {
+		targetTotal = cfg.Count * cfg.ConcurrentUsers
+	}
+
+	// Calentamiento: en modo por tiempo lo iniciado antes de warmupEnd no se registra y
+	// el throughput se mide desde measureStart
+	var warmupEnd time.Time
+	measureStart := startTime
+	if useDuration && cfg.WarmupSeconds > 0 {
+		warmupEnd = startTime.Add(time.Duration(cfg.WarmupSeconds) * time.Second)
+		measureStart = warmupEnd
+	}
+
+	// Identificadores para correlacionar requests en los logs del servidor
+	runID := newRunID()
+	var requestSeq atomic.Int64
+	var inFlight atomic.Int64   // Requests enviadas que aún no terminaron
+	var urlCursor atomic.Int64  // Round-robin compartido sobre cfg.URLs
+	var bodyCursor atomic.Int64 // Round-robin compartido sobre los bodies de cfg.BodyDir
+
+	// Circuit breaker: error rate sobre una ventana móvil de las últimas N requests
+	errorWindow := cfg.ErrorRateWindow
+	if errorWindow <= 0 {
+		errorWindow = DefaultErrorRateWindow
+	}
+	recentErrors := make([]bool, 0, errorWindow)
+	recentIdx := 0
+	recentErrorCount := 0
+	abortChan := make(chan struct{})
+	var abortOnce sync.Once
+	var abortReason string
+	var firstFailure *SingleResponse // Solo en modo AbortOnFirstError
+
+	// Régimen estable: desde que todos los usuarios enviaron su primera request hasta que el primero termina.
+	// Se guarda el contador de requests registradas en cada extremo (protegido por resultsMutex)
+	totalUsers := int64(max(cfg.ConcurrentUsers, 1))
+	var startedUsers atomic.Int64
+	var steadyStart, steadyEnd time.Time
+	var steadyStartCount, steadyEndCount int
+	var steadyEndOnce sync.Once
+	scenarioSteps := make([]SingleResponse, len(cfg.Scenario)) // Última vuelta de cada paso, para depurar el escenario
+
+	// Timeout global: corta la ejecución aunque no se haya alcanzado Count (ej. backend colgado).
+	// requestEnd es el límite de las requests en curso: el fin del modo por tiempo o el timeout global
+	requestEnd := endTime
+	var runDeadline time.Time
+	if cfg.MaxRunDurationSeconds > 0 {
+		runDeadline = startTime.Add(time.Duration(cfg.MaxRunDurationSeconds) * time.Second)
+		if requestEnd.IsZero() || runDeadline.Before(requestEnd) {
+			requestEnd = runDeadline
+		}
+		runTimer := time.AfterFunc(time.Until(runDeadline), func() {
+			abortOnce.Do(func() {
+				abortReason = runTimeoutReason(cfg)
+				close(abortChan)
+			})
+		})
+		defer runTimer.Stop()
+	}
+
+	// Ritmo global: con TargetRPS cada request espera un tick compartido por todos los usuarios.
+	// Los ticks que nadie toma se pierden, así el ritmo nunca supera el objetivo.
+	var pacer *time.Ticker
+	if cfg.TargetRPS > 0 {
+		pacer = time.NewTicker(max(time.Second/time.Duration(cfg.TargetRPS), time.Nanosecond)) // NewTicker no acepta 0
+		defer pacer.Stop()
+	}
+
+	// WaitGroup para sincronizar usuarios concurrentes
+	var wg sync.WaitGroup
+
+	// Función que ejecuta requests para un usuario
+	executeUser := func(userID int) {
+		defer wg.Done()
+		defer func() {
+			resultsMutex.Lock()
+			steadyEndOnce.Do(func() { steadyEnd, steadyEndCount = time.Now(), recorded })
+			resultsMutex.Unlock()
+		}()
+		userStarted := false
+
+		client := newHTTPClient(cfg)
+		if cfg.UseCookieJar {
+			// Jar propio por usuario: las cookies de sesión se reenvían solo dentro de su secuencia
+			if jar, err := cookiejar.New(nil); err == nil {
+				client.Jar = jar
+			}
+		}
+		requestCount := 0
+		scenarioPos := 0                               // Próximo paso del escenario de este usuario
+		stepStatuses := make([]int, len(cfg.Scenario)) // Status de cada paso en la vuelta actual, para las condiciones
+		rng := rand.New(rand.NewSource(time.Now().UnixNano() + int64(userID)))
+
+		// waitRetryAfter hace la espera pedida con Retry-After (en modo por tiempo, hasta el fin de la
+		// ejecución). Retorna false si la ejecución se canceló o abortó mientras esperaba.
+		waitRetryAfter := func(retryAfter time.Duration) bool {
+			if retryAfter <= 0 {
+				return true
+			}
+			if useDuration {
+				retryAfter = min(retryAfter, time.Until(endTime))
+			}
+			select {
+			case <-cancelChan:
+				return false
+			case <-abortChan:
+				return false
+			case <-time.After(retryAfter):
+				return true
+			}
+		}
+
+		for {
+			// Verificar cancelación
+			select {
+			case <-cancelChan:
+				return
+			case <-abortChan:
+				return
+			default:
+			}
+
+			// Verificar condición de parada ANTES de iniciar cualquier request
+			if useDuration {
+				// Validación estricta: NO iniciar request si el tiempo ha expirado
+				if time.Now().After(endTime) {
+					break
+				}
+			} else if cfg.CountMode == CountModePerUser {
+				// Cada usuario ejecuta exactamente Count requests
+				if requestCount >= cfg.Count {
+					break
+				}
+			} else {
+				resultsMutex.Lock()
+				currentTotal := recorded
+				resultsMutex.Unlock()
+
+				if currentTotal >= cfg.Count {
+					break
+				}
+			}
+
+			if pacer != nil {
+				select {
+				case <-cancelChan:
+					return
+				case <-abortChan:
+					return
+				case <-pacer.C:
+				}
+				if useDuration && time.Now().After(endTime) {
+					break
+				}
+			}
+
+			// Elegir el paso del escenario, el endpoint según los pesos (tráfico mixto) o usar la configuración principal
+			reqCfg := cfg
+			endpointName := ""
+			step := -1 // Índice del paso del escenario (-1 fuera de un escenario)
+			if len(cfg.Scenario) > 0 {
+				step = scenarioPos % len(cfg.Scenario)
+				scenarioPos++
+				if step == 0 {
+					clear(stepStatuses)
+				}
+				current := cfg.Scenario[step]
+				if !current.Condition.Allows(stepStatuses) {
+					// Paso omitido: no cuenta como request y los pasos que dependan de él lo ven con status 0
+					stepStatuses[step] = 0
+					resultsMutex.Lock()
+					scenarioSteps[step] = SingleResponse{Body: fmt.Sprintf("omitido (el paso %d respondió %d, se esperaba %d)",
+						current.Condition.Step+1, stepStatuses[current.Condition.Step], current.Condition.Status)}
+					resultsMutex.Unlock()
+					continue
+				}
+				if current.DelayMs > 0 {
+					select {
+					case <-cancelChan:
+						return
+					case <-abortChan:
+						return
+					case <-time.After(time.Duration(current.DelayMs) * time.Millisecond):
+					}
+					if useDuration && time.Now().After(endTime) {
+						break
+					}
+				}
+				reqCfg, endpointName = scenarioStep(cfg, step)
+			} else if len(cfg.Endpoints) > 0 {
+				reqCfg, endpointName = pickWeightedEndpoint(cfg, rng)
+			} else if len(cfg.URLs) > 0 {
+				reqCfg.URL = pickURL(cfg, rng, &urlCursor)
+			}
+			bodyName := ""
+			if step < 0 && endpointName == "" && len(bodies) > 0 {
+				entry := pickBody(bodies, cfg.RandomizeBodies, rng, &bodyCursor)
+				reqCfg.Body, bodyName = entry.Content, entry.Name
+			}
+
+			// Número de request en orden de envío, para {{seq}} y X-Request-Seq
+			seq := requestSeq.Add(1)
+			reqCfg = expandSeqToken(reqCfg, seq)
+			var retryAfter time.Duration // Espera pedida por el servidor antes de la próxima request (HonorRetryAfter)
+
+			if !userStarted {
+				userStarted = true
+				if startedUsers.Add(1) == totalUsers {
+					resultsMutex.Lock()
+					steadyStart, steadyStartCount = time.Now(), recorded
+					resultsMutex.Unlock()
+				}
+			}
+
+			// Ejecutar request
+			req, authInfo, err := buildRequest(reqCfg)
+			if err == nil {
+				if cfg.TagRequests {
+					req.Header.Set("X-Run-Id", runID)
+					req.Header.Set("X-Request-Seq", strconv.FormatInt(seq, 10))
+				}
+
+				// En modo por tiempo la request se cancela al terminar la ejecución
+				req, cancel := requestContext(req, cfg, requestEnd)
+				req, timing := traceRequest(req)
+				start := time.Now()
+				timing.start = start
+				inFlight.Add(1)
+				resp, err := client.Do(req)
+				inFlight.Add(-1)
+				duration := durationMs(time.Since(start))
+				warmingUp := start.Before(warmupEnd)
+
+				// Una request cortada por el fin de la ejecución (o el timeout global) no es un timeout del endpoint: se descarta
+				if err != nil && endedWithRun(req) {
+					cancel()
+					break
+				}
+
+				status := 0
+				ttlb := duration
+				errorKind := classifyError(err)
+				var entry LogEntry
+				errorDetail := ""
+				var captured *SingleResponse // Respuesta completa de una falla en modo AbortOnFirstError o de un paso del escenario
+				var failure *SingleResponse  // La captura, si es la falla que detiene el test
+				if err == nil {
+					status = resp.StatusCode
+					if cfg.HonorRetryAfter {
+						retryAfter, _ = retryAfterDelay(status, resp.Header.Get("Retry-After"), time.Now())
+					}
+					if schema != nil && isSuccess(status, cfg) {
+						if errorDetail = checkResponseSchema(schema, resp); errorDetail != "" {
+							errorKind = "schema"
+						}
+					}
+					failed := countsAsError(BenchmarkResult{Status: status, ErrorKind: errorKind}, cfg)
+					if step >= 0 || cfg.AbortOnFirstError && failed {
+						// Capturar el body completo; el log lee luego la misma copia
+						body := readCappedBody(resp, cfg.MaxBodyCaptureBytes)
+						captured = &SingleResponse{Request: req, AuthInfo: authInfo, Body: body,
+							ContentType: resp.Header.Get("Content-Type"), Headers: resp.Header, Proto: resp.Proto}
+						resp.Body = io.NopCloser(strings.NewReader(body))
+						if cfg.AbortOnFirstError && failed {
+							failure = captured
+						}
+					}
+					if logger != nil {
+						if errorKind != "" {
+							entry.Error, entry.ErrorKind = errorDetail, errorKind
+						}
+						entry.ResponseHeaders = redactHTTPHeader(resp.Header)
+						if cfg.LogBodies {
+							entry.ResponseBody, entry.BodyTruncated = readLoggedBody(resp.Body, cfg.LogBodyMaxBytes)
+						}
+					}
+					// Leer el resto del body para medir el tiempo hasta el último byte
+					io.Copy(io.Discard, resp.Body)
+					ttlb = durationMs(time.Since(start))
+					resp.Body.Close()
+				} else {
+					entry.Error = err.Error()
+					entry.ErrorKind = errorKind
+					if step >= 0 || cfg.AbortOnFirstError {
+						captured = &SingleResponse{Request: req, AuthInfo: authInfo, Body: fmt.Sprintf("Error: %v", err), Err: err}
+						if cfg.AbortOnFirstError {
+							failure = captured
+						}
+					}
+					if errors.Is(err, syscall.EMFILE) || errors.Is(err, syscall.ENFILE) {
+						resultsMutex.Lock()
+						connLimitHit = true
+						resultsMutex.Unlock()
+					}
+				}
+
+				cancel()
+				if step >= 0 {
+					stepStatuses[step] = status
+				}
+
+				// Las requests del calentamiento no cuentan en las estadísticas ni en el gráfico
+				if warmingUp {
+					// El servidor puede pedir esperar también durante el calentamiento
+					if !waitRetryAfter(retryAfter) {
+						return
+					}
+					if pacer == nil {
+						time.Sleep(10 * time.Millisecond)
+					}
+					continue
+				}
+
+				// Guardar resultado de forma segura
+				resultsMutex.Lock()
+				if cfg.StopIfErrorRateExceeds > 0 {
+					// Los 429 que se cuentan aparte no disparan el circuit breaker, igual que en el error rate
+					isError := countsAsError(BenchmarkResult{Status: status, ErrorKind: errorKind}, cfg)
+					if len(recentErrors) < errorWindow {
+						recentErrors = append(recentErrors, isError)
+					} else {
+						if recentErrors[recentIdx] {
+							recentErrorCount--
+						}
+						recentErrors[recentIdx] = isError
+						recentIdx = (recentIdx + 1) % errorWindow
+					}
+					if isError {
+						recentErrorCount++
+					}
+
+					if len(recentErrors) >= errorWindow {
+						windowRate := float64(recentErrorCount) * 100 / float64(errorWindow)
+						if windowRate > cfg.StopIfErrorRateExceeds {
+							abortOnce.Do(func() {
+								abortReason = fmt.Sprintf("error rate de %.1f%% en las últimas %d requests (límite %.1f%%)",
+									windowRate, errorWindow, cfg.StopIfErrorRateExceeds)
+								close(abortChan)
+							})
+						}
+					}
+				}
+
+				requestCount++
+				recorded++
+				result := BenchmarkResult{
+					Seq:         recorded,
+					Timestamp:   start.Format("15:04:05"),
+					StartedAt:   start,
+					Duration:    duration,
+					Status:      status,
+					Endpoint:    endpointName,
+					ErrorKind:   errorKind,
+					ErrorDetail: errorDetail,
+					TTLBMs:      ttlb,
+				}
+				if retryAfter > 0 {
+					result.RetryAfterMs = durationMs(retryAfter)
+				}
+				if endpointName == "" && len(cfg.URLs) > 0 {
+					result.URL = reqCfg.URL
+				}
+				result.BodyFile = bodyName
+				timing.apply(&result)
+				aggregate.add(result, cfg)
+				results = append(results, result)
+				if realtimeUpdate != nil {
+					pending = append(pending, result)
+				}
+				if keep := cfg.MaxRetainedResults; keep > 0 && len(results) >= 2*keep {
+					// Descartar los más antiguos de a bloques: el costo de la copia se reparte entre keep appends
+					results = results[:copy(results, results[len(results)-keep:])]
+				}
+
+				if step >= 0 && captured != nil {
+					captured.Result = result
+					captured.RequestBody = describeBody(reqCfg)
+					scenarioSteps[step] = *captured
+				}
+
+				// Modo debug: la primera falla detiene a todos los usuarios y se conserva para mostrarla
+				if failure != nil {
+					failure.Result = result
+					abortOnce.Do(func() {
+						abortReason = fmt.Sprintf("primer error (status %d)", status)
+						if errorKind == "schema" {
+							abortReason = fmt.Sprintf("primer error (schema: %s)", errorDetail)
+						}
+						firstFailure = failure
+						close(abortChan)
+					})
+				}
+
+				currentTotal := recorded
+
+				if logger != nil {
+					entry.Timestamp = start.Format(time.RFC3339Nano)
+					entry.Seq = currentTotal
+					entry.User = userID
+					entry.Method = req.Method
+					entry.URL = redactURL(req.URL.String())
+					entry.Status = status
+					entry.DurationMs = duration
+					entry.RequestHeaders = redactHTTPHeader(req.Header) // El log nunca guarda credenciales
+					logger.Log(entry)
+				}
+
+				// Actualizar UI en tiempo real (throttle cada 5 requests) solo con los resultados nuevos:
+				// el gráfico acumula su propia vista. Se envía con el lock tomado para respetar el orden.
+				if realtimeUpdate != nil && currentTotal%5 == 0 {
+					partialStats := aggregate.counters(cfg, time.Since(measureStart))
+					applyRecentStats(&partialStats, results)
+					partialStats.InFlight = int(inFlight.Load())
+					realtimeUpdate(pending, partialStats)
+					pending = nil
+				}
+				resultsMutex.Unlock()
+
+				// Actualizar progreso
+				if progress != nil {
+					var progressValue float64
+					if useDuration {
+						elapsed := time.Since(startTime).Seconds()
+						progressValue = elapsed / float64(cfg.Duration)
+					} else {
+						progressValue = float64(currentTotal) / float64(targetTotal)
+					}
+					progress(progressValue)
+				}
+			} else {
+				// Una request que no se puede construir (URL inválida, hook con error) fallaría siempre
+				abortOnce.Do(func() {
+					abortReason = fmt.Sprintf("error al construir la request (%v)", err)
+					close(abortChan)
+				})
+			}
+
+			// Retry-After: el servidor pidió esperar antes de la próxima request de este usuario
+			if !waitRetryAfter(retryAfter) {
+				return
+			}
+
+			// Pequeña pausa para no saturar; con límite de RPS el pacer ya marca el ritmo
+			if pacer == nil {
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+	}
+
+	// Lanzar usuarios concurrentes
+	users := cfg.ConcurrentUsers
+	if users < 1 {
+		users = 1
+	}
+
+	for i := 0; i < users; i++ {
+		wg.Add(1)
+		go executeUser(i)
+	}
+
+	// Esperar a que terminen todos los usuarios
+	wg.Wait()
+
+	// Un log incompleto se informa con las estadísticas: el test en sí terminó bien
+	var logErr error
+	if logger != nil {
+		logErr = logger.Close()
+	}
+
+	if keep := cfg.MaxRetainedResults; keep > 0 && len(results) > keep {
+		results = append([]BenchmarkResult(nil), results[len(results)-keep:]...)
+	}
+
+	// Las estadísticas salen del acumulado, que incluye los resultados descartados;
+	// el throughput se calcula sobre el tiempo real transcurrido
+	stats := aggregate.counters(cfg, time.Since(measureStart))
+	stats.AbortReason = abortReason
+	stats.FirstFailure = firstFailure
+	stats.ScenarioSteps = scenarioSteps
+	stats.Aborted = abortReason != ""
+	stats.ConnLimitHit = connLimitHit
+	if logErr != nil {
+		stats.LogError = logErr.Error()
+	}
+	stats.SteadyRequestsPerSecond = stats.RequestsPerSecond
+	if !steadyStart.IsZero() {
+		// Lo iniciado durante el calentamiento no se registra: al terminar el calentamiento el contador vale 0
+		from, fromCount := steadyStart, steadyStartCount
+		if from.Before(warmupEnd) {
+			from, fromCount = warmupEnd, 0
+		}
+		if window := steadyEnd.Sub(from); window > 0 && steadyEndCount > fromCount {
+			stats.SteadyRequestsPerSecond = float64(steadyEndCount-fromCount) / window.Seconds()
+		}
+	}
+	aggregate.apply(&stats, cfg)
+
+	return results, stats
+}
+
+// runTimeoutReason es el motivo de aborto de una ejecución cortada por MaxRunDurationSeconds
+func runTimeoutReason(cfg RequestConfig) string {
+	return fmt.Sprintf("timeout global de la ejecución (%d s)", cfg.MaxRunDurationSeconds)
+}
+
+// percentile retorna el percentil p (0-1) de una lista de duraciones ya ordenada
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(p * float64(len(sorted)))
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+// computePercentiles calcula cada percentil pedido (en %) sobre una lista de duraciones ya ordenada
+func computePercentiles(sorted []float64, percentiles []float64) map[float64]float64 {
+	return percentilesFrom(percentiles, func(p float64) float64 { return percentile(sorted, p) })
+}
+
+// percentilesFrom calcula cada percentil pedido (en %) con at, que recibe el percentil en 0-1
+func percentilesFrom(percentiles []float64, at func(p float64) float64) map[float64]float64 {
+	if len(percentiles) == 0 {
+		percentiles = DefaultPercentiles
+	}
+	values := make(map[float64]float64, len(percentiles))
+	for _, p := range percentiles {
+		values[p] = at(p / 100)
+	}
+	return values
+}
+
+// parsePercentiles interpreta una lista de percentiles separados por comas (ej. "50, 90, 99.9")
+func parsePercentiles(text string) ([]float64, error) {
+	var out []float64
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		p, err := strconv.ParseFloat(strings.TrimPrefix(strings.ToUpper(part), "P"), 64)
+		if err != nil || p <= 0 || p > 100 {
+			return nil, fmt.Errorf("percentil inválido %q (debe estar entre 0 y 100)", part)
+		}
+		out = append(out, p)
+	}
+	sort.Float64s(out)
+	return out, nil
+}
+
+// sortedPercentiles retorna los percentiles calculados en orden ascendente
+func sortedPercentiles(values map[float64]float64) []float64 {
+	percentiles := make([]float64, 0, len(values))
+	for p := range values {
+		percentiles = append(percentiles, p)
+	}
+	sort.Float64s(percentiles)
+	return percentiles
+}
+
+// formatPercentileLabel genera la etiqueta de un percentil (90 -> "P90", 99.9 -> "P99.9")
+func formatPercentileLabel(p float64) string {
+	return "P" + strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// summarizeResults calcula las estadísticas completas de un conjunto de resultados
+// (un resultado es exitoso si su status está dentro del rango configurado en cfg)
+func summarizeResults(results []BenchmarkResult, elapsed time.Duration, cfg RequestConfig) BenchmarkStats {
+	stats := BenchmarkStats{Total: len(results)}
+	if stats.Total == 0 {
+		return stats
+	}
+
+	durations := make([]float64, len(results))
+	stats.Min = results[0].Duration
+	for i, r := range results {
+		durations[i] = r.Duration
+		stats.TotalDuration += r.Duration
+		if r.Duration < stats.Min {
+			stats.Min = r.Duration
+		}
+		if r.Duration > stats.Max {
+			stats.Max = r.Duration
+		}
+		if succeeded(r, cfg) {
+			stats.Success++
+		} else if isRateLimited(r, cfg) {
+			stats.RateLimited++
+		}
+		if r.RetryAfterMs > 0 {
+			stats.Throttled++
+		}
+	}
+	sort.Float64s(durations)
+
+	stats.Avg = stats.TotalDuration / float64(stats.Total)
+	stats.ErrorRate = ((stats.Total - stats.Success - stats.RateLimited) * 100) / stats.Total
+	if elapsed > 0 {
+		stats.RequestsPerSecond = float64(stats.Total) / elapsed.Seconds()
+	}
+	stats.PercentileValues = computePercentiles(durations, cfg.Percentiles)
+	checkP95SLA(&stats, percentile(durations, 0.95), cfg)
+	return stats
+}
+
+const RecentStatsWindow = 50 // Requests consideradas "actuales" en las estadísticas parciales
+
+// applyRecentStats calcula avg/mín/máx de las últimas RecentStatsWindow requests, para comparar
+// la latencia actual con la de toda la ejecución mientras el test sigue corriendo
+func applyRecentStats(stats *BenchmarkStats, results []BenchmarkResult) {
+	if len(results) > RecentStatsWindow {
+		results = results[len(results)-RecentStatsWindow:]
+	}
+	if len(results) == 0 {
+		return
+	}
+	stats.RecentMin = results[0].Duration
+	stats.RecentMax = results[0].Duration
+	total := 0.0
+	for _, r := range results {
+		total += r.Duration
+		stats.RecentMin = min(stats.RecentMin, r.Duration)
+		stats.RecentMax = max(stats.RecentMax, r.Duration)
+	}
+	stats.RecentAvg = total / float64(len(results))
+}
+
+// checkP95SLA compara el P95 medido con el SLA configurado; se calcula
+// aparte por si el 95 no está entre los percentiles elegidos
+func checkP95SLA(stats *BenchmarkStats, p95 float64, cfg RequestConfig) {
+	if cfg.SLAP95Ms <= 0 || stats.Total == 0 {
+		return
+	}
+	stats.SLAP95Ms = cfg.SLAP95Ms
+	stats.P95Ms = p95
+	stats.SLABreached = stats.P95Ms > cfg.SLAP95Ms
+}
+
+// --- BARRIDO DE CONCURRENCIA ---
+
+// DefaultSweepLevels son los niveles de usuarios concurrentes del barrido por defecto
+var DefaultSweepLevels = []int{1, 5, 10, 25, 50}
+
+// parseSweepLevels interpreta niveles de usuarios separados por comas (ej. "1, 5, 10"); vacío = DefaultSweepLevels.
+// Los niveles se ordenan sin repetidos y deben estar entre 1 y MaxConcurrentUsers.
+func parseSweepLevels(text string) ([]int, error) {
+	var out []int
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		users, err := strconv.Atoi(part)
+		if err != nil || users < 1 || users > MaxConcurrentUsers {
+			return nil, fmt.Errorf("nivel de usuarios inválido %q (debe estar entre 1 y %d)", part, MaxConcurrentUsers)
+		}
+		out = append(out, users)
+	}
+	if len(out) == 0 {
+		return append([]int(nil), DefaultSweepLevels...), nil
+	}
+	sort.Ints(out)
+	return slices.Compact(out), nil
+}
+
+// withP95 agrega el P95 a los percentiles configurados si no está (vacío = DefaultPercentiles, que ya lo incluye)
+func withP95(percentiles []float64) []float64 {
+	if len(percentiles) == 0 {
+		return percentiles
+	}
+	for _, p := range percentiles {
+		if p == 95 {
+			return percentiles
+		}
+	}
+	return append(append([]float64(nil), percentiles...), 95)
+}
+
+// SweepPoint es el resultado de un nivel del barrido de concurrencia
+type SweepPoint struct {
+	Users int
+	P95   float64 // Latencia P95 en ms con Users usuarios concurrentes
+	Stats BenchmarkStats
+}
+
+// runConcurrencySweep ejecuta el mismo test una vez por cada nivel de usuarios concurrentes, en orden,
+// y retorna la curva de saturación (P95 por nivel) junto con los resultados del último nivel completo.
+// progress recibe el avance de todo el barrido (0-1). Un nivel cancelado o abortado no entra en la
+// curva (sus estadísticas son parciales) y corta el barrido.
+func runConcurrencySweep(cfg RequestConfig, levels []int, progress func(float64), cancelChan <-chan bool) ([]SweepPoint, []BenchmarkResult) {
+	cfg.Percentiles = withP95(cfg.Percentiles) // El P95 se necesita aunque no esté configurado
+
+	var points []SweepPoint
+	var results []BenchmarkResult
+	for i, users := range levels {
+		select {
+		case <-cancelChan:
+			return points, results
+		default:
+		}
+		levelCfg := cfg
+		levelCfg.ConcurrentUsers = users
+		var levelProgress func(float64)
+		if progress != nil {
+			levelProgress = func(p float64) { progress((float64(i) + p) / float64(len(levels))) }
+		}
+		levelResults, stats := testRunner(cfg.URL)(levelCfg, levelProgress, cancelChan, nil)
+		if stats.Total == 0 || stats.Aborted {
+			break // El test no pudo iniciarse (ej. carpeta de bodies vacía) o se cortó: los demás niveles tampoco
+		}
+		select {
+		case <-cancelChan:
+			return points, results
+		default:
+		}
+		points = append(points, SweepPoint{Users: users, P95: stats.PercentileValues[95], Stats: stats})
+		results = levelResults
+	}
+	return points, results
+}
+
+// formatSweepTable resume el barrido en una tabla de texto, un nivel por línea
+func formatSweepTable(points []SweepPoint, decimals int) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%-9s %-12s %-12s %-9s %s\n", "Usuarios", "P95", "Avg", "Req/s", "Errores"))
+	for _, p := range points {
+		errorPct := 0.0
+		if p.Stats.Total > 0 {
+			errorPct = float64(p.Stats.Total-p.Stats.Success) / float64(p.Stats.Total) * 100
+		}
+		b.WriteString(fmt.Sprintf("%-9d %-12s %-12s %-9.1f %.1f%%\n",
+			p.Users, formatDuration(p.P95, decimals), formatDuration(p.Stats.Avg, decimals), p.Stats.RequestsPerSecond, errorPct))
+	}
+	return b.String()
+}
+
+// --- BARRIDO DE THROUGHPUT ---
+
+const MaxSustainableErrorRate = 1.0 // Error rate (%) por encima del cual un escalón de RPS no es sostenible
+const MinAchievedRPSRatio = 0.9     // Fracción del objetivo que el throughput logrado debe alcanzar
+const MaxRPSSteps = 50              // Tope de escalones del barrido de RPS
+
+// RPSStep es el resultado de un escalón del barrido de throughput
+type RPSStep struct {
+	TargetRPS int
+	P95       float64 // Latencia P95 en ms
+	Stats     BenchmarkStats
+	Failure   string // Motivo por el que el escalón no es sostenible ("" = sostenible)
+}
+
+// rpsStepFailure indica por qué un escalón no es sostenible: error rate, P95 sobre el SLA (slaP95Ms,
+// 0 = sin SLA) o un throughput logrado muy por debajo del objetivo. "" si el escalón es sostenible.
+// Los 429 cuentan como fallidos aunque SeparateRateLimited los saque del error rate: un servidor
+// que limita ese ritmo no lo sostiene.
+func rpsStepFailure(step RPSStep, slaP95Ms float64) string {
+	stats := step.Stats
+	if stats.Aborted {
+		return "abortado: " + stats.AbortReason // Estadísticas parciales (circuit breaker, tope de duración)
+	}
+	if stats.Total == 0 {
+		return "no se completó ninguna request"
+	}
+	errorRate := float64(stats.Total-stats.Success) / float64(stats.Total) * 100
+	switch {
+	case errorRate > MaxSustainableErrorRate && stats.RateLimited > 0:
+		return fmt.Sprintf("error rate %.1f%% con %d respuestas 429 (máx. %.1f%%)", errorRate, stats.RateLimited, MaxSustainableErrorRate)
+	case errorRate > MaxSustainableErrorRate:
+		return fmt.Sprintf("error rate %.1f%% (máx. %.1f%%)", errorRate, MaxSustainableErrorRate)
+	case slaP95Ms > 0 && step.P95 > slaP95Ms:
+		return fmt.Sprintf("P95 %s supera el SLA de %s", formatDuration(step.P95, DefaultLatencyDecimals), formatDuration(slaP95Ms, DefaultLatencyDecimals))
+	case stats.RequestsPerSecond < float64(step.TargetRPS)*MinAchievedRPSRatio:
+		return fmt.Sprintf("solo se lograron %.1f req/s (¿faltan usuarios concurrentes?)", stats.RequestsPerSecond)
+	}
+	return ""
+}
+
+// findMaxRPS sube TargetRPS de a stepRPS desde startRPS, con un test completo por escalón, hasta que uno
+// deja de ser sostenible (ver rpsStepFailure), se cancela, se alcanzan MaxRPSSteps escalones o el
+// siguiente objetivo supera MaxTargetRPS.
+// Retorna los escalones completos, el índice del último sostenible (el codo; -1 si ninguno lo fue)
+// y los resultados del último escalón retornado: el escalón cancelado se descarta, sus estadísticas
+// son parciales. progress recibe el avance del escalón en curso.
+func findMaxRPS(cfg RequestConfig, startRPS, stepRPS int, slaP95Ms float64, progress func(float64), cancelChan <-chan bool) ([]RPSStep, int, []BenchmarkResult) {
+	cfg.Percentiles = withP95(cfg.Percentiles) // El P95 se necesita aunque no esté configurado
+
+	var steps []RPSStep
+	var results []BenchmarkResult
+	knee := -1
+	for i := 0; i < MaxRPSSteps; i++ {
+		stepCfg := cfg
+		stepCfg.TargetRPS = startRPS + i*stepRPS
+		if stepCfg.TargetRPS > MaxTargetRPS {
+			break
+		}
+		stepResults, stats := runLoadTest(stepCfg, progress, cancelChan, nil)
+		select {
+		case <-cancelChan:
+			return steps, knee, results
+		default:
+		}
+		results = stepResults
+		step := RPSStep{TargetRPS: stepCfg.TargetRPS, P95: stats.PercentileValues[95], Stats: stats}
+		step.Failure = rpsStepFailure(step, slaP95Ms)
+		steps = append(steps, step)
+		if step.Failure != "" {
+			break
+		}
+		knee = i
+	}
+	return steps, knee, results
+}
+
+// formatRPSTable resume el barrido de throughput en una tabla de texto, un escalón por línea
+func formatRPSTable(steps []RPSStep, knee, decimals int) string {
+	var b strings.Builder
+	if knee >= 0 {
+		b.WriteString(fmt.Sprintf("Máximo sostenible: %d req/s (logrado %.1f req/s, P95 %s)\n\n",
+			steps[knee].TargetRPS, steps[knee].Stats.RequestsPerSecond, formatDuration(steps[knee].P95, decimals)))
+	} else {
+		b.WriteString("Ningún escalón fue sostenible: prueba con un RPS inicial menor\n\n")
+	}
+	b.WriteString(fmt.Sprintf("%-10s %-10s %-12s %s\n", "Objetivo", "Logrado", "P95", "Resultado"))
+	for _, step := range steps {
+		verdict := "ok"
+		if step.Failure != "" {
+			verdict = step.Failure
+		}
+		b.WriteString(fmt.Sprintf("%-10d %-10.1f %-12s %s\n", step.TargetRPS, step.Stats.RequestsPerSecond, formatDuration(step.P95, decimals), verdict))
+	}
+	return b.String()
+}
+
+// --- SESIONES NO HTTP (WebSocket, gRPC) ---
+
+// callSession es la conexión de un usuario en los modos que no usan http.Client
+type callSession interface {
+	// Call ejecuta una operación y retorna el status a registrar (200 = OK, 0 = error de conexión).
+	// Debe cortar la operación en curso cuando ctx vence o se cancela.
+	Call(ctx context.Context) (int, error)
+	Close()
+}
+
+// sessionOptionsError rechaza las opciones del motor HTTP que runSessionTest no implementa, para
+// que una prueba WebSocket o gRPC no las ignore en silencio (nil si no hay ninguna)
+func sessionOptionsError(cfg RequestConfig) error {
+	var unsupported []string
+	if cfg.WarmupSeconds > 0 {
+		unsupported = append(unsupported, "calentamiento")
+	}
+	if cfg.TargetRPS > 0 {
+		unsupported = append(unsupported, "límite de requests/s")
+	}
+	if cfg.StopIfErrorRateExceeds > 0 {
+		unsupported = append(unsupported, "detener por error rate")
+	}
+	if cfg.LogFile != "" {
+		unsupported = append(unsupported, "log de requests")
+	}
+	if len(unsupported) == 0 {
+		return nil
+	}
+	return fmt.Errorf("WebSocket y gRPC no admiten: %s", strings.Join(unsupported, ", "))
+}
+
+// runSessionTest ejecuta el bucle de carga para los modos basados en sesiones: cada usuario abre
+// su sesión con open, se reconecta tras un error y solo se mide la duración de Call.
+func runSessionTest(cfg RequestConfig, progress func(float64), cancelChan <-chan bool, realtimeUpdate func([]BenchmarkResult, BenchmarkStats), open func() (callSession, error)) ([]BenchmarkResult, BenchmarkStats) {
+	if err := sessionOptionsError(cfg); err != nil {
+		return nil, BenchmarkStats{Aborted: true, AbortReason: err.Error()}
+	}
+	results := make([]BenchmarkResult, 0)
+	var resultsMutex sync.Mutex
+	aggregate := newResultAggregate(cfg) // Estadísticas sin recorrer todos los resultados (que pueden descartarse)
+	var pending []BenchmarkResult        // Resultados aún no enviados a realtimeUpdate
+	issued := 0                          // Operaciones iniciadas (modo por cantidad total)
+	recorded := 0                        // Resultados registrados; puede superar len(results) con MaxRetainedResults
+	var inFlight atomic.Int64            // Operaciones en curso
+	startTime := time.Now()
+	useDuration := cfg.Duration > 0
+	endTime := startTime.Add(time.Duration(cfg.Duration) * time.Second)
+	// Timeout global (MaxRunDurationSeconds): vence el contexto de las operaciones, así un backend
+	// colgado no retiene la ejecución hasta el timeout de cada request. Cancelar también lo cierra.
+	runCtx, cancelRun := context.WithCancel(context.Background())
+	var runDeadline time.Time
+	var runTimedOut bool
+	if cfg.MaxRunDurationSeconds > 0 {
+		runDeadline = startTime.Add(time.Duration(cfg.MaxRunDurationSeconds) * time.Second)
+		runCtx, cancelRun = context.WithDeadline(context.Background(), runDeadline)
+	}
+	defer cancelRun()
+	go func() {
+		select {
+		case <-cancelChan:
+			cancelRun()
+		case <-runCtx.Done():
+		}
+	}()
+
+	targetTotal := cfg.Count
+	if cfg.CountMode == CountModePerUser && cfg.ConcurrentUsers > 1 {
+		targetTotal = cfg.Count * cfg.ConcurrentUsers
+	}
+
+	var wg sync.WaitGroup
+	executeUser := func() {
+		defer wg.Done()
+
+		var session callSession
+		defer func() {
+			if session != nil {
+				session.Close()
+			}
+		}()
+
+		sent := 0
+		for {
+			select {
+			case <-cancelChan:
+				return
+			default:
+			}
+
+			if !runDeadline.IsZero() && time.Now().After(runDeadline) {
+				resultsMutex.Lock()
+				runTimedOut = true
+				resultsMutex.Unlock()
+				return
+			}
+			if useDuration {
+				if time.Now().After(endTime) {
+					return
+				}
+			} else if cfg.CountMode == CountModePerUser {
+				if sent >= cfg.Count {
+					return
+				}
+			} else {
+				// Reservar el turno antes de ejecutar para no superar Count entre usuarios
+				resultsMutex.Lock()
+				done := issued >= cfg.Count
+				if !done {
+					issued++
+				}
+				resultsMutex.Unlock()
+				if done {
+					return
+				}
+			}
+			sent++
+
+			start := time.Now()
+			status := 0
+			var err error
+			if session == nil {
+				session, err = open()
+			}
+			if err == nil {
+				// Medir solo la operación, sin el establecimiento de la conexión
+				start = time.Now()
+				inFlight.Add(1)
+				status, err = session.Call(runCtx)
+				inFlight.Add(-1)
+				// Una operación cortada por el timeout global o la cancelación no se registra
+				if err != nil && runCtx.Err() != nil {
+					if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+						resultsMutex.Lock()
+						runTimedOut = true
+						resultsMutex.Unlock()
+					}
+					return
+				}
+				if err != nil && status == 0 {
+					// Error de conexión: reconectar en la siguiente iteración
+					session.Close()
+					session = nil
+				}
+			}
+			duration := durationMs(time.Since(start))
+
+			resultsMutex.Lock()
+			recorded++
+			result := BenchmarkResult{
+				Seq:       recorded,
+				Timestamp: start.Format("15:04:05"),
+				StartedAt: start,
+				Duration:  duration,
+				Status:    status,
+			}
+			results = append(results, result)
+			aggregate.add(result, cfg)
+			if keep := cfg.MaxRetainedResults; keep > 0 && len(results) >= 2*keep {
+				// Igual que runLoadTest: descartar los más antiguos de a bloques
+				results = results[:copy(results, results[len(results)-keep:])]
+			}
+			currentTotal := recorded
+			if realtimeUpdate != nil {
+				// Solo los resultados nuevos, enviados con el lock tomado para respetar el orden
+				pending = append(pending, result)
+				if currentTotal%5 == 0 {
+					partialStats := aggregate.counters(cfg, time.Since(startTime))
+					applyRecentStats(&partialStats, results)
+					partialStats.InFlight = int(inFlight.Load())
+					realtimeUpdate(pending, partialStats)
+					pending = nil
+				}
+			}
+			resultsMutex.Unlock()
+
+			if progress != nil {
+				if useDuration {
+					progress(time.Since(startTime).Seconds() / float64(cfg.Duration))
+				} else {
+					progress(float64(currentTotal) / float64(targetTotal))
+				}
+			}
+		}
+	}
+
+	users := cfg.ConcurrentUsers
+	if users < 1 {
+		users = 1
+	}
+	for i := 0; i < users; i++ {
+		wg.Add(1)
+		go executeUser()
+	}
+	wg.Wait()
+
+	if keep := cfg.MaxRetainedResults; keep > 0 && len(results) > keep {
+		results = append([]BenchmarkResult(nil), results[len(results)-keep:]...)
+	}
+
+	// Las estadísticas salen del acumulado, que incluye los resultados descartados
+	stats := aggregate.counters(cfg, time.Since(startTime))
+	aggregate.apply(&stats, cfg)
+	if runTimedOut {
+		stats.Aborted = true
+		stats.AbortReason = runTimeoutReason(cfg)
+	}
+	return results, stats
+}
+
+// --- NTLM ---
+
+// ntlmNegotiateFlags son los flags pedidos en el mensaje NEGOTIATE: Unicode, NTLM, target info,
+// extended session security y claves de 128 bits
+const ntlmNegotiateFlags = 0x00000001 | 0x00000004 | 0x00000200 | 0x00008000 | 0x00080000 | 0x00800000 | 0x20000000 | 0x80000000
+
+var ntlmSignature = []byte("NTLMSSP\x00")
+
+// ntlmTransport autentica cada conexión con el handshake NTLM (negotiate, challenge, authenticate).
+// NTLM autentica la conexión y no la request, así que se limita a una conexión persistente por
+// host y el handshake solo se repite si el servidor vuelve a responder 401.
+type ntlmTransport struct {
+	base                   *http.Transport
+	domain, user, password string
+	authenticated          atomic.Bool
+}
+
+func newNTLMTransport(cfg RequestConfig) *ntlmTransport {
+	// NTLM autentica la conexión, no la request: sobre HTTP/2 IIS responde HTTP_1_1_REQUIRED
+	cfg.ForceHTTP1 = true
+	base := newTransport(cfg)
+	base.DisableKeepAlives = false
+	base.MaxConnsPerHost = 1 // El challenge y el authenticate deben viajar por la misma conexión
+	base.MaxIdleConnsPerHost = 1
+	return &ntlmTransport{base: base, domain: cfg.NTLMDomain, user: cfg.NTLMUser, password: cfg.NTLMPassword}
+}
+
+// CloseIdleConnections cierra las conexiones ociosas del transport base (ver http.Client.CloseIdleConnections)
+func (t *ntlmTransport) CloseIdleConnections() {
+	t.base.CloseIdleConnections()
+}
+
+func (t *ntlmTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.authenticated.Load() {
+		resp, err := t.base.RoundTrip(ntlmClone(req, ""))
+		if err != nil || !ntlmRequested(resp) {
+			return resp, err
+		}
+		// La conexión autenticada se perdió: repetir el handshake
+		drainBody(resp)
+		t.authenticated.Store(false)
+	}
+
+	resp, err := t.base.RoundTrip(ntlmClone(req, "NTLM "+base64.StdEncoding.EncodeToString(ntlmNegotiateMessage())))
+	if err != nil {
+		return nil, err
+	}
+	challenge, ok := ntlmChallenge(resp)
+	if !ok {
+		return resp, nil // El servidor no pidió NTLM (o rechazó el negotiate): se registra tal cual
+	}
+	drainBody(resp)
+
+	msg, err := ntlmAuthenticateMessage(challenge, t.domain, t.user, t.password)
+	if err != nil {
+		return nil, err
+	}
+	resp, err = t.base.RoundTrip(ntlmClone(req, "NTLM "+base64.StdEncoding.EncodeToString(msg)))
+	if err == nil && resp.StatusCode != http.StatusUnauthorized {
+		t.authenticated.Store(true)
+	}
+	return resp, err
+}
+
+// ntlmClone copia la request con un body nuevo (vía GetBody) y el header Authorization indicado
+func ntlmClone(req *http.Request, authorization string) *http.Request {
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			clone.Body = body
+		}
+	}
+	if authorization != "" {
+		clone.Header.Set("Authorization", authorization)
+	}
+	return clone
+}
+
+// drainBody consume y cierra el body para que la conexión vuelva al pool y se reutilice
+func drainBody(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+}
+
+// ntlmRequested indica si la respuesta es un 401 que pide autenticación NTLM
+func ntlmRequested(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	for _, v := range resp.Header.Values("WWW-Authenticate") {
+		if strings.EqualFold(strings.TrimSpace(v), "NTLM") || strings.HasPrefix(strings.ToUpper(v), "NTLM ") {
+			return true
+		}
+	}
+	return false
+}
+
+// ntlmChallenge extrae el mensaje CHALLENGE (tipo 2) de un 401
+func ntlmChallenge(resp *http.Response) ([]byte, bool) {
+	if resp.StatusCode != http.StatusUnauthorized {
+		return nil, false
+	}
+	for _, v := range resp.Header.Values("WWW-Authenticate") {
+		v = strings.TrimSpace(v)
+		if len(v) > 5 && strings.EqualFold(v[:5], "NTLM ") {
+			data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(v[5:]))
+			if err == nil && len(data) >= 32 && bytes.Equal(data[:8], ntlmSignature) && binary.LittleEndian.Uint32(data[8:]) == 2 {
+				return data, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ntlmNegotiateMessage arma el mensaje NEGOTIATE (tipo 1), sin dominio ni workstation
+func ntlmNegotiateMessage() []byte {
+	msg := make([]byte, 32)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 1)
+	binary.LittleEndian.PutUint32(msg[12:], ntlmNegotiateFlags)
+	return msg
+}
+
+// ntlmAuthenticateMessage arma el mensaje AUTHENTICATE (tipo 3) con la respuesta NTLMv2 al challenge
+func ntlmAuthenticateMessage(challenge []byte, domain, user, password string) ([]byte, error) {
+	if len(challenge) < 48 {
+		return nil, errors.New("NTLM: mensaje challenge demasiado corto")
+	}
+	flags := binary.LittleEndian.Uint32(challenge[20:])
+	serverChallenge := challenge[24:32]
+	targetInfo, err := ntlmSecurityBuffer(challenge, 40)
+	if err != nil {
+		return nil, err
+	}
+
+	clientChallenge := make([]byte, 8)
+	crand.Read(clientChallenge)
+	timestamp := ntlmTimestamp(targetInfo)
+
+	key := ntowfV2(domain, user, password)
+	ntResponse := ntlmV2Response(key, serverChallenge, clientChallenge, timestamp, targetInfo)
+	lmResponse := append(hmacMD5(key, serverChallenge, clientChallenge), clientChallenge...)
+
+	payload := [][]byte{lmResponse, ntResponse, utf16LE(domain), utf16LE(user), utf16LE(""), nil}
+	const headerLen = 64
+	msg := make([]byte, headerLen)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 3)
+	offset := headerLen
+	for i, field := range payload {
+		pos := 12 + i*8
+		binary.LittleEndian.PutUint16(msg[pos:], uint16(len(field)))
+		binary.LittleEndian.PutUint16(msg[pos+2:], uint16(len(field)))
+		binary.LittleEndian.PutUint32(msg[pos+4:], uint32(offset))
+		offset += len(field)
+	}
+	binary.LittleEndian.PutUint32(msg[60:], flags&ntlmNegotiateFlags|0x00000001)
+	for _, field := range payload {
+		msg = append(msg, field...)
+	}
+	return msg, nil
+}
+
+// ntlmSecurityBuffer lee el campo (largo, largo máximo, offset) ubicado en pos
+func ntlmSecurityBuffer(msg []byte, pos int) ([]byte, error) {
+	length := int(binary.LittleEndian.Uint16(msg[pos:]))
+	offset := int(binary.LittleEndian.Uint32(msg[pos+4:]))
+	if offset+length > len(msg) {
+		return nil, errors.New("NTLM: mensaje challenge inválido")
+	}
+	return msg[offset : offset+length], nil
+}
+
+// ntlmTimestamp usa el MsvAvTimestamp del servidor si lo envió; si no, la hora local en formato FILETIME
+func ntlmTimestamp(targetInfo []byte) []byte {
+	for i := 0; i+4 <= len(targetInfo); {
+		id := binary.LittleEndian.Uint16(targetInfo[i:])
+		length := int(binary.LittleEndian.Uint16(targetInfo[i+2:]))
+		if id == 0 || i+4+length > len(targetInfo) {
+			break
+		}
+		if id == 7 && length == 8 {
+			return targetInfo[i+4 : i+12]
+		}
+		i += 4 + length
+	}
+	ts := make([]byte, 8)
+	// FILETIME: intervalos de 100ns desde el 1/1/1601
+	binary.LittleEndian.PutUint64(ts, uint64(time.Now().UnixNano()/100+116444736000000000))
+	return ts
+}
+
+// ntowfV2 deriva la clave NTLMv2: HMAC-MD5(MD4(password), MAYÚSCULAS(usuario) + dominio)
+func ntowfV2(domain, user, password string) []byte {
+	ntHash := md4Sum(utf16LE(password))
+	return hmacMD5(ntHash[:], utf16LE(strings.ToUpper(user)+domain))
+}
+
+// ntlmV2Response calcula la respuesta NTLMv2: NTProofStr (16 bytes) seguido del blob del cliente
+func ntlmV2Response(key, serverChallenge, clientChallenge, timestamp, targetInfo []byte) []byte {
+	blob := []byte{0x01, 0x01, 0, 0, 0, 0, 0, 0}
+	blob = append(blob, timestamp...)
+	blob = append(blob, clientChallenge...)
+	blob = append(blob, 0, 0, 0, 0)
+	blob = append(blob, targetInfo...)
+	blob = append(blob, 0, 0, 0, 0)
+	return append(hmacMD5(key, serverChallenge, blob), blob...)
+}
+
+func hmacMD5(key []byte, data ...[]byte) []byte {
+	h := hmac.New(md5.New, key)
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// utf16LE codifica el texto en UTF-16 little endian, como exige NTLM
+func utf16LE(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	out := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(out[2*i:], u)
+	}
+	return out
+}
+
+// md4Sum implementa MD4 (RFC 1320), necesario para el hash NT y ausente en la librería estándar
+func md4Sum(data []byte) [16]byte {
+	msg := append([]byte{}, data...)
+	msg = append(msg, 0x80)
+	for len(msg)%64 != 56 {
+		msg = append(msg, 0)
+	}
+	msg = binary.LittleEndian.AppendUint64(msg, uint64(len(data))*8)
+
+	a, b, c, d := uint32(0x67452301), uint32(0xefcdab89), uint32(0x98badcfe), uint32(0x10325476)
+	rotl := func(x uint32, s uint) uint32 { return x<<s | x>>(32-s) }
+	var x [16]uint32
+	for chunk := 0; chunk < len(msg); chunk += 64 {
+		for i := range x {
+			x[i] = binary.LittleEndian.Uint32(msg[chunk+4*i:])
+		}
+		aa, bb, cc, dd := a, b, c, d
+
+		f := func(x, y, z uint32) uint32 { return x&y | ^x&z }
+		for _, i := range []int{0, 4, 8, 12} {
+			a = rotl(a+f(b, c, d)+x[i], 3)
+			d = rotl(d+f(a, b, c)+x[i+1], 7)
+			c = rotl(c+f(d, a, b)+x[i+2], 11)
+			b = rotl(b+f(c, d, a)+x[i+3], 19)
+		}
+		g := func(x, y, z uint32) uint32 { return x&y | x&z | y&z }
+		for _, i := range []int{0, 1, 2, 3} {
+			a = rotl(a+g(b, c, d)+x[i]+0x5a827999, 3)
+			d = rotl(d+g(a, b, c)+x[i+4]+0x5a827999, 5)
+			c = rotl(c+g(d, a, b)+x[i+8]+0x5a827999, 9)
+			b = rotl(b+g(c, d, a)+x[i+12]+0x5a827999, 13)
+		}
+		h := func(x, y, z uint32) uint32 { return x ^ y ^ z }
+		for _, i := range []int{0, 2, 1, 3} {
+			a = rotl(a+h(b, c, d)+x[i]+0x6ed9eba1, 3)
+			d = rotl(d+h(a, b, c)+x[i+8]+0x6ed9eba1, 9)
+			c = rotl(c+h(d, a, b)+x[i+4]+0x6ed9eba1, 11)
+			b = rotl(b+h(c, d, a)+x[i+12]+0x6ed9eba1, 15)
+		}
+
+		a, b, c, d = a+aa, b+bb, c+cc, d+dd
+	}
+
+	var sum [16]byte
+	binary.LittleEndian.PutUint32(sum[0:], a)
+	binary.LittleEndian.PutUint32(sum[4:], b)
+	binary.LittleEndian.PutUint32(sum[8:], c)
+	binary.LittleEndian.PutUint32(sum[12:], d)
+	return sum
+}
+
+// --- WEBSOCKET ---
+
+// isWebSocketURL indica si la URL debe probarse con runWebSocketTest
+func isWebSocketURL(rawURL string) bool {
+	u := strings.ToLower(strings.TrimSpace(rawURL))
+	return strings.HasPrefix(u, "ws://") || strings.HasPrefix(u, "wss://")
+}
+
+// dialWebSocket abre la conexión aplicando los headers y el timeout configurados
+func dialWebSocket(cfg RequestConfig) (*websocket.Conn, error) {
+	origin := "http://" + strings.TrimPrefix(strings.TrimPrefix(cfg.URL, "ws://"), "wss://")
+	if strings.HasPrefix(strings.ToLower(cfg.URL), "wss://") {
+		origin = "https://" + strings.TrimPrefix(cfg.URL, "wss://")
+	}
+	wsCfg, err := websocket.NewConfig(cfg.URL, origin)
+	if err != nil {
+		return nil, err
+	}
+	wsCfg.Header.Set("User-Agent", userAgent(cfg))
+	applyHeaders(wsCfg.Header, cfg.Headers)
+	wsCfg.Dialer = &net.Dialer{Timeout: requestTimeout(cfg)}
+	return websocket.DialConfig(wsCfg)
+}
+
+// wsSession envía el mensaje configurado y espera la respuesta (eco) del servidor
+type wsSession struct {
+	conn    *websocket.Conn
+	message string
+	timeout time.Duration
+}
+
+func (s *wsSession) Call(ctx context.Context) (int, error) {
+	deadline := time.Now().Add(s.timeout)
+	if runEnd, ok := ctx.Deadline(); ok && runEnd.Before(deadline) {
+		deadline = runEnd
+	}
+	s.conn.SetDeadline(deadline)
+	// La conexión no recibe un contexto: al cancelarlo se vence el deadline para cortar la espera
+	stop := context.AfterFunc(ctx, func() { s.conn.SetDeadline(time.Now()) })
+	defer stop()
+	if err := websocket.Message.Send(s.conn, s.message); err != nil {
+		return 0, err
+	}
+	var reply string
+	if err := websocket.Message.Receive(s.conn, &reply); err != nil {
+		return 0, err
+	}
+	return 200, nil
+}
+
+func (s *wsSession) Close() {
+	s.conn.Close()
+}
+
+// runWebSocketTest envía cfg.Body como mensaje por una conexión WebSocket (una por usuario)
+// y mide el round-trip hasta recibir la respuesta. Cada ida y vuelta exitosa se registra con
+// Status 200 y los errores con Status 0, para reutilizar el gráfico y las estadísticas.
+func runWebSocketTest(cfg RequestConfig, progress func(float64), cancelChan <-chan bool, realtimeUpdate func([]BenchmarkResult, BenchmarkStats)) ([]BenchmarkResult, BenchmarkStats) {
+	return runSessionTest(cfg, progress, cancelChan, realtimeUpdate, func() (callSession, error) {
+		conn, err := dialWebSocket(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return &wsSession{conn: conn, message: cfg.Body, timeout: requestTimeout(cfg)}, nil
+	})
+}
+
+// --- gRPC ---
+
+// testRunner elige el motor según el esquema de la URL: ws:// y wss:// usan el modo WebSocket,
+// grpc:// y grpcs:// el modo gRPC y el resto HTTP. Todos los motores llaman a realtimeUpdate
+// solo con los resultados nuevos desde la llamada anterior, en orden.
+func testRunner(rawURL string) func(RequestConfig, func(float64), <-chan bool, func([]BenchmarkResult, BenchmarkStats)) ([]BenchmarkResult, BenchmarkStats) {
+	if isWebSocketURL(rawURL) {
+		return runWebSocketTest
+	} else if isGRPCURL(rawURL) {
+		return runGRPCTest
+	}
+	return runLoadTest
+}
+
+// isGRPCURL indica si la URL es un target gRPC (grpc:// sin TLS, grpcs:// con TLS)
+func isGRPCURL(rawURL string) bool {
+	u := strings.ToLower(strings.TrimSpace(rawURL))
+	return strings.HasPrefix(u, "grpc://") || strings.HasPrefix(u, "grpcs://")
+}
+
+// dialGRPC abre la conexión con el target indicado en la URL
+func dialGRPC(rawURL, userAgent string) (*grpc.ClientConn, error) {
+	target := strings.TrimSpace(rawURL)
+	creds := insecure.NewCredentials()
+	if strings.HasPrefix(strings.ToLower(target), "grpcs://") {
+		creds = credentials.NewTLS(&tls.Config{})
+		target = target[len("grpcs://"):]
+	} else {
+		target = target[len("grpc://"):]
+	}
+	return grpc.NewClient(strings.TrimSuffix(target, "/"), grpc.WithTransportCredentials(creds), grpc.WithUserAgent(userAgent))
+}
+
+// splitGRPCMethod separa "paquete.Servicio/Metodo" (o "paquete.Servicio.Metodo")
+func splitGRPCMethod(fullMethod string) (string, string, error) {
+	fullMethod = strings.TrimPrefix(strings.TrimSpace(fullMethod), "/")
+	sep := strings.LastIndex(fullMethod, "/")
+	if sep == -1 {
+		sep = strings.LastIndex(fullMethod, ".")
+	}
+	if sep <= 0 || sep == len(fullMethod)-1 {
+		return "", "", fmt.Errorf("método gRPC inválido %q (formato: paquete.Servicio/Metodo)", fullMethod)
+	}
+	return fullMethod[:sep], fullMethod[sep+1:], nil
+}
+
+// fetchFileDescriptors envía una consulta de reflection y agrega los descriptores recibidos
+func fetchFileDescriptors(stream rpb.ServerReflection_ServerReflectionInfoClient, req *rpb.ServerReflectionRequest, out map[string]*descriptorpb.FileDescriptorProto) error {
+	if err := stream.Send(req); err != nil {
+		return err
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if errResp := resp.GetErrorResponse(); errResp != nil {
+		return fmt.Errorf("reflection: %s", errResp.GetErrorMessage())
+	}
+	fdResp := resp.GetFileDescriptorResponse()
+	if fdResp == nil {
+		return fmt.Errorf("reflection: respuesta inesperada")
+	}
+	for _, raw := range fdResp.GetFileDescriptorProto() {
+		fd := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(raw, fd); err != nil {
+			return err
+		}
+		out[fd.GetName()] = fd
+	}
+	return nil
+}
+
+// resolveGRPCMethod descubre el método mediante server reflection, resolviendo las dependencias
+// de los .proto (los tipos conocidos de google/protobuf se toman del registro local si faltan)
+func resolveGRPCMethod(ctx context.Context, conn *grpc.ClientConn, fullMethod string) (protoreflect.MethodDescriptor, error) {
+	serviceName, methodName, err := splitGRPCMethod(fullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.CloseSend()
+
+	fdProtos := make(map[string]*descriptorpb.FileDescriptorProto)
+	err = fetchFileDescriptors(stream, &rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: serviceName},
+	}, fdProtos)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pedir las dependencias que falten hasta completar el árbol de imports
+	for {
+		missing := ""
+		for _, fd := range fdProtos {
+			for _, dep := range fd.GetDependency() {
+				if _, ok := fdProtos[dep]; !ok {
+					missing = dep
+					break
+				}
+			}
+			if missing != "" {
+				break
+			}
+		}
+		if missing == "" {
+			break
+		}
+		err := fetchFileDescriptors(stream, &rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: missing},
+		}, fdProtos)
+		if err != nil {
+			local, findErr := protoregistry.GlobalFiles.FindFileByPath(missing)
+			if findErr != nil {
+				return nil, fmt.Errorf("dependencia %s: %w", missing, err)
+			}
+			fdProtos[missing] = protodesc.ToFileDescriptorProto(local)
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range fdProtos {
+		set.File = append(set.File, fd)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("servicio %s no encontrado: %w", serviceName, err)
+	}
+	service, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s no es un servicio", serviceName)
+	}
+	method := service.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		return nil, fmt.Errorf("método %s no encontrado en %s", methodName, serviceName)
+	}
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return nil, fmt.Errorf("%s es de streaming; solo se soportan llamadas unary", methodName)
+	}
+	return method, nil
+}
+
+// grpcCodeToHTTPStatus traduce el código gRPC a un status HTTP equivalente para las estadísticas
+func grpcCodeToHTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return 200
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return 400
+	case codes.Unauthenticated:
+		return 401
+	case codes.PermissionDenied:
+		return 403
+	case codes.NotFound:
+		return 404
+	case codes.AlreadyExists, codes.Aborted:
+		return 409
+	case codes.ResourceExhausted:
+		return 429
+	case codes.Canceled:
+		return 499
+	case codes.Unimplemented:
+		return 501
+	case codes.Unavailable:
+		return 503
+	case codes.DeadlineExceeded:
+		return 504
+	default:
+		return 500
+	}
+}
+
+// grpcSession ejecuta la llamada unary con el mensaje ya convertido desde JSON
+type grpcSession struct {
+	conn     *grpc.ClientConn
+	method   protoreflect.MethodDescriptor
+	path     string
+	request  *dynamicpb.Message
+	metadata metadata.MD
+	timeout  time.Duration
+}
+
+func (s *grpcSession) Call(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(metadata.NewOutgoingContext(ctx, s.metadata), s.timeout)
+	defer cancel()
+	reply := dynamicpb.NewMessage(s.method.Output())
+	err := s.conn.Invoke(ctx, s.path, s.request, reply)
+	if err == nil {
+		return 200, nil
+	}
+	st, ok := status.FromError(err)
+	if !ok || st.Code() == codes.Unavailable {
+		// Sin status gRPC o servidor no disponible: tratar como error de conexión
+		return 0, err
+	}
+	return grpcCodeToHTTPStatus(st.Code()), err
+}
+
+func (s *grpcSession) Close() {
+	s.conn.Close()
+}
+
+// runGRPCTest descubre cfg.GRPCMethod por reflection, convierte cfg.Body (JSON) al mensaje de
+// entrada y mide la latencia de cada llamada unary. Los headers se envían como metadata.
+func runGRPCTest(cfg RequestConfig, progress func(float64), cancelChan <-chan bool, realtimeUpdate func([]BenchmarkResult, BenchmarkStats)) ([]BenchmarkResult, BenchmarkStats) {
+	timeout := requestTimeout(cfg)
+
+	// Descubrir el método y preparar el mensaje una sola vez
+	conn, err := dialGRPC(cfg.URL, userAgent(cfg))
+	if err != nil {
+		return nil, BenchmarkStats{Aborted: true, AbortReason: fmt.Sprintf("conexión gRPC: %v", err)}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	method, err := resolveGRPCMethod(ctx, conn, cfg.GRPCMethod)
+	cancel()
+	conn.Close()
+	if err != nil {
+		return nil, BenchmarkStats{Aborted: true, AbortReason: err.Error()}
+	}
+
+	request := dynamicpb.NewMessage(method.Input())
+	if body := strings.TrimSpace(cfg.Body); body != "" {
+		if err := protojson.Unmarshal([]byte(body), request); err != nil {
+			return nil, BenchmarkStats{Aborted: true, AbortReason: fmt.Sprintf("JSON inválido para %s: %v", method.Input().FullName(), err)}
+		}
+	}
+
+	md := metadata.MD{}
+	for _, line := range strings.Split(cfg.Headers, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) == 2 {
+			md.Append(strings.ToLower(strings.TrimSpace(parts[0])), strings.TrimSpace(parts[1]))
+		}
+	}
+	path := fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name())
+
+	return runSessionTest(cfg, progress, cancelChan, realtimeUpdate, func() (callSession, error) {
+		conn, err := dialGRPC(cfg.URL, userAgent(cfg))
+		if err != nil {
+			return nil, err
+		}
+		return &grpcSession{conn: conn, method: method, path: path, request: request, metadata: md, timeout: timeout}, nil
+	})
+}
+
+// parseCurlCommand extrae información de un comando cURL
+func parseCurlCommand(curl string, urlEntry *widget.Entry, methodPicker *MethodPicker, headerTable *HeaderTable, bodyEntry *widget.Entry) {
+	// Unir las líneas primero para facilitar el parsing
+	curl = normalizeCurlCommand(strings.TrimSpace(curl))
+
+	var foundURL bool
+
+	// Extraer URL - mejorado para manejar URLs con comillas
+	if strings.Contains(curl, "curl") {
+		// Buscar URL entre comillas primero
+		for _, quote := range []string{"'", "\"", ""} {
+			if foundURL {
+				break
+			}
+			if quote != "" {
+				pattern := quote + "http"
+				if idx := strings.Index(curl, pattern); idx != -1 {
+					startPos := idx + len(quote)
+					endIdx := strings.Index(curl[startPos:], quote)
+					if endIdx > 0 {
+						url := curl[startPos : startPos+endIdx]
+						urlEntry.SetText(url)
+						foundURL = true
+					}
+				}
+			} else {
+				// Sin comillas - buscar por espacios
+				parts := strings.Fields(curl)
+				for i, part := range parts {
+					if strings.HasPrefix(part, "http") {
+						urlEntry.SetText(strings.Trim(part, `"'`))
+						foundURL = true
+						break
+					}
+					if part == "curl" && i+1 < len(parts) {
+						nextPart := parts[i+1]
+						if strings.HasPrefix(nextPart, "http") {
+							urlEntry.SetText(strings.Trim(nextPart, `"'`))
+							foundURL = true
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Extraer método (-X o --request) - SIEMPRE intentar extraer
+	var methodFound bool
+	if idx := strings.Index(curl, "-X "); idx != -1 {
+		fields := strings.Fields(curl[idx:])
+		if len(fields) > 1 {
+			method := strings.ToUpper(strings.Trim(fields[1], `"'`))
+			methodPicker.SetMethod(method)
+			methodFound = true
+		}
+	}
+
+	if !methodFound {
+		if idx := strings.Index(curl, "--request "); idx != -1 {
+			fields := strings.Fields(curl[idx:])
+			if len(fields) > 1 {
+				method := strings.ToUpper(strings.Trim(fields[1], `"'`))
+				methodPicker.SetMethod(method)
+				methodFound = true
+			}
+		}
+	}
+
+	// Si no se encontró método explícito pero hay --data o -d, es POST
+	if !methodFound {
+		if strings.Contains(curl, "--data") || strings.Contains(curl, "-d ") {
+			methodPicker.SetMethod("POST")
+		}
+	}
+
+	// Extraer headers (-H o --header)
+	headers := []string{}
+
+	// Buscar todos los -H y --header
+	searchPatterns := []string{"-H '", `-H "`, "--header '", `--header "`}
+
+	for _, pattern := range searchPatterns {
+		startIdx := 0
+		for {
+			idx := strings.Index(curl[startIdx:], pattern)
+			if idx == -1 {
+				break
+			}
+
+			actualIdx := startIdx + idx
+			startPos := actualIdx + len(pattern)
+
+			// Leer el header hasta su comilla de cierre (la del patrón)
+			if headerContent, _, ok := shellQuoted(curl[startPos-1:]); ok && headerContent != "" {
+				headers = append(headers, headerContent)
+			}
+
+			startIdx = actualIdx + len(pattern) + 1
+			if startIdx >= len(curl) {
+				break
+			}
+		}
+	}
+
+	if len(headers) > 0 {
+		headerTable.SetRows(parseHeaderRows(strings.Join(headers, "\n")))
+	}
+
+	// Extraer body (-d, --data, --data-raw); los --data-urlencode se codifican y se unen con &
+	body := curlDataBody(curl)
+	if encoded := curlURLEncodedData(curl); len(encoded) > 0 {
+		if body != "" {
+			encoded = append([]string{body}, encoded...)
+		}
+		body = strings.Join(encoded, "&")
+	}
+	if body != "" {
+		bodyEntry.SetText(body)
+	}
+}
+
+// normalizeCurlCommand une las líneas de un comando cURL copiado de una terminal: quita las
+// continuaciones con \ (bash) o ^ (cmd de Windows) y los escapes ^ de cmd, sin tocar las
+// barras invertidas del contenido (ej. \" dentro de un body JSON)
+func normalizeCurlCommand(curl string) string {
+	curl = strings.ReplaceAll(curl, "\r\n", "\n")
+	curl = strings.ReplaceAll(curl, "\\\n", " ")
+	if strings.Contains(curl, "^\n") || strings.Contains(curl, `^"`) {
+		// Formato cmd ("Copy as cURL (cmd)"): ^ escapa el carácter siguiente (^" ^& ^^ ...)
+		curl = strings.ReplaceAll(curl, "^\n", " ")
+		var b strings.Builder
+		for i := 0; i < len(curl); i++ {
+			if curl[i] == '^' && i+1 < len(curl) {
+				i++
+			}
+			b.WriteByte(curl[i])
+		}
+		curl = b.String()
+	}
+	return strings.ReplaceAll(curl, "\n", " ")
+}
+
+// shellQuoted lee el valor que abre la comilla s[0] hasta su cierre, como lo interpreta el shell:
+// entre comillas simples todo es literal y entre dobles \" \\ \$ y \` son escapes. Retorna el valor
+// y lo que sigue a la comilla de cierre (ok = false si no se cierra).
+func shellQuoted(s string) (value, rest string, ok bool) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if c == quote {
+			return b.String(), s[i+1:], true
+		}
+		if quote == '"' && c == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`", s[i+1]) >= 0 {
+			i++
+			c = s[i]
+		}
+		b.WriteByte(c)
+	}
+	return "", s, false
+}
+
+// curlDataBody retorna el valor entre comillas del primer --data-raw o -d del comando
+func curlDataBody(curl string) string {
+	for _, pattern := range []string{"--data-raw '", `--data-raw "`, "-d '", `-d "`} {
+		if idx := strings.Index(curl, pattern); idx != -1 {
+			if body, _, ok := shellQuoted(curl[idx+len(pattern)-1:]); ok && body != "" {
+				return body
+			}
+		}
+	}
+	return ""
+}
+
+// curlURLEncodedData retorna los valores de cada --data-urlencode ya codificados como lo hace cURL:
+// "contenido" y "=contenido" codifican todo, "nombre=contenido" solo el contenido
+func curlURLEncodedData(curl string) []string {
+	const flag = "--data-urlencode"
+	var parts []string
+	for rest := curl; ; {
+		idx := strings.Index(rest, flag)
+		if idx == -1 {
+			break
+		}
+		rest = strings.TrimPrefix(rest[idx+len(flag):], "d") // También se acepta --data-urlencoded
+		if !strings.HasPrefix(rest, " ") {
+			continue
+		}
+		rest = strings.TrimLeft(rest, " ")
+
+		// Valor entre comillas o hasta el próximo espacio
+		value := ""
+		if rest != "" && (rest[0] == '\'' || rest[0] == '"') {
+			var ok bool
+			if value, rest, ok = shellQuoted(rest); !ok {
+				break
+			}
+		} else {
+			end := strings.IndexByte(rest, ' ')
+			if end == -1 {
+				end = len(rest)
+			}
+			value, rest = rest[:end], rest[end:]
+		}
+
+		name, content, hasName := strings.Cut(value, "=")
+		if !hasName {
+			name, content = "", value
+		}
+		// QueryEscape codifica los espacios como "+"; cURL usa %20 (un "+" literal ya queda como %2B)
+		encoded := strings.ReplaceAll(url.QueryEscape(content), "+", "%20")
+		if name != "" {
+			encoded = name + "=" + encoded
+		}
+		parts = append(parts, encoded)
+	}
+	return parts
+}
+
+const DefaultRequestTimeout = 10 * time.Second // Timeout por defecto de cada request
+
+// normalizeMethod limpia el método configurado (vacío = GET)
+func normalizeMethod(method string) string {
+	method = strings.ToUpper(strings.TrimSpace(method))
+	if method == "" {
+		return http.MethodGet
+	}
+	return method
+}
+
+// sendsBody indica si la request lleva body: GET, HEAD y DELETE no lo envían salvo que
+// cfg.AllowBodyAllMethods esté activado
+func sendsBody(cfg RequestConfig) bool {
+	if cfg.Body == "" && cfg.BodyFile == "" {
+		return false
+	}
+	if cfg.AllowBodyAllMethods {
+		return true
+	}
+	switch normalizeMethod(cfg.Method) {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return false
+	}
+	return true
+}
+
+const DefaultUserAgent = "BenchmarkMe/1.0"
+
+// Tipos de autenticación de RequestConfig.AuthType
+const (
+	AuthTypeHMAC = "HMAC"
+	AuthTypeNTLM = "NTLM"
+)
+
+// userAgent retorna el User-Agent configurado (o DefaultUserAgent)
+func userAgent(cfg RequestConfig) string {
+	if ua := strings.TrimSpace(cfg.UserAgent); ua != "" {
+		return ua
+	}
+	return DefaultUserAgent
+}
+
+// requestTimeout retorna el timeout configurado para cada request (o DefaultRequestTimeout)
+func requestTimeout(cfg RequestConfig) time.Duration {
+	if cfg.TimeoutSeconds > 0 {
+		return time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+	return DefaultRequestTimeout
+}
+
+// newTransport clona el transport por defecto aplicando la versión de HTTP configurada
+func newTransport(cfg RequestConfig) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.ForceHTTP1 {
+		// Un TLSNextProto vacío (no nil) desactiva HTTP/2 sobre TLS; además el ALPN solo
+		// debe ofrecer http/1.1, ya que el transport por defecto agrega "h2" al usarse
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		t.TLSClientConfig.NextProtos = []string{"http/1.1"}
+	}
+	if cfg.ConnectTimeoutMs > 0 || cfg.UnixSocketPath != "" {
+		// Mismo keep-alive que el dialer del transport por defecto
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		if cfg.ConnectTimeoutMs > 0 {
+			dialer.Timeout = time.Duration(cfg.ConnectTimeoutMs) * time.Millisecond
+		}
+		t.DialContext = dialer.DialContext
+		if cfg.UnixSocketPath != "" {
+			// Se ignora la dirección de la URL (y el proxy del entorno): toda conexión va al socket
+			t.Proxy = nil
+			t.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", cfg.UnixSocketPath)
+			}
+		}
+	}
+	return t
+}
+
+// checkUnixSocket verifica que path exista y sea un socket Unix
+func checkUnixSocket(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s no es un socket Unix", path)
+	}
+	return nil
+}
+
+// newHTTPClient crea el cliente HTTP aplicando el timeout y la política de redirects configurados
+func newHTTPClient(cfg RequestConfig) *http.Client {
+	client := &http.Client{Timeout: requestTimeout(cfg)}
+	if cfg.AuthType == AuthTypeNTLM {
+		client.Transport = newNTLMTransport(cfg)
+	} else if cfg.ForceHTTP1 || cfg.ConnectTimeoutMs > 0 || cfg.UnixSocketPath != "" {
+		client.Transport = newTransport(cfg)
+	}
+	if cfg.DisableRedirects {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return client
+}
+
+// buildRequest construye la request con X-Timestamp, Content-Type, headers y firma HMAC.
+// Retorna además la descripción de la autenticación para mostrar en la consola.
+func buildRequest(cfg RequestConfig) (*http.Request, string, error) {
+	var bodyReader io.Reader
+	if sendsBody(cfg) {
+		bodyReader = strings.NewReader(cfg.Body)
+	}
+
+	req, err := http.NewRequest(normalizeMethod(cfg.Method), cfg.URL, bodyReader)
+	if err != nil {
+		return nil, "", err
+	}
+
+	timestamp := time.Now().Format(time.RFC3339)
+	req.Header.Set("X-Timestamp", timestamp)
+	req.Header.Set("User-Agent", userAgent(cfg))
+
+	// Sin body no tiene sentido declarar su tipo
+	if cfg.ContentType != "" && bodyReader != nil {
+		req.Header.Set("Content-Type", cfg.ContentType)
+	}
+
+	applyHeaders(req.Header, cfg.Headers)
+
+	authInfo := "Sin autenticación"
+	if cfg.AuthType == AuthTypeNTLM {
+		// El handshake lo hace ntlmTransport al enviar la request
+		user := cfg.NTLMUser
+		if cfg.NTLMDomain != "" {
+			user = cfg.NTLMDomain + `\` + cfg.NTLMUser
+		}
+		authInfo = fmt.Sprintf("NTLM - Usuario: %s", user)
+	} else if cfg.User != "" && cfg.Secret != "" {
+		sig := generateHMACSignature(cfg.Secret, timestamp)
+		req.Header.Set("Authorization", fmt.Sprintf("HMAC %s:%s", cfg.User, sig))
+		authInfo = fmt.Sprintf("HMAC - User: %s, Signature: %s", cfg.User, sig)
+	}
+
+	// El hook corre al final para ver (y poder firmar) la request completa
+	if err := applyPreRequest(req, cfg); err != nil {
+		return nil, "", err
+	}
+
+	return req, authInfo, nil
+}
+
+// ScriptRequest es la vista de la request que recibe un PreRequestHook (request.headers y request.body)
+type ScriptRequest struct {
+	Method  string
+	URL     string
+	Headers http.Header
+	Body    string
+}
+
+// PreRequestHook puede modificar los headers y el body de cada request antes de enviarla, para
+// esquemas de firma que no cubren los tipos de autenticación incorporados
+type PreRequestHook func(*ScriptRequest) error
+
+// applyPreRequest ejecuta el hook sobre la request ya construida y aplica los cambios de body.
+// Sin hook compilado (request suelta, curl) compila PreRequestScript en el momento.
+func applyPreRequest(req *http.Request, cfg RequestConfig) error {
+	hook := cfg.PreRequest
+	if hook == nil {
+		var err error
+		if hook, err = compilePreRequestScript(cfg.PreRequestScript); err != nil {
+			return fmt.Errorf("pre-request: %w", err)
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	body := ""
+	if sendsBody(cfg) {
+		body = cfg.Body
+	}
+	sr := &ScriptRequest{Method: req.Method, URL: req.URL.String(), Headers: req.Header, Body: body}
+	if err := hook(sr); err != nil {
+		return fmt.Errorf("pre-request: %w", err)
+	}
+	if sr.Body != body {
+		req.Body = io.NopCloser(strings.NewReader(sr.Body))
+		req.ContentLength = int64(len(sr.Body))
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(strings.NewReader(sr.Body)), nil }
+	}
+	return nil
+}
+
+// scriptVM es una VM de JavaScript con el script pre-request ya compilado
+type scriptVM struct {
+	vm     *otto.Otto
+	script *otto.Script
+}
+
+// newScriptVM crea una VM con las funciones auxiliares para firmar (hex): hmacSHA256(clave, mensaje) y sha256(texto)
+func newScriptVM(src string) (*scriptVM, error) {
+	vm := otto.New()
+	vm.Set("hmacSHA256", func(key, message string) string { return generateHMACSignature(key, message) })
+	vm.Set("sha256", func(text string) string {
+		sum := sha256.Sum256([]byte(text))
+		return hex.EncodeToString(sum[:])
+	})
+	script, err := vm.Compile("pre-request.js", src)
+	if err != nil {
+		return nil, err
+	}
+	return &scriptVM{vm: vm, script: script}, nil
+}
+
+// compilePreRequestScript convierte un script JavaScript en un PreRequestHook (nil si src está vacío).
+// El script ve request.method, request.url, request.headers (un valor por clave) y request.body;
+// lo que cambie en headers y body se aplica a la request.
+func compilePreRequestScript(src string) (PreRequestHook, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, nil
+	}
+	if _, err := newScriptVM(src); err != nil {
+		return nil, err
+	}
+	// Una VM de otto no admite uso concurrente: cada usuario toma la suya del pool
+	pool := sync.Pool{New: func() any {
+		s, _ := newScriptVM(src) // Ya compiló sin error arriba
+		return s
+	}}
+	return func(sr *ScriptRequest) error {
+		s := pool.Get().(*scriptVM)
+		defer pool.Put(s)
+
+		headers, _ := s.vm.Object(`({})`)
+		for key := range sr.Headers {
+			headers.Set(key, sr.Headers.Get(key))
+		}
+		request, _ := s.vm.Object(`({})`)
+		request.Set("method", sr.Method)
+		request.Set("url", sr.URL)
+		request.Set("headers", headers)
+		request.Set("body", sr.Body)
+		s.vm.Set("request", request)
+		if _, err := s.vm.Run(s.script); err != nil {
+			return err
+		}
+
+		// El script puede modificar request.headers o reemplazarlo por otro objeto
+		value, _ := request.Get("headers")
+		if !value.IsObject() {
+			return fmt.Errorf("request.headers debe ser un objeto")
+		}
+		keys := value.Object().Keys()
+		for key := range sr.Headers {
+			if !slices.Contains(keys, key) {
+				sr.Headers.Del(key)
+			}
+		}
+		for _, key := range keys {
+			v, _ := value.Object().Get(key)
+			if v.String() != sr.Headers.Get(key) {
+				sr.Headers.Set(key, v.String())
+			}
+		}
+		if body, _ := request.Get("body"); body.IsDefined() && !body.IsNull() {
+			sr.Body = body.String()
+		} else {
+			sr.Body = ""
+		}
+		return nil
+	}, nil
+}
+
+// requestTiming registra las fases de una request (DNS, TCP, TLS y primer byte) con httptrace
+type requestTiming struct {
+	mu                                   sync.Mutex
+	start, dnsStart, connStart, tlsStart time.Time
+	dns, connect, tls, ttfb              time.Duration
+}
+
+// traceRequest agrega el trace a la request; start debe asignarse justo antes de enviarla
+func traceRequest(req *http.Request) (*http.Request, *requestTiming) {
+	t := &requestTiming{}
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.mu.Lock(); t.dnsStart = time.Now(); t.mu.Unlock() },
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			t.dns = time.Since(t.dnsStart)
+			t.mu.Unlock()
+		},
+		ConnectStart: func(string, string) { t.mu.Lock(); t.connStart = time.Now(); t.mu.Unlock() },
+		ConnectDone: func(string, string, error) {
+			t.mu.Lock()
+			t.connect = time.Since(t.connStart)
+			t.mu.Unlock()
+		},
+		TLSHandshakeStart: func() { t.mu.Lock(); t.tlsStart = time.Now(); t.mu.Unlock() },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			t.tls = time.Since(t.tlsStart)
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.ttfb = time.Since(t.start)
+			t.mu.Unlock()
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), t
+}
+
+// apply copia el desglose capturado al resultado
+func (t *requestTiming) apply(r *BenchmarkResult) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r.DNSMs, r.ConnectMs, r.TLSMs, r.TTFBMs = durationMs(t.dns), durationMs(t.connect), durationMs(t.tls), durationMs(t.ttfb)
+}
+
+// durationMs convierte d a milisegundos con resolución de microsegundos (los endpoints locales
+// responden en menos de 1 ms)
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// errRunEnded es la causa del corte de una request por el fin de la ejecución
+var errRunEnded = errors.New("fin de la ejecución")
+
+// requestContext aplica el deadline duro por request (si está configurado) y, en modo por
+// tiempo, corta la request al terminar la ejecución (runEnd cero = sin límite de ejecución)
+func requestContext(req *http.Request, cfg RequestConfig, runEnd time.Time) (*http.Request, context.CancelFunc) {
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if cfg.RequestDeadlineMs > 0 {
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(cfg.RequestDeadlineMs)*time.Millisecond)
+	}
+	if !runEnd.IsZero() {
+		deadlineCancel := cancel
+		var runCancel context.CancelFunc
+		ctx, runCancel = context.WithDeadlineCause(ctx, runEnd, errRunEnded)
+		cancel = func() { runCancel(); deadlineCancel() }
+	}
+	return req.WithContext(ctx), cancel
+}
+
+// endedWithRun indica si la request se cortó por el fin de la ejecución y no por el deadline
+// por request ni por el endpoint; classifyError la vería como un timeout más
+func endedWithRun(req *http.Request) bool {
+	return errors.Is(context.Cause(req.Context()), errRunEnded)
+}
+
+// classifyError clasifica el error de una request para las estadísticas y el log
+func classifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+	// Un timeout durante el dial indica que el backend ni siquiera aceptó la conexión
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" && opErr.Timeout() {
+		return "connect_timeout"
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return "timeout"
+	}
+	return "connection"
+}
+
+// applyHeaders aplica headers "Clave: Valor" por línea. La primera aparición de una clave
+// reemplaza el valor existente (ej. Content-Type) y las repeticiones se agregan en orden,
+// permitiendo enviar la misma clave varias veces (ej. X-Forwarded-For)
+func applyHeaders(h http.Header, text string) {
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(text, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key, value := http.CanonicalHeaderKey(strings.TrimSpace(parts[0])), strings.TrimSpace(parts[1])
+		if seen[key] {
+			h.Add(key, value)
+		} else {
+			h.Set(key, value)
+			seen[key] = true
+		}
+	}
+}
+
+// formatHeaders convierte los headers reales de una request en texto para la consola
+func formatHeaders(h http.Header) string {
+	var sb strings.Builder
+	for name, values := range h {
+		for _, value := range values {
+			sb.WriteString(fmt.Sprintf("%s: %s\n", name, value))
+		}
+	}
+	return sb.String()
+}
+
+// executeRequest ejecuta un single HTTP request
+func executeRequest(cfg RequestConfig, seq int) BenchmarkResult {
+	return executeSingleRequest(cfg, seq).Result
+}
+
+// SingleResponse es el resultado completo de una request única, incluyendo el body para el visor
+type SingleResponse struct {
+	Result      BenchmarkResult
+	Request     *http.Request // nil si la request no se pudo construir
+	AuthInfo    string
+	Body        string // Body capturado o descripción del error
+	ContentType string
+	Headers     http.Header // Headers de la respuesta (nil si hubo error)
+	Proto       string      // Protocolo de la respuesta (ej. "HTTP/2.0")
+	TLS         string      // Versión y cipher suite negociados (ej. "TLS 1.3, TLS_AES_128_GCM_SHA256"; "" sin TLS)
+	RequestBody string      // Body enviado, descrito como en la consola (solo en los pasos de escenario)
+	Err         error
+}
+
+// executeSingleRequest ejecuta una request con la misma configuración que el benchmark
+// (timeout, redirects, auth) y captura el body de la respuesta hasta MaxBodyCaptureBytes
+func executeSingleRequest(cfg RequestConfig, seq int) SingleResponse {
+	return sendSingleRequest(newHTTPClient(cfg), cfg, seq)
+}
+
+// sendSingleRequest es executeSingleRequest con un cliente dado, para reutilizar sus conexiones entre requests
+func sendSingleRequest(client *http.Client, cfg RequestConfig, seq int) SingleResponse {
+	req, authInfo, err := buildRequest(expandSeqToken(cfg, int64(seq)))
+	if err != nil {
+		return SingleResponse{
+			Result: BenchmarkResult{Seq: seq, Timestamp: time.Now().Format("15:04:05"), StartedAt: time.Now(), Duration: 0, Status: 0},
+			Body:   fmt.Sprintf("Error: %v", err),
+			Err:    err,
+		}
+	}
+
+	req, cancel := requestContext(req, cfg, time.Time{})
+	defer cancel()
+	req, timing := traceRequest(req)
+	start := time.Now()
+	timing.start = start
+	resp, err := client.Do(req)
+	duration := durationMs(time.Since(start))
+
+	out := SingleResponse{Request: req, AuthInfo: authInfo, Err: err}
+	status := 0
+	ttlb := duration
+	errorKind, errorDetail := classifyError(err), ""
+	if err == nil {
+		status = resp.StatusCode
+		if schema, _ := compileResponseSchema(cfg.ResponseSchema); schema != nil && isSuccess(status, cfg) {
+			if errorDetail = checkResponseSchema(schema, resp); errorDetail != "" {
+				errorKind = "schema"
+			}
+		}
+		out.ContentType = resp.Header.Get("Content-Type")
+		out.Headers = resp.Header
+		out.Proto = resp.Proto
+		out.TLS = describeTLS(resp.TLS)
+		out.Body = readCappedBody(resp, cfg.MaxBodyCaptureBytes)
+		ttlb = durationMs(time.Since(start))
+		resp.Body.Close()
+	} else {
+		out.Body = fmt.Sprintf("Error: %v", err)
+	}
+
+	out.Result = BenchmarkResult{
+		Seq:         seq,
+		Timestamp:   start.Format("15:04:05"),
+		StartedAt:   start,
+		Duration:    duration,
+		Status:      status,
+		ErrorKind:   errorKind,
+		ErrorDetail: errorDetail,
+		TTLBMs:      ttlb,
+	}
+	timing.apply(&out.Result)
+	return out
+}
+
+// cacheHeaders son los headers de las respuestas comparados por el detector de caché
+var cacheHeaders = []string{"Age", "ETag", "X-Cache", "Cache-Control"}
+
+// CacheCheck es el resultado del detector de caché: la misma request enviada dos veces seguidas
+type CacheCheck struct {
+	First, Second SingleResponse
+	SameBody      bool
+	FromCache     bool   // La segunda respuesta indica que salió de una caché (X-Cache HIT o Age > 0)
+	Reason        string // Explicación del veredicto
+}
+
+// checkResponseCaching envía la request de cfg dos veces y compara Age, ETag, X-Cache y el body
+// para determinar si la segunda respuesta se sirvió desde una caché (CDN, proxy o servidor)
+func checkResponseCaching(cfg RequestConfig) CacheCheck {
+	check := CacheCheck{First: executeSingleRequest(cfg, 1)}
+	if check.First.Err != nil {
+		check.Reason = fmt.Sprintf("la primera request falló: %v", check.First.Err)
+		return check
+	}
+	check.Second = executeSingleRequest(cfg, 2)
+	if check.Second.Err != nil {
+		check.Reason = fmt.Sprintf("la segunda request falló: %v", check.Second.Err)
+		return check
+	}
+	check.SameBody = check.First.Body == check.Second.Body
+
+	second := check.Second.Headers
+	age, _ := strconv.Atoi(second.Get("Age"))
+	switch {
+	case strings.Contains(strings.ToUpper(second.Get("X-Cache")), "HIT"):
+		check.FromCache = true
+		check.Reason = fmt.Sprintf("X-Cache: %s", second.Get("X-Cache"))
+	case age > 0:
+		check.FromCache = true
+		check.Reason = fmt.Sprintf("Age: %d s (la respuesta ya estaba almacenada)", age)
+	case second.Get("ETag") != "" && second.Get("ETag") == check.First.Headers.Get("ETag") && check.SameBody:
+		check.Reason = "misma ETag y mismo body, pero sin Age ni X-Cache HIT: el recurso es estable, aunque no hay evidencia de caché"
+	case !check.SameBody:
+		check.Reason = "el body cambió entre las dos requests: la respuesta no se está cacheando"
+	default:
+		check.Reason = "sin headers de caché (Age, X-Cache) en la segunda respuesta"
+	}
+	return check
+}
+
+// formatCacheReport arma el reporte del detector de caché con los headers de ambas respuestas lado a lado
+func formatCacheReport(check CacheCheck, decimals int) string {
+	var b strings.Builder
+	if check.FromCache {
+		b.WriteString("✅ La segunda respuesta se sirvió desde caché\n")
+	} else {
+		b.WriteString("❌ No se detectó caché\n")
+	}
+	b.WriteString(check.Reason + "\n")
+	if check.First.Err != nil || check.Second.Err != nil {
+		return b.String()
+	}
+
+	b.WriteString(fmt.Sprintf("\n%-14s %-28s %s\n", "", "1ª request", "2ª request"))
+	b.WriteString(fmt.Sprintf("%-14s %-28d %d\n", "Status", check.First.Result.Status, check.Second.Result.Status))
+	b.WriteString(fmt.Sprintf("%-14s %-28s %s\n", "Duración", formatDuration(check.First.Result.Duration, decimals), formatDuration(check.Second.Result.Duration, decimals)))
+	for _, h := range cacheHeaders {
+		first, second := check.First.Headers.Get(h), check.Second.Headers.Get(h)
+		if first == "" && second == "" {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%-14s %-28s %s\n", h, orDash(first), orDash(second)))
+	}
+	same := "no"
+	if check.SameBody {
+		same = "sí"
+	}
+	b.WriteString(fmt.Sprintf("%-14s %s\n", "Body idéntico", same))
+	return b.String()
+}
+
+// DefaultColdWarmRequests es la cantidad de requests por defecto del diagnóstico frío/caliente
+const DefaultColdWarmRequests = 10
+
+// ColdWarmCheck separa la latencia de la primera request (en frío: conexión nueva, cachés vacías)
+// del promedio del resto (en caliente) para cuantificar la penalización del arranque
+type ColdWarmCheck struct {
+	Cold    BenchmarkResult   // Primera request
+	Warm    []BenchmarkResult // Requests siguientes
+	WarmAvg float64           // Latencia promedio de Warm en ms
+	Reused  int               // Requests de Warm que reutilizaron la conexión (sin tiempo de conexión)
+	Failed  int               // Requests de Warm que fallaron
+}
+
+// Penalty es cuánto más tardó la request en frío que el promedio en caliente (ms)
+func (c ColdWarmCheck) Penalty() float64 {
+	return c.Cold.Duration - c.WarmAvg
+}
+
+// checkColdWarm envía n requests seguidas con la configuración de cfg y separa la primera del resto.
+// Todas usan un mismo cliente con un transport propio: la primera tiene que abrir una conexión nueva
+// y las siguientes pueden reutilizarla. Al terminar se cierran sus conexiones ociosas.
+func checkColdWarm(cfg RequestConfig, n int) ColdWarmCheck {
+	client := newHTTPClient(cfg)
+	if client.Transport == nil {
+		client.Transport = newTransport(cfg) // Sin conexiones previas del transport por defecto
+	}
+	defer client.CloseIdleConnections()
+	check := ColdWarmCheck{Cold: sendSingleRequest(client, cfg, 1).Result}
+	sum := 0.0
+	for seq := 2; seq <= n; seq++ {
+		r := sendSingleRequest(client, cfg, seq).Result
+		check.Warm = append(check.Warm, r)
+		sum += r.Duration
+		if countsAsError(r, cfg) {
+			check.Failed++
+		} else if r.ConnectMs == 0 {
+			check.Reused++
+		}
+	}
+	if len(check.Warm) > 0 {
+		check.WarmAvg = sum / float64(len(check.Warm))
+	}
+	return check
+}
+
+// formatColdWarmReport arma el reporte del diagnóstico frío/caliente
+func formatColdWarmReport(check ColdWarmCheck, cfg RequestConfig, decimals int) string {
+	var b strings.Builder
+	cold := check.Cold
+	if countsAsError(cold, cfg) {
+		b.WriteString(fmt.Sprintf("❌ La primera request falló (status %d %s): la medición en frío no es representativa\n\n", cold.Status, cold.ErrorKind))
+	}
+	b.WriteString(fmt.Sprintf("%-26s %s (status %d)\n", "Frío (1ª request)", formatDuration(cold.Duration, decimals), cold.Status))
+	if cold.DNSMs > 0 || cold.ConnectMs > 0 || cold.TLSMs > 0 {
+		b.WriteString(fmt.Sprintf("%-26s DNS %s, TCP %s, TLS %s\n", "", formatDuration(cold.DNSMs, decimals), formatDuration(cold.ConnectMs, decimals), formatDuration(cold.TLSMs, decimals)))
+	}
+	b.WriteString(fmt.Sprintf("%-26s %s\n", fmt.Sprintf("Caliente (promedio de %d)", len(check.Warm)), formatDuration(check.WarmAvg, decimals)))
+	delta := fmt.Sprintf("%+.*f ms", decimals, check.Penalty())
+	if check.WarmAvg > 0 {
+		delta += fmt.Sprintf(" (%.1fx)", cold.Duration/check.WarmAvg)
+	}
+	b.WriteString(fmt.Sprintf("%-26s %s\n", "Penalización en frío", delta))
+	b.WriteString(fmt.Sprintf("%-26s %d de %d\n", "Conexiones reutilizadas", check.Reused, len(check.Warm)))
+	if check.Failed > 0 {
+		b.WriteString(fmt.Sprintf("%-26s %d\n", "Requests fallidas", check.Failed))
+	}
+	return b.String()
+}
+
+// orDash retorna s o "-" si está vacío
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// tlsVersionName traduce la constante tls.VersionXXX a su nombre ("TLS 1.3")
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionSSL30:
+		return "SSL 3.0"
+	}
+	return fmt.Sprintf("0x%04X", version)
+}
+
+// describeTLS resume la versión y el cipher suite negociados ("" si la conexión no usó TLS)
+func describeTLS(state *tls.ConnectionState) string {
+	if state == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s, %s", tlsVersionName(state.Version), tls.CipherSuiteName(state.CipherSuite))
+}
+
+const DefaultMaxBodyCaptureBytes = 1 << 20 // 1MB capturado como máximo en request única
+
+// readCappedBody lee el body hasta maxBytes y agrega un aviso si la respuesta era mayor.
+// El resto se descarta sin guardarlo en memoria para poder informar el tamaño total.
+func readCappedBody(resp *http.Response, maxBytes int) string {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBodyCaptureBytes
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, int64(maxBytes)+1))
+	if len(data) <= maxBytes {
+		return string(data)
+	}
+
+	total := resp.ContentLength
+	if total < 0 {
+		rest, _ := io.Copy(io.Discard, resp.Body)
+		total = int64(len(data)) + rest
+	}
+	return string(data[:maxBytes]) + fmt.Sprintf("\n\n... (truncated, %d bytes total)", total)
+}
+
+// formatBody intenta formatear un body como JSON o XML (básico)
+func formatBody(body string) (string, bool) {
+	body = strings.TrimSpace(body)
+
+	// Intentar formatear como JSON
+	var jsonData interface{}
+	if err := json.Unmarshal([]byte(body), &jsonData); err == nil {
+		formatted, err := json.MarshalIndent(jsonData, "", "  ")
+		if err == nil {
+			return string(formatted), true
+		}
+	}
+
+	// Si no es JSON, intentar como XML
+	if strings.HasPrefix(body, "<") {
+		if formatted, ok := formatXML(body); ok {
+			return formatted, true
+		}
+		// XML mal formado: formateo básico, un tag por línea
+		return strings.ReplaceAll(body, "><", ">\n<"), true
+	}
+
+	return body, false
+}
+
+// formatXML indenta un documento XML re-codificándolo token a token con un xml.Encoder. Los prefijos de
+// namespace se conservan tal como vienen y los elementos vacíos se escriben como <tag/> (ok = false si el
+// XML no está bien formado)
+func formatXML(body string) (string, bool) {
+	dec := xml.NewDecoder(strings.NewReader(body))
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	var open []string     // Elementos abiertos: RawToken no verifica que los cierres coincidan
+	emptyFrom := -1       // Largo del buffer tras un tag de apertura todavía sin contenido (-1 = no hay)
+	wroteElement := false // Hasta el primer elemento el encoder no agrega saltos de línea
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", false
+		}
+		closesEmpty := false
+		misc := false // Comentario, declaración o directiva: el encoder no los indenta
+		switch t := tok.(type) {
+		case xml.StartElement:
+			start := xml.StartElement{Name: flatXMLName(t.Name)}
+			for _, attr := range t.Attr {
+				start.Attr = append(start.Attr, xml.Attr{Name: flatXMLName(attr.Name), Value: attr.Value})
+			}
+			open = append(open, start.Name.Local)
+			wroteElement = true
+			tok = start
+		case xml.EndElement:
+			name := flatXMLName(t.Name)
+			if len(open) == 0 || open[len(open)-1] != name.Local {
+				return "", false
+			}
+			open = open[:len(open)-1]
+			closesEmpty = emptyFrom >= 0
+			tok = xml.EndElement{Name: name}
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) == 0 {
+				continue // La indentación la agrega el encoder
+			}
+			tok = t.Copy()
+		default:
+			misc = true
+			tok = xml.CopyToken(tok)
+			if wroteElement {
+				buf.WriteString("\n" + strings.Repeat("  ", len(open)))
+			}
+		}
+		if err := enc.EncodeToken(tok); err != nil {
+			return "", false
+		}
+		if err := enc.Flush(); err != nil {
+			return "", false
+		}
+		if closesEmpty {
+			// El encoder siempre escribe <tag></tag>: se reemplaza por <tag/>
+			buf.Truncate(emptyFrom - 1)
+			buf.WriteString("/>")
+		}
+		if misc && !wroteElement {
+			buf.WriteString("\n") // Antes del elemento raíz (ej. <?xml ...?>)
+		}
+		emptyFrom = -1
+		if _, ok := tok.(xml.StartElement); ok {
+			emptyFrom = buf.Len()
+		}
+	}
+	if len(open) > 0 || !wroteElement {
+		return "", false
+	}
+	return buf.String(), true
+}
+
+const BodyValidationDelay = 400 * time.Millisecond // Pausa de tipeo tras la cual se valida el body
+
+// checkBodySyntax valida el body con el mismo parseo que formatBody: JSON si empieza con { o [,
+// XML si empieza con <. kind es "" para bodies vacíos o de otro formato (form, texto), que no se validan.
+// El token {{seq}} se reemplaza por un número, como al enviarlo
+func checkBodySyntax(body string) (kind string, err error) {
+	body = strings.TrimSpace(strings.ReplaceAll(body, SeqToken, "1"))
+	switch {
+	case strings.HasPrefix(body, "{") || strings.HasPrefix(body, "["):
+		var v interface{}
+		return "JSON", json.Unmarshal([]byte(body), &v)
+	case strings.HasPrefix(body, "<"):
+		if _, ok := formatXML(body); !ok {
+			return "XML", errors.New("XML mal formado")
+		}
+		return "XML", nil
+	}
+	return "", nil
+}
+
+// flatXMLName pasa el prefijo de namespace al nombre local ("soap:Envelope"): así el encoder
+// lo escribe tal cual en lugar de declarar un namespace nuevo
+func flatXMLName(name xml.Name) xml.Name {
+	if name.Space == "" {
+		return name
+	}
+	return xml.Name{Local: name.Space + ":" + name.Local}
+}
+
+// isFormattableContentType detecta si el Content-Type (o el propio body) es JSON o XML
+func isFormattableContentType(contentType, body string) bool {
+	ct := strings.ToLower(contentType)
+	if strings.Contains(ct, "json") || strings.Contains(ct, "xml") {
+		return true
+	}
+	if ct == "" || strings.HasPrefix(ct, "text/plain") {
+		trimmed := strings.TrimSpace(body)
+		return strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "<?xml")
+	}
+	return false
+}
+
+// browserExtension retorna la extensión con la que se abre en el navegador una respuesta HTML o XML
+// (ok = false para el resto de los Content-Type, que el visor de texto ya muestra bien)
+func browserExtension(contentType string) (string, bool) {
+	ct := strings.ToLower(contentType)
+	switch {
+	case strings.Contains(ct, "html"):
+		return ".html", true
+	case strings.Contains(ct, "xml"):
+		return ".xml", true
+	}
+	return "", false
+}
+
+// writeBrowserFile guarda el body en un archivo temporal con la extensión dada y retorna su URL file://
+func writeBrowserFile(body, ext string) (*url.URL, error) {
+	f, err := os.CreateTemp("", "benchmarkpro-response-*"+ext)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.WriteString(body); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	path := filepath.ToSlash(f.Name())
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // Windows: file:///C:/...
+	}
+	return &url.URL{Scheme: "file", Path: path}, nil
+}
+
+// Opciones especiales del selector de Content-Type (el resto son valores literales)
+const (
+	ContentTypeAuto = "Auto (según body)"
+	ContentTypeNone = "Sin Content-Type"
+)
+
+// contentTypeOptions son los valores ofrecidos en el selector (también se puede escribir uno propio)
+var contentTypeOptions = []string{
+	ContentTypeAuto,
+	ContentTypeNone,
+	"application/json",
+	"application/xml",
+	"text/plain",
+	"application/x-www-form-urlencoded",
+	"multipart/form-data",
+}
+
+// detectContentType deduce el Content-Type del body (o de la extensión del archivo de body).
+// Retorna "" si no hay body o no se reconoce el formato.
+func detectContentType(body, fileName string) string {
+	if fileName != "" {
+		if ct := mime.TypeByExtension(filepath.Ext(fileName)); ct != "" {
+			return ct
+		}
+		return ""
+	}
+	trimmed := strings.TrimSpace(body)
+	switch {
+	case trimmed == "":
+		return ""
+	case json.Valid([]byte(trimmed)):
+		return "application/json"
+	case strings.HasPrefix(trimmed, "<"):
+		return "application/xml"
+	case !strings.ContainsAny(trimmed, " \n") && strings.Contains(trimmed, "="):
+		return "application/x-www-form-urlencoded"
+	}
+	return "text/plain"
+}
+
+// resolveContentType convierte la opción elegida en el selector en el valor de RequestConfig.ContentType
+func resolveContentType(selected, body, fileName string) string {
+	switch strings.TrimSpace(selected) {
+	case ContentTypeAuto:
+		return detectContentType(body, fileName)
+	case ContentTypeNone, "":
+		return ""
+	}
+	return strings.TrimSpace(selected)
+}
+
+const MaxResponseDisplayBytes = 256 * 1024 // Límite de caracteres mostrados en el visor de respuesta
+
+// truncateForDisplay recorta bodies grandes para mantener la UI fluida, sin partir un carácter UTF-8
+func truncateForDisplay(body string) string {
+	if len(body) <= MaxResponseDisplayBytes {
+		return body
+	}
+	cut := MaxResponseDisplayBytes
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	return body[:cut] + fmt.Sprintf("\n\n... (mostrando %d de %d bytes)", MaxResponseDisplayBytes, len(body))
+}
+
+// --- HISTORIAL DE URLs ---
+
+const recentURLsKey = "recentURLs"
+const MaxRecentURLs = 10 // Cantidad de URLs distintas recordadas
+
+// Sugerencia de pantalla completa al terminar tests con muchos resultados
+const fullScreenSuggestKey = "fullScreenSuggestThreshold"
+const DefaultFullScreenSuggestThreshold = 30 // Resultados a partir de los cuales se sugiere (0 = nunca)
+
+// Ajustes persistentes editados en el diálogo de Ajustes
+const (
+	settingsTimeoutKey     = "settingsTimeoutSeconds"
+	settingsConnTimeoutKey = "settingsConnectTimeoutMs"
+	settingsUserAgentKey   = "settingsUserAgent"
+	settingsMaxBodyKBKey   = "settingsMaxBodyCaptureKB"
+	settingsPercentilesKey = "settingsPercentiles"
+	settingsMaxResultsKey  = "settingsMaxRetainedResults"
+	settingsSafeTotalKey   = "settingsSafeTotalRequests"
+	settingsDecimalsKey    = "settingsLatencyDecimals"
+	settingsSteadyRPSKey   = "settingsPreferSteadyRPS"
+	settingsInfluxURLKey   = "settingsInfluxWriteURL"
+	settingsInfluxTokenKey = "settingsInfluxToken"
+	settingsPushgatewayKey = "settingsPushgatewayURL"
+	settingsPromJobKey     = "settingsPrometheusJob"
+)
+
+// bindEntryPreference carga en el entry el valor guardado en key (o fallback) y lo guarda en cada cambio.
+// El valor se valida recién al ejecutar, igual que el resto de la configuración.
+func bindEntryPreference(e *widget.Entry, prefs fyne.Preferences, key, fallback string) {
+	e.SetText(prefs.StringWithFallback(key, fallback))
+	e.OnChanged = func(text string) { prefs.SetString(key, text) }
+}
+
+// addRecentURL guarda la URL al principio del historial persistente, sin duplicados
+func addRecentURL(prefs fyne.Preferences, url string) {
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return
+	}
+	recent := []string{url}
+	for _, u := range prefs.StringList(recentURLsKey) {
+		if u != url && len(recent) < MaxRecentURLs {
+			recent = append(recent, u)
+		}
+	}
+	prefs.SetStringList(recentURLsKey, recent)
+}
+
+// --- UI PRINCIPAL ---
+
+// compactPaddingLayout es un layout con padding reducido para compactar elementos
+type compactPaddingLayout struct {
+	padding float32
+}
+
+func (c *compactPaddingLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	padding := c.padding
+	if padding == 0 {
+		padding = 2
+	}
+	pos := fyne.NewPos(padding, padding)
+	for _, o := range objects {
+		size := fyne.NewSize(size.Width-2*padding, size.Height-2*padding)
+		o.Resize(size)
+		o.Move(pos)
+	}
+}
+
+func (c *compactPaddingLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	padding := c.padding
+	if padding == 0 {
+		padding = 2
+	}
+	minSize := fyne.NewSize(0, 0)
+	for _, o := range objects {
+		childSize := o.MinSize()
+		if childSize.Width > minSize.Width {
+			minSize.Width = childSize.Width
+		}
+		if childSize.Height > minSize.Height {
+			minSize.Height = childSize.Height
+		}
+	}
+	return fyne.NewSize(minSize.Width+2*padding, minSize.Height+2*padding)
+}
+
+func newBoldLabel(text string, align fyne.TextAlign) *widget.Label {
+	l := widget.NewLabel(text)
 	l.TextStyle = fyne.TextStyle{Bold: true}
 	l.Alignment = align
 	return l
 }
 
-func main() {
-	// CORRECCIÓN: Usamos NewWithID para evitar la advertencia de las preferencias.
-	myApp := app.NewWithID("com.francisco.benchmarkpro")
-	myWindow := myApp.NewWindow("Benchmark Pro - Postman Integrado")
-	myWindow.Resize(fyne.NewSize(1000, 700))
+// --- MODO HEADLESS ---
+
+// runHeadless ejecuta un test sin abrir la ventana a partir de los flags de línea de comandos,
+// imprime las estadísticas en JSON por stdout y retorna el código de salida del proceso:
+// 0 si todo salió bien, 1 si se incumplió el SLA y 2 si los flags son inválidos.
+func runHeadless(args []string) int {
+	fs := flag.NewFlagSet("headless", flag.ContinueOnError)
+	fs.Bool("headless", true, "Ejecutar sin interfaz gráfica")
+	url := fs.String("url", "", "URL a probar (http(s)://, ws(s):// o grpc(s)://)")
+	method := fs.String("method", "GET", "Método HTTP (cualquier token válido, ej. PURGE)")
+	headers := fs.String("headers", "", "Headers \"Clave: Valor\", uno por línea")
+	body := fs.String("body", "", "Body de la request")
+	bodyFile := fs.String("body-file", "", "Archivo cuyo contenido se envía como body")
+	bodyDir := fs.String("body-dir", "", "Carpeta con un body por archivo; cada request envía el siguiente")
+	randomBodies := fs.Bool("random-bodies", false, "Elegir los archivos de -body-dir al azar en lugar de round-robin")
+	contentType := fs.String("content-type", ContentTypeAuto, "Content-Type del body")
+	user := fs.String("user", "", "User ID para la firma HMAC")
+	secret := fs.String("secret", "", "Secret Key para la firma HMAC")
+	ntlmDomain := fs.String("ntlm-domain", "", "Dominio para autenticación NTLM")
+	ntlmUser := fs.String("ntlm-user", "", "Usuario para autenticación NTLM (activa NTLM en lugar de HMAC)")
+	ntlmPassword := fs.String("ntlm-password", "", "Password para autenticación NTLM")
+	grpcMethod := fs.String("grpc-method", "", "Método gRPC paquete.Servicio/Metodo")
+	count := fs.Int("count", 1, "Cantidad de requests")
+	duration := fs.Int("duration", 0, "Duración del test en segundos (0 = usar -count)")
+	users := fs.Int("users", 1, "Usuarios concurrentes")
+	warmup := fs.Int("warmup", 0, "Segundos iniciales excluidos de las estadísticas (solo con -duration)")
+	targetRPS := fs.Int("rps", 0, "Límite de requests por segundo entre todos los usuarios (0 = sin límite)")
+	maxRunDuration := fs.Int("max-run-duration", 0, "Abortar la ejecución completa si supera estos segundos, también en modo por cantidad (0 = sin tope)")
+	timeout := fs.Int("timeout", 0, "Timeout por request en segundos (0 = por defecto)")
+	connectTimeout := fs.Int("connect-timeout", 0, "Timeout para establecer la conexión en ms (0 = por defecto)")
+	unixSocket := fs.String("unix-socket", "", "Socket Unix al que conectar (la URL aporta el path y el Host)")
+	maxResults := fs.Int("max-results", DefaultMaxRetainedResults, "Resultados completos conservados en memoria (0 = todos); las estadísticas cubren todos")
+	schemaFile := fs.String("response-schema", "", "Archivo con el JSON Schema que deben cumplir las respuestas 2xx")
+	preRequestFile := fs.String("pre-request", "", "Archivo JavaScript que ajusta request.headers y request.body antes de cada request")
+	envFile := fs.String("env-file", "", "Archivo CLAVE=VALOR cuyos valores reemplazan los tokens ${CLAVE} de URL, headers y body")
+	http1 := fs.Bool("http1", false, "Forzar HTTP/1.1 (no negociar HTTP/2)")
+	separate429 := fs.Bool("separate-429", false, "Contar las respuestas 429 aparte (RateLimited) y no como errores")
+	honorRetryAfter := fs.Bool("honor-retry-after", false, "Ante un 429/503 con Retry-After, esperar ese tiempo antes de la próxima request del usuario")
+	note := fs.String("note", "", "Nota libre que se incluye en el JSON de salida (ej. \"baseline antes del cambio de caché\")")
+	percentiles := fs.String("percentiles", "", "Percentiles a calcular separados por comas (ej. 50,90,99.9)")
+	slaMs := fs.Int("sla", 0, "SLA de latencia en ms para contar requests lentas")
+	slaP95 := fs.Float64("sla-p95", 0, "SLA del P95 en ms; si se supera el proceso termina con código 1")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if strings.TrimSpace(*url) == "" {
+		fmt.Fprintln(os.Stderr, "headless: falta -url")
+		return 2
+	}
+	httpMethod := strings.ToUpper(strings.TrimSpace(*method))
+	if !isHTTPToken(httpMethod) {
+		fmt.Fprintf(os.Stderr, "headless: -method inválido: %q\n", *method)
+		return 2
+	}
+	if *count < 1 || *users < 1 || *duration < 0 || *slaP95 < 0 {
+		fmt.Fprintln(os.Stderr, "headless: -count y -users deben ser al menos 1; -duration y -sla-p95 no pueden ser negativos")
+		return 2
+	}
+	if *targetRPS < 0 || *targetRPS > MaxTargetRPS {
+		fmt.Fprintf(os.Stderr, "headless: -rps debe estar entre 0 y %d\n", MaxTargetRPS)
+		return 2
+	}
+	percentileList, err := parsePercentiles(*percentiles)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "headless:", err)
+		return 2
+	}
+	if *unixSocket != "" {
+		if err := checkUnixSocket(*unixSocket); err != nil {
+			fmt.Fprintln(os.Stderr, "headless:", err)
+			return 2
+		}
+	}
+	preRequestScript := ""
+	if *preRequestFile != "" {
+		data, err := os.ReadFile(*preRequestFile)
+		if err == nil {
+			_, err = compilePreRequestScript(string(data))
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "headless: -pre-request:", err)
+			return 2
+		}
+		preRequestScript = string(data)
+	}
+	responseSchema := ""
+	if *schemaFile != "" {
+		data, err := os.ReadFile(*schemaFile)
+		if err == nil {
+			_, err = compileResponseSchema(string(data))
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "headless: -response-schema:", err)
+			return 2
+		}
+		responseSchema = string(data)
+	}
+	var env map[string]string
+	if *envFile != "" {
+		data, err := os.ReadFile(*envFile)
+		if err == nil {
+			env, err = parseEnvFile(data)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "headless: -env-file:", err)
+			return 2
+		}
+	}
+
+	cfg := RequestConfig{
+		URL:                   strings.TrimSpace(*url),
+		Method:                httpMethod,
+		Headers:               *headers,
+		Body:                  *body,
+		BodyFile:              *bodyFile,
+		BodyDir:               *bodyDir,
+		RandomizeBodies:       *randomBodies,
+		ContentType:           resolveContentType(*contentType, *body, *bodyFile),
+		User:                  *user,
+		Secret:                *secret,
+		GRPCMethod:            strings.TrimSpace(*grpcMethod),
+		Count:                 *count,
+		Duration:              *duration,
+		ConcurrentUsers:       *users,
+		TimeoutSeconds:        *timeout,
+		ConnectTimeoutMs:      *connectTimeout,
+		UnixSocketPath:        *unixSocket,
+		ResponseSchema:        responseSchema,
+		PreRequestScript:      preRequestScript,
+		MaxRetainedResults:    *maxResults,
+		Percentiles:           percentileList,
+		SlowThresholdMs:       *slaMs,
+		SLAP95Ms:              *slaP95,
+		ForceHTTP1:            *http1,
+		SeparateRateLimited:   *separate429,
+		HonorRetryAfter:       *honorRetryAfter,
+		WarmupSeconds:         *warmup,
+		MaxRunDurationSeconds: *maxRunDuration,
+		TargetRPS:             *targetRPS,
+		Note:                  strings.TrimSpace(*note),
+	}
+	if *ntlmUser != "" {
+		cfg.AuthType = AuthTypeNTLM
+		cfg.NTLMDomain, cfg.NTLMUser, cfg.NTLMPassword = *ntlmDomain, *ntlmUser, *ntlmPassword
+	}
+	cfg = expandEnvTokens(cfg, env)
+	if isWebSocketURL(cfg.URL) || isGRPCURL(cfg.URL) {
+		if err := sessionOptionsError(cfg); err != nil {
+			fmt.Fprintln(os.Stderr, "headless:", err)
+			return 2
+		}
+	}
+	_, stats := testRunner(cfg.URL)(cfg, nil, nil, nil)
+	stats.Note = cfg.Note
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(stats); err != nil {
+		fmt.Fprintln(os.Stderr, "headless:", err)
+		return 2
+	}
+	if stats.SLABreached {
+		fmt.Fprintf(os.Stderr, "headless: SLA incumplido, P95 %s > %s\n", formatDuration(stats.P95Ms, DefaultLatencyDecimals), formatDuration(stats.SLAP95Ms, DefaultLatencyDecimals))
+		return 1
+	}
+	return 0
+}
+
+// hasHeadlessFlag indica si la línea de comandos pide el modo headless
+func hasHeadlessFlag(args []string) bool {
+	for _, a := range args {
+		switch a {
+		case "-headless", "--headless", "-headless=true", "--headless=true":
+			return true
+		}
+	}
+	return false
+}
+
+func main() {
+	// Modo headless: ejecutar el test desde la línea de comandos sin crear la app Fyne
+	if hasHeadlessFlag(os.Args[1:]) {
+		os.Exit(runHeadless(os.Args[1:]))
+	}
+
+	// CORRECCIÓN: Usamos NewWithID para evitar la advertencia de las preferencias.
+	myApp := app.NewWithID("com.francisco.benchmarkpro")
+	myWindow := myApp.NewWindow("Benchmark Pro - Postman Integrado")
+	myWindow.Resize(fyne.NewSize(1000, 700))
+
+	// --- ELEMENTOS DE UI ---
+	urlEntry := widget.NewEntry()
+	urlEntry.SetText("https://google.com")
+	urlEntry.SetPlaceHolder("https://api...")
+
+	// Nota de la ejecución: se guarda con los resultados y se muestra en el resumen
+	noteEntry := widget.NewEntry()
+	noteEntry.SetPlaceHolder("Nota de la ejecución (ej. baseline antes del cambio de caché)")
+
+	// Menú con las últimas URLs ejecutadas (persistidas en las preferencias)
+	var recentURLsBtn *widget.Button
+	recentURLsBtn = widget.NewButtonWithIcon("", theme.HistoryIcon(), func() {
+		prefs := myApp.Preferences()
+		items := []*fyne.MenuItem{}
+		for _, u := range prefs.StringList(recentURLsKey) {
+			url := u
+			items = append(items, fyne.NewMenuItem(url, func() { urlEntry.SetText(url) }))
+		}
+		if len(items) == 0 {
+			disabled := fyne.NewMenuItem("Sin historial", nil)
+			disabled.Disabled = true
+			items = append(items, disabled)
+		} else {
+			items = append(items, fyne.NewMenuItemSeparator(), fyne.NewMenuItem("Limpiar historial", func() {
+				prefs.SetStringList(recentURLsKey, []string{})
+			}))
+		}
+		widget.ShowPopUpMenuAtRelativePosition(fyne.NewMenu("", items...), myWindow.Canvas(),
+			fyne.NewPos(0, recentURLsBtn.Size().Height), recentURLsBtn)
+	})
+
+	userEntry := widget.NewEntry()
+	userEntry.SetPlaceHolder("User ID")
+	secretEntry := widget.NewPasswordEntry()
+	secretEntry.SetPlaceHolder("HMAC Secret")
+
+	ntlmDomainEntry := widget.NewEntry()
+	ntlmDomainEntry.SetPlaceHolder("Dominio")
+	ntlmUserEntry := widget.NewEntry()
+	ntlmUserEntry.SetPlaceHolder("Usuario")
+	ntlmPasswordEntry := widget.NewPasswordEntry()
+	ntlmPasswordEntry.SetPlaceHolder("Password")
+
+	// El tipo de autenticación alterna entre los campos HMAC y NTLM
+	hmacFields := container.NewGridWithColumns(2, userEntry, secretEntry)
+	ntlmFields := container.NewGridWithColumns(3, ntlmDomainEntry, ntlmUserEntry, ntlmPasswordEntry)
+	ntlmFields.Hide()
+	authTypeSelect := widget.NewSelect([]string{AuthTypeHMAC, AuthTypeNTLM}, func(s string) {
+		if s == AuthTypeNTLM {
+			hmacFields.Hide()
+			ntlmFields.Show()
+		} else {
+			ntlmFields.Hide()
+			hmacFields.Show()
+		}
+	})
+	authTypeSelect.SetSelected(AuthTypeHMAC)
+
+	methodPicker := NewMethodPicker()
+
+	headerTable := NewHeaderTable()
+
+	// Edición de los headers como texto (los deshabilitados se escriben con "#")
+	headersTextBtn := widget.NewButtonWithIcon("Editar como texto", theme.DocumentCreateIcon(), func() {
+		textEntry := widget.NewMultiLineEntry()
+		textEntry.SetPlaceHolder("Content-Type: application/json\n# Authorization: Bearer token")
+		textEntry.SetMinRowsVisible(8)
+		textEntry.SetText(formatHeaderRows(headerTable.Rows(), true))
+		dialog.ShowCustomConfirm("Headers como texto", "Aplicar", "Cancelar", textEntry, func(ok bool) {
+			if ok {
+				headerTable.SetRows(parseHeaderRows(textEntry.Text))
+			}
+		}, myWindow)
+	})
+
+	bodyEntry := widget.NewMultiLineEntry()
+	bodyEntry.SetPlaceHolder(`{\n  "key": "value",\n  "nested": {\n    "data": "example"\n  }\n}`)
+	bodyEntry.SetMinRowsVisible(15) // Más grande para mejor visualización
+	bodyEntry.Wrapping = fyne.TextWrapWord
+
+	// Indicador de sintaxis del body: se valida al dejar de tipear, no en cada tecla
+	bodySyntaxLabel := widget.NewLabel("")
+	bodySyntaxLabel.Truncation = fyne.TextTruncateEllipsis
+	var bodyValidation *time.Timer
+	bodyEntry.OnChanged = func(text string) {
+		if bodyValidation != nil {
+			bodyValidation.Stop()
+		}
+		bodyValidation = time.AfterFunc(BodyValidationDelay, func() {
+			kind, err := checkBodySyntax(text)
+			fyne.Do(func() {
+				switch {
+				case kind == "":
+					bodySyntaxLabel.SetText("")
+				case err != nil:
+					bodySyntaxLabel.Importance = widget.DangerImportance
+					bodySyntaxLabel.SetText(fmt.Sprintf("✗ %s inválido: %v", kind, err))
+				default:
+					bodySyntaxLabel.Importance = widget.SuccessImportance
+					bodySyntaxLabel.SetText(fmt.Sprintf("✓ %s válido", kind))
+				}
+			})
+		})
+	}
+
+	// Content-Type del body (editable para agregar parámetros, ej. boundary de multipart)
+	contentTypeSelect := widget.NewSelectEntry(contentTypeOptions)
+	contentTypeSelect.SetText(ContentTypeAuto)
+
+	// GET/HEAD/DELETE no envían body salvo que se active explícitamente
+	allowBodyCheck := widget.NewCheck("Enviar body en todos los métodos (incluye GET/HEAD/DELETE)", nil)
+
+	// Lista de URLs desde archivo (una por línea); cada request usa una de ellas en lugar de urlEntry
+	var urlList []string
+	urlListLabel := widget.NewLabel("Sin lista de URLs")
+	urlListClearBtn := widget.NewButtonWithIcon("", theme.ContentClearIcon(), nil)
+	urlListClearBtn.Hide()
+	randomizeURLsCheck := widget.NewCheck("Orden aleatorio", nil)
+	randomizeURLsCheck.Hide()
+	setURLList := func(path string) {
+		urlList = nil
+		urlListLabel.SetText("Sin lista de URLs")
+		urlListClearBtn.Hide()
+		randomizeURLsCheck.Hide()
+		if path == "" {
+			return
+		}
+		urls, err := loadURLList(path)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("no se pudo cargar la lista de URLs: %w", err), myWindow)
+			return
+		}
+		urlList = urls
+		urlListLabel.SetText(fmt.Sprintf("%s (%d URLs)", filepath.Base(path), len(urls)))
+		urlListClearBtn.Show()
+		randomizeURLsCheck.Show()
+	}
+	urlListClearBtn.OnTapped = func() { setURLList("") }
+	urlListBtn := widget.NewButtonWithIcon("URLs desde archivo", theme.FileIcon(), func() {
+		fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			setURLList(reader.URI().Path())
+		}, myWindow)
+		fd.Show()
+	})
+
+	// Body desde archivo (reemplaza el contenido de bodyEntry al ejecutar)
+	var bodyFilePath string
+	var bodyDirPath string // Carpeta de bodies (ver setBodyDir)
+	bodyFileLabel := widget.NewLabel("Sin archivo")
+	bodyFileClearBtn := widget.NewButtonWithIcon("", theme.ContentClearIcon(), nil)
+	bodyFileClearBtn.Hide()
+	setBodyFile := func(path string) {
+		bodyFilePath = path
+		if path == "" {
+			bodyFileLabel.SetText("Sin archivo")
+			bodyFileClearBtn.Hide()
+			if bodyDirPath == "" {
+				bodyEntry.Enable()
+			}
+			return
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("no se pudo leer el archivo: %w", err), myWindow)
+			bodyFilePath = ""
+			return
+		}
+		bodyFileLabel.SetText(fmt.Sprintf("%s (%d bytes)", filepath.Base(path), info.Size()))
+		bodyFileClearBtn.Show()
+		bodyEntry.Disable()
+	}
+	bodyFileClearBtn.OnTapped = func() { setBodyFile("") }
+
+	// Carpeta de bodies precalculados: cada request envía el contenido de uno de sus archivos
+	bodyDirLabel := widget.NewLabel("Sin carpeta de bodies")
+	bodyDirClearBtn := widget.NewButtonWithIcon("", theme.ContentClearIcon(), nil)
+	bodyDirClearBtn.Hide()
+	randomizeBodiesCheck := widget.NewCheck("Orden aleatorio", nil)
+	randomizeBodiesCheck.Hide()
+	setBodyDir := func(path string) {
+		bodyDirPath = ""
+		bodyDirLabel.SetText("Sin carpeta de bodies")
+		bodyDirClearBtn.Hide()
+		randomizeBodiesCheck.Hide()
+		if path == "" {
+			if bodyFilePath == "" {
+				bodyEntry.Enable()
+			}
+			return
+		}
+		bodies, err := loadBodyDir(path)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("no se pudo cargar la carpeta de bodies: %w", err), myWindow)
+			return
+		}
+		bodyDirPath = path
+		bodyDirLabel.SetText(fmt.Sprintf("%s (%d archivos)", filepath.Base(path), len(bodies)))
+		bodyDirClearBtn.Show()
+		randomizeBodiesCheck.Show()
+		bodyEntry.Disable()
+	}
+	bodyDirClearBtn.OnTapped = func() { setBodyDir("") }
+	bodyDirBtn := widget.NewButtonWithIcon("Bodies desde carpeta", theme.FolderOpenIcon(), func() {
+		fd := dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+			if err != nil || dir == nil {
+				return
+			}
+			setBodyDir(dir.Path())
+		}, myWindow)
+		fd.Show()
+	})
+	bodyFileBtn := widget.NewButtonWithIcon("Body desde archivo", theme.FileIcon(), func() {
+		fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			setBodyFile(reader.URI().Path())
+		}, myWindow)
+		fd.Show()
+	})
+
+	// Botón para formatear JSON/XML
+	formatBtn := widget.NewButtonWithIcon("Formatear Body", theme.DocumentIcon(), func() {
+		body := strings.TrimSpace(bodyEntry.Text)
+		if body == "" {
+			return
+		}
+
+		if formatted, ok := formatBody(body); ok {
+			bodyEntry.SetText(formatted)
+			return
+		}
+
+		dialog.ShowInformation("Formateo", "No se pudo formatear. Asegúrate de que sea JSON o XML válido.", myWindow)
+	})
+
+	// Log de requests a archivo
+	logFileEntry := widget.NewEntry()
+	logFileEntry.SetPlaceHolder("Ruta del archivo de log (vacío = sin log)")
+	logBodiesCheck := widget.NewCheck("Incluir body de respuesta", nil)
+	logBodyMaxEntry := widget.NewEntry()
+	logBodyMaxEntry.SetText(strconv.Itoa(DefaultLogBodyMaxBytes))
+	logBodyMaxEntry.SetPlaceHolder("Máx. bytes")
+	logBrowseBtn := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		fd := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			writer.Close()
+			logFileEntry.SetText(writer.URI().Path())
+		}, myWindow)
+		fd.SetFileName("benchmark.log")
+		fd.Show()
+	})
+
+	// Endpoints ponderados para tráfico mixto
+	// JSON Schema que deben cumplir las respuestas (vacío = sin validar)
+	responseSchemaEntry := widget.NewMultiLineEntry()
+	responseSchemaEntry.SetPlaceHolder(`{"type": "object", "required": ["id"]}`)
+	responseSchemaEntry.SetMinRowsVisible(3)
+
+	// Script JavaScript que ajusta headers y body antes de cada request (vacío = sin script)
+	preRequestEntry := widget.NewMultiLineEntry()
+	preRequestEntry.SetPlaceHolder(`request.headers["X-Signature"] = hmacSHA256("secreto", request.body + Date.now())`)
+	preRequestEntry.SetMinRowsVisible(3)
+
+	endpointsEntry := widget.NewMultiLineEntry()
+	endpointsEntry.SetPlaceHolder("70 GET https://api.example.com/items\n30 POST https://api.example.com/items {\"name\": \"x\"}")
+	endpointsEntry.SetMinRowsVisible(3)
+
+	// Escenario importado de un HAR: cada usuario reproduce sus requests en orden (reemplaza a los endpoints ponderados)
+	var harScenario []RequestConfig
+	harLabel := widget.NewLabel("Sin HAR")
+	harClearBtn := widget.NewButtonWithIcon("", theme.ContentClearIcon(), nil)
+	harClearBtn.Hide()
+	harStepsBtn := widget.NewButtonWithIcon("Ajustar pasos", theme.SettingsIcon(), nil)
+	harStepsBtn.Hide()
+	setHARFile := func(path string) {
+		harScenario = nil
+		harLabel.SetText("Sin HAR")
+		harClearBtn.Hide()
+		harStepsBtn.Hide()
+		endpointsEntry.Enable()
+		if path == "" {
+			return
+		}
+		data, err := os.ReadFile(path)
+		if err == nil {
+			harScenario, err = parseHAR(data)
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("no se pudo importar el HAR: %w", err), myWindow)
+			return
+		}
+		harLabel.SetText(fmt.Sprintf("%s (%d requests)", filepath.Base(path), len(harScenario)))
+		harClearBtn.Show()
+		harStepsBtn.Show()
+		endpointsEntry.Disable()
+	}
+	harClearBtn.OnTapped = func() { setHARFile("") }
+
+	// Ajustar pasos: espera previa y condición ("paso=status") de cada paso del escenario, para armar flujos reales
+	// (ej. llamar a /checkout solo si /cart respondió 200)
+	harStepsBtn.OnTapped = func() {
+		delayEntries := make([]*widget.Entry, len(harScenario))
+		conditionEntries := make([]*widget.Entry, len(harScenario))
+		grid := container.NewGridWithColumns(3,
+			widget.NewLabelWithStyle("Paso", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle("Espera (ms)", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle("Solo si (paso=status)", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		)
+		for i, step := range harScenario {
+			delayEntries[i] = widget.NewEntry()
+			delayEntries[i].SetPlaceHolder("0")
+			if step.DelayMs > 0 {
+				delayEntries[i].SetText(strconv.Itoa(step.DelayMs))
+			}
+			conditionEntries[i] = widget.NewEntry()
+			if i > 0 {
+				conditionEntries[i].SetPlaceHolder(fmt.Sprintf("ej. %d=200", i))
+			} else {
+				conditionEntries[i].Disable() // El primer paso no tiene pasos anteriores
+			}
+			if c := step.Condition; c != nil {
+				conditionEntries[i].SetText(fmt.Sprintf("%d=%d", c.Step+1, c.Status))
+			}
+			label := widget.NewLabel(fmt.Sprintf("%d. %s %s", i+1, step.Method, step.URL))
+			label.Truncation = fyne.TextTruncateEllipsis
+			grid.Add(label)
+			grid.Add(delayEntries[i])
+			grid.Add(conditionEntries[i])
+		}
+		stepsDialog := dialog.NewCustomConfirm("Ajustar pasos del escenario", "Aplicar", "Cancelar", container.NewVScroll(grid), func(ok bool) {
+			if !ok {
+				return
+			}
+			// Validar todo antes de aplicar para no dejar el escenario a medio editar
+			delays := make([]int, len(harScenario))
+			conditions := make([]*StepCondition, len(harScenario))
+			for i := range harScenario {
+				if text := strings.TrimSpace(delayEntries[i].Text); text != "" {
+					if _, err := fmt.Sscanf(text, "%d", &delays[i]); err != nil || delays[i] < 0 {
+						dialog.ShowError(fmt.Errorf("paso %d: espera inválida %q", i+1, text), myWindow)
+						return
+					}
+				}
+				condition, err := parseStepCondition(conditionEntries[i].Text, i)
+				if err != nil {
+					dialog.ShowError(fmt.Errorf("paso %d: %w", i+1, err), myWindow)
+					return
+				}
+				conditions[i] = condition
+			}
+			for i := range harScenario {
+				harScenario[i].DelayMs = delays[i]
+				harScenario[i].Condition = conditions[i]
+			}
+		}, myWindow)
+		stepsDialog.Resize(fyne.NewSize(800, 450))
+		stepsDialog.Show()
+	}
+	harBtn := widget.NewButtonWithIcon("Importar HAR", theme.FileIcon(), func() {
+		fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			setHARFile(reader.URI().Path())
+		}, myWindow)
+		fd.SetFilter(storage.NewExtensionFileFilter([]string{".har"}))
+		fd.Show()
+	})
+
+	// Archivo de entorno: sus valores reemplazan los tokens ${CLAVE} (ej. un archivo por dev/staging/prod)
+	var envVars map[string]string
+	envLabel := widget.NewLabel("Sin archivo de entorno")
+	envClearBtn := widget.NewButtonWithIcon("", theme.ContentClearIcon(), nil)
+	envClearBtn.Hide()
+	setEnvFile := func(path string) {
+		envVars = nil
+		envLabel.SetText("Sin archivo de entorno")
+		envClearBtn.Hide()
+		if path == "" {
+			return
+		}
+		data, err := os.ReadFile(path)
+		if err == nil {
+			envVars, err = parseEnvFile(data)
+		}
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("no se pudo cargar el archivo de entorno: %w", err), myWindow)
+			return
+		}
+		envLabel.SetText(fmt.Sprintf("%s (%d variables)", filepath.Base(path), len(envVars)))
+		envClearBtn.Show()
+	}
+	envClearBtn.OnTapped = func() { setEnvFile("") }
+	envBtn := widget.NewButtonWithIcon("Cargar entorno", theme.FileIcon(), func() {
+		fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			setEnvFile(reader.URI().Path())
+		}, myWindow)
+		fd.Show()
+	})
+
+	// Método gRPC (se usa con URLs grpc:// o grpcs://)
+	grpcMethodEntry := widget.NewEntry()
+	grpcMethodEntry.SetPlaceHolder("paquete.Servicio/Metodo")
+
+	// Opciones de ejecución
+	cookieJarCheck := widget.NewCheck("Mantener cookies por usuario (sesiones)", nil)
+	tagRequestsCheck := widget.NewCheck("Etiquetar requests (X-Request-Seq / X-Run-Id)", nil)
+	disableRedirectsCheck := widget.NewCheck("No seguir redirects", nil)
+	timeoutEntry := widget.NewEntry()
+	bindEntryPreference(timeoutEntry, myApp.Preferences(), settingsTimeoutKey, strconv.Itoa(int(DefaultRequestTimeout/time.Second)))
+	timeoutEntry.SetPlaceHolder("Segundos")
+	connectTimeoutEntry := widget.NewEntry()
+	bindEntryPreference(connectTimeoutEntry, myApp.Preferences(), settingsConnTimeoutKey, "")
+	connectTimeoutEntry.SetPlaceHolder("ms (vacío = por defecto)")
+	deadlineEntry := widget.NewEntry()
+	deadlineEntry.SetPlaceHolder("ms (vacío = no)")
+	maxRunDurationEntry := widget.NewEntry()
+	maxRunDurationEntry.SetPlaceHolder("s (vacío = no)")
+
+	userAgentEntry := widget.NewEntry()
+	bindEntryPreference(userAgentEntry, myApp.Preferences(), settingsUserAgentKey, DefaultUserAgent)
+	userAgentEntry.SetPlaceHolder(DefaultUserAgent)
+
+	// Tamaño máximo del body capturado en request única
+	maxBodyEntry := widget.NewEntry()
+	bindEntryPreference(maxBodyEntry, myApp.Preferences(), settingsMaxBodyKBKey, strconv.Itoa(DefaultMaxBodyCaptureBytes/1024))
+	maxBodyEntry.SetPlaceHolder("KB")
+
+	// Destino de la exportación a InfluxDB
+	influxURLEntry := widget.NewEntry()
+	bindEntryPreference(influxURLEntry, myApp.Preferences(), settingsInfluxURLKey, "")
+	influxURLEntry.SetPlaceHolder("http://localhost:8086/api/v2/write?org=mi-org&bucket=bench&precision=ns")
+	influxTokenEntry := widget.NewPasswordEntry()
+	bindEntryPreference(influxTokenEntry, myApp.Preferences(), settingsInfluxTokenKey, "")
+	influxTokenEntry.SetPlaceHolder("Token (opcional)")
+
+	// Destino del push de métricas a Prometheus
+	pushgatewayEntry := widget.NewEntry()
+	bindEntryPreference(pushgatewayEntry, myApp.Preferences(), settingsPushgatewayKey, "")
+	pushgatewayEntry.SetPlaceHolder("http://localhost:9091")
+	promJobEntry := widget.NewEntry()
+	bindEntryPreference(promJobEntry, myApp.Preferences(), settingsPromJobKey, DefaultPrometheusJob)
+	promJobEntry.SetPlaceHolder(DefaultPrometheusJob)
+
+	forceHTTP1Check := widget.NewCheck("Forzar HTTP/1.1 (no negociar HTTP/2)", nil)
+
+	// Socket Unix: para daemons locales sin listener TCP (la URL aporta el path y el Host)
+	unixSocketEntry := widget.NewEntry()
+	unixSocketEntry.SetPlaceHolder("/var/run/servicio.sock (vacío = TCP)")
+
+	// Sin gráfico en vivo: durante la ejecución solo se actualiza un contador y el gráfico se dibuja al final,
+	// para que los repintados no le quiten CPU al generador de carga
+	noLiveChartCheck := widget.NewCheck("Sin gráfico durante la ejecución (máximo throughput)", nil)
+	liveCounter := widget.NewLabelWithStyle("", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	liveCounterView := container.NewCenter(liveCounter)
+
+	abortOnFirstErrorCheck := widget.NewCheck("Detener en el primer error y mostrar su respuesta (debug)", nil)
+	separateRateLimitedCheck := widget.NewCheck("Contar los 429 aparte (rate limited, fuera del error rate)", nil)
+	honorRetryAfterCheck := widget.NewCheck(fmt.Sprintf("Respetar Retry-After de 429/503 (hasta %s)", MaxRetryAfter), nil)
+
+	// Límite de throughput (TargetRPS) compartido por todos los usuarios
+	targetRPSEntry := widget.NewEntry()
+	targetRPSEntry.SetPlaceHolder("req/s (vacío = sin límite)")
+
+	// Barrido de throughput: sube el límite de requests/s por escalones hasta que deja de ser sostenible
+	maxRPSStartEntry := widget.NewEntry()
+	maxRPSStartEntry.SetPlaceHolder("Desde (req/s)")
+	maxRPSStepEntry := widget.NewEntry()
+	maxRPSStepEntry.SetPlaceHolder("Escalón (req/s)")
+	maxRPSFields := container.NewGridWithColumns(2, maxRPSStartEntry, maxRPSStepEntry)
+	maxRPSFields.Hide()
+	maxRPSCheck := widget.NewCheck("Buscar el RPS máximo sostenible", func(on bool) {
+		if on {
+			maxRPSFields.Show()
+		} else {
+			maxRPSFields.Hide()
+		}
+	})
+
+	// Barrido de concurrencia: repite el test con cada nivel de usuarios (ignora "Usuarios") y grafica el P95
+	sweepLevelsEntry := widget.NewEntry()
+	defaultLevels := make([]string, len(DefaultSweepLevels))
+	for i, users := range DefaultSweepLevels {
+		defaultLevels[i] = strconv.Itoa(users)
+	}
+	sweepLevelsEntry.SetPlaceHolder(strings.Join(defaultLevels, ", "))
+	sweepLevelsEntry.Hide()
+	sweepCheck := widget.NewCheck("Barrido de usuarios (curva de saturación)", func(on bool) {
+		if on {
+			sweepLevelsEntry.Show()
+		} else {
+			sweepLevelsEntry.Hide()
+		}
+	})
+
+	// Umbral de la sugerencia de pantalla completa (persistido en preferencias)
+	fullScreenSuggestEntry := widget.NewEntry()
+	fullScreenSuggestEntry.SetText(strconv.Itoa(myApp.Preferences().IntWithFallback(fullScreenSuggestKey, DefaultFullScreenSuggestThreshold)))
+	fullScreenSuggestEntry.SetPlaceHolder("0 = nunca")
+	fullScreenSuggestEntry.OnChanged = func(text string) {
+		threshold := 0
+		if _, err := fmt.Sscanf(text, "%d", &threshold); err == nil && threshold >= 0 {
+			myApp.Preferences().SetInt(fullScreenSuggestKey, threshold)
+		}
+	}
+
+	// Rango de status considerado exitoso
+	successMinEntry := widget.NewEntry()
+	successMinEntry.SetText(strconv.Itoa(DefaultSuccessStatusMin))
+	successMaxEntry := widget.NewEntry()
+	successMaxEntry.SetText(strconv.Itoa(DefaultSuccessStatusMax))
+
+	// Circuit breaker por error rate
+	stopErrorRateEntry := widget.NewEntry()
+	stopErrorRateEntry.SetPlaceHolder("% (vacío = no)")
+	errorWindowEntry := widget.NewEntry()
+	errorWindowEntry.SetText(strconv.Itoa(DefaultErrorRateWindow))
+	errorWindowEntry.SetPlaceHolder("Requests")
+
+	// Percentiles a mostrar en las estadísticas
+	percentilesEntry := widget.NewEntry()
+	bindEntryPreference(percentilesEntry, myApp.Preferences(), settingsPercentilesKey, "90, 95, 99")
+	percentilesEntry.SetPlaceHolder("Ej: 50, 90, 99.9")
+
+	maxResultsEntry := widget.NewEntry()
+	bindEntryPreference(maxResultsEntry, myApp.Preferences(), settingsMaxResultsKey, strconv.Itoa(DefaultMaxRetainedResults))
+	maxResultsEntry.SetPlaceHolder("0 = sin límite")
+
+	// Total de requests a partir del cual se pide confirmación (protege de cantidades tipeadas de más)
+	safeTotalEntry := widget.NewEntry()
+	bindEntryPreference(safeTotalEntry, myApp.Preferences(), settingsSafeTotalKey, strconv.Itoa(DefaultSafeTotalRequests))
+	safeTotalEntry.SetPlaceHolder("0 = nunca")
+
+	// Selector de modo de test
+	testModeSelect := widget.NewSelect([]string{"Por Cantidad", "Por Tiempo"}, nil)
+	testModeSelect.SetSelected("Por Cantidad")
+
+	countEntry := widget.NewEntry()
+	countEntry.SetText("10")
+	countEntry.SetPlaceHolder("Ej: 10, 50, 100")
+
+	durationEntry := widget.NewEntry()
+	durationEntry.SetText("1")
+	durationEntry.SetPlaceHolder("Ej: 1, 5, 10")
+	durationEntry.Hide()
+
+	// Selector de unidad de tiempo
+	timeUnitSelect := widget.NewSelect([]string{"Segundos", "Minutos", "Horas"}, nil)
+	timeUnitSelect.SetSelected("Minutos")
+	timeUnitSelect.Hide()
+
+	// Segundos iniciales excluidos de las estadísticas (solo en modo por tiempo)
+	warmupEntry := widget.NewEntry()
+	warmupEntry.SetPlaceHolder("Warmup s")
+	warmupEntry.Hide()
+
+	usersEntry := widget.NewEntry()
+	usersEntry.SetText("1")
+	usersEntry.SetPlaceHolder("Usuarios concurrentes")
+
+	slaEntry := widget.NewEntry()
+	slaEntry.SetPlaceHolder("SLA ms")
+
+	slaP95Entry := widget.NewEntry()
+	slaP95Entry.SetPlaceHolder("SLA P95 ms")
+
+	// Selector de cantidad total o por usuario
+	countModeSelect := widget.NewSelect([]string{"Total", "Por usuario"}, nil)
+	countModeSelect.SetSelected("Total")
+
+	// Contenedor dinámico para cantidad/duración con unidad de tiempo
+	countWithMode := container.NewHBox(countEntry, countModeSelect)
+	durationWithUnit := container.NewHBox(durationEntry, timeUnitSelect, warmupEntry)
+	valueContainer := container.NewStack(countWithMode, durationWithUnit)
+
+	// Cambiar UI según el modo seleccionado
+	testModeSelect.OnChanged = func(mode string) {
+		if mode == "Por Tiempo" {
+			countEntry.Hide()
+			countModeSelect.Hide()
+			durationEntry.Show()
+			timeUnitSelect.Show()
+			warmupEntry.Show()
+			valueContainer.Refresh()
+		} else {
+			durationEntry.Hide()
+			timeUnitSelect.Hide()
+			warmupEntry.Hide()
+			countEntry.Show()
+			countModeSelect.Show()
+			valueContainer.Refresh()
+		}
+	}
+
+	// Consola desplegable para mostrar detalles de la request
+	consoleEntry := widget.NewMultiLineEntry()
+	consoleEntry.Wrapping = fyne.TextWrapWord
+	consoleEntry.SetMinRowsVisible(10)
+
+	// Estilo personalizado para que se vea como consola pero sea seleccionable
+	consoleBg := canvas.NewRectangle(color.NRGBA{R: 20, G: 20, B: 25, A: 255})
+
+	consoleDisplay := container.NewStack(consoleBg, container.NewPadded(consoleEntry))
+
+	consoleVisible := false
+	consoleContainer := container.NewVBox()
+	consoleContainer.Hide()
+
+	consoleToggleBtn := widget.NewButtonWithIcon("Mostrar Consola Request", theme.InfoIcon(), nil)
+	consoleToggleBtn.OnTapped = func() {
+		if consoleVisible {
+			consoleContainer.Hide()
+			consoleToggleBtn.SetText("Mostrar Consola Request")
+			consoleToggleBtn.SetIcon(theme.InfoIcon())
+			consoleVisible = false
+		} else {
+			consoleContainer.Show()
+			consoleToggleBtn.SetText("Ocultar Consola Request")
+			consoleToggleBtn.SetIcon(theme.ContentClearIcon())
+			consoleVisible = true
+		}
+	}
+
+	updateConsole := func(details RequestDetails) {
+		consoleText := fmt.Sprintf(`=== REQUEST ENVIADA ===
+
+Método: %s
+URL: %s
+Timestamp: %s
+
+--- HEADERS ---
+%s
+
+--- BODY ---
+%s
+
+--- AUTH ---
+%s`,
+			details.Method, details.URL, details.Timestamp, details.Headers, details.Body, details.Auth)
+		consoleEntry.SetText(consoleText)
+	}
+
+	consoleScrollContainer := container.NewVScroll(consoleDisplay)
+	consoleScrollContainer.SetMinSize(fyne.NewSize(0, 250))
+	// Copiar o guardar lo enviado (headers resueltos y firma HMAC incluidos), útil para reportar bugs
+	consoleCopyBtn := widget.NewButtonWithIcon("Copiar", theme.ContentCopyIcon(), func() {
+		myApp.Clipboard().SetContent(consoleEntry.Text)
+	})
+	consoleSaveBtn := widget.NewButtonWithIcon("Guardar", theme.DocumentSaveIcon(), func() {
+		fd := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			defer writer.Close()
+			if _, err := writer.Write([]byte(consoleEntry.Text)); err != nil {
+				dialog.ShowError(fmt.Errorf("Error al guardar la consola: %w", err), myWindow)
+			}
+		}, myWindow)
+		fd.SetFileName("request.txt")
+		fd.Show()
+	})
+
+	consoleContainer.Objects = []fyne.CanvasObject{
+		widget.NewSeparator(),
+		container.NewBorder(nil, nil,
+			newBoldLabel("Detalles de la Request Enviada", fyne.TextAlignLeading),
+			container.NewHBox(consoleCopyBtn, consoleSaveBtn),
+		),
+		consoleScrollContainer,
+	}
+
+	// --- BINDING PARA ESTADÍSTICAS ---
+	avgBind := binding.NewString()
+	minBind := binding.NewString()
+	maxBind := binding.NewString()
+	successBind := binding.NewString()
 
-	// --- ELEMENTOS DE UI ---
-	urlEntry := widget.NewEntry()
-	urlEntry.SetText("https://google.com")
-	urlEntry.SetPlaceHolder("https://api...")
+	avgBind.Set("Promedio: -")
+	minBind.Set("Mínimo: -")
+	maxBind.Set("Máximo: -")
+	successBind.Set("Éxito: -")
 
-	userEntry := widget.NewEntry()
-	userEntry.SetPlaceHolder("User ID")
-	secretEntry := widget.NewPasswordEntry()
-	secretEntry.SetPlaceHolder("HMAC Secret")
+	// Variables para el Árbol de Postman
+	treeData := make(map[string]PostmanItem)
+	treeRoots := []string{}
+	var collectionItems []PostmanItem // Colección completa (el árbol puede mostrar una vista filtrada)
+	var loadedCollection PostmanCollection
+	selectedTreeID := ""
 
-	methodSelect := widget.NewSelect([]string{"GET", "POST", "PUT", "DELETE"}, nil)
-	methodSelect.Selected = "GET"
+	var processItems func([]PostmanItem, string)
+	processItems = func(items []PostmanItem, parentID string) {
+		for i, item := range items {
+			id := item.Name + strconv.Itoa(i)
+			if parentID != "" {
+				id = parentID + "/" + id
+			}
 
-	headersEntry := widget.NewMultiLineEntry()
-	headersEntry.SetPlaceHolder("Content-Type: application/json\nAuthorization: Bearer token")
-	headersEntry.SetMinRowsVisible(4)
+			treeData[id] = item
+			if parentID == "" {
+				treeRoots = append(treeRoots, id)
+			}
+			if len(item.Items) > 0 {
+				processItems(item.Items, id)
+			}
+		}
+	}
 
-	bodyEntry := widget.NewMultiLineEntry()
-	bodyEntry.SetPlaceHolder(`{\n  "key": "value",\n  "nested": {\n    "data": "example"\n  }\n}`)
-	bodyEntry.SetMinRowsVisible(15) // Más grande para mejor visualización
-	bodyEntry.Wrapping = fyne.TextWrapWord
+	postmanTree := NewPostmanTree(
+		func(id widget.TreeNodeID) []widget.TreeNodeID {
+			if id == "" {
+				return treeRoots
+			}
+			item := treeData[id]
+			children := []string{}
+			for i, child := range item.Items {
+				childID := id + "/" + child.Name + strconv.Itoa(i)
+				treeData[childID] = child
+				children = append(children, childID)
+			}
+			return children
+		},
+		func(id widget.TreeNodeID) bool {
+			return len(treeData[id].Items) > 0
+		},
+		func(branch bool) fyne.CanvasObject {
+			icon := theme.FileTextIcon()
+			if branch {
+				icon = theme.FolderIcon()
+			}
+			return container.NewHBox(widget.NewIcon(icon), widget.NewLabel("Template"))
+		},
+		func(id widget.TreeNodeID, branch bool, o fyne.CanvasObject) {
+			lbl := o.(*fyne.Container).Objects[1].(*widget.Label)
+			lbl.SetText(treeData[id].Name)
+		},
+	)
 
-	// Botón para formatear JSON/XML
-	formatBtn := widget.NewButtonWithIcon("Formatear Body", theme.DocumentIcon(), func() {
-		body := strings.TrimSpace(bodyEntry.Text)
-		if body == "" {
-			return
+	postmanTree.OnSelected = func(id widget.TreeNodeID) {
+		selectedTreeID = id
+		item := treeData[id]
+		if item.Request != nil {
+			urlEntry.SetText(item.Request.Url.Raw)
+			methodPicker.SetMethod(item.Request.Method)
+
+			var rows []HeaderRow
+			for _, h := range item.Request.Header {
+				rows = append(rows, HeaderRow{Key: h.Key, Value: h.Value, Enabled: !h.Disabled})
+			}
+			rows, contentType := splitContentType(rows)
+			headerTable.SetRows(rows)
+			if contentType != "" {
+				contentTypeSelect.SetText(contentType)
+			} else {
+				contentTypeSelect.SetText(ContentTypeAuto)
+			}
+			bodyEntry.SetText(item.Request.Body.Raw)
 		}
+	}
 
-		// Intentar formatear como JSON
-		var jsonData interface{}
-		if err := json.Unmarshal([]byte(body), &jsonData); err == nil {
-			formatted, err := json.MarshalIndent(jsonData, "", "  ")
-			if err == nil {
-				bodyEntry.SetText(string(formatted))
+	// showTree reconstruye el árbol con los items que coinciden con el filtro ("" = colección completa)
+	showTree := func(query string) {
+		treeData = make(map[string]PostmanItem)
+		treeRoots = []string{}
+		processItems(filterPostmanItems(collectionItems, query), "")
+		postmanTree.UnselectAll()
+		selectedTreeID = ""
+		if strings.TrimSpace(query) != "" {
+			// Mostrar desplegadas las carpetas que contienen coincidencias
+			postmanTree.OpenAllBranches()
+		} else {
+			postmanTree.CloseAllBranches()
+		}
+		postmanTree.Refresh()
+	}
+
+	treeFilterEntry := widget.NewEntry()
+	treeFilterEntry.SetPlaceHolder("🔍 Filtrar requests...")
+	treeFilterEntry.OnChanged = showTree
+
+	importBtn := widget.NewButtonWithIcon("Cargar JSON Postman", theme.FolderOpenIcon(), func() {
+		fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			defer reader.Close()
+
+			byteValue, _ := io.ReadAll(reader)
+			var collection PostmanCollection
+			err = json.Unmarshal(byteValue, &collection)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("Error al parsear Postman JSON: %w", err), myWindow)
+				return
+			}
+
+			loadedCollection = collection
+			collectionItems = collection.Items
+			treeFilterEntry.SetText("")
+			showTree("")
+
+		}, myWindow)
+		fd.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+		fd.Show()
+	})
+
+	// Exportar la colección (con las ediciones del formulario aplicadas a la request seleccionada)
+	exportBtn := widget.NewButtonWithIcon("Exportar Postman", theme.DocumentSaveIcon(), func() {
+		collection := loadedCollection
+		collection.Items = collectionItems
+		headers := withContentType(headerTable.Rows(), resolveContentType(contentTypeSelect.Text, bodyEntry.Text, bodyFilePath))
+		if len(collection.Items) == 0 {
+			// Sin colección cargada: exportar la request actual como colección de un solo item
+			collection.Info.Name = "BenchmarkPro"
+			req := &PostmanRequest{}
+			applyToPostmanRequest(req, methodPicker.Method(), urlEntry.Text, headers, bodyEntry.Text)
+			collection.Items = []PostmanItem{{Name: urlEntry.Text, Request: req}}
+		} else if item, ok := treeData[selectedTreeID]; ok && item.Request != nil {
+			// Request compartida por puntero con la colección completa: editarla la actualiza también ahí
+			applyToPostmanRequest(item.Request, methodPicker.Method(), urlEntry.Text, headers, bodyEntry.Text)
+		}
+		if collection.Info.Schema == "" {
+			collection.Info.Schema = PostmanSchemaV21
+		}
+
+		save := func(collection PostmanCollection) {
+			data, err := json.MarshalIndent(collection, "", "  ")
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("Error al generar Postman JSON: %w", err), myWindow)
+				return
+			}
+			fd := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+				if err != nil || writer == nil {
+					return
+				}
+				defer writer.Close()
+				if _, err := writer.Write(data); err != nil {
+					dialog.ShowError(fmt.Errorf("Error al guardar la colección: %w", err), myWindow)
+				}
+			}, myWindow)
+			fd.SetFileName(collection.Info.Name + ".postman_collection.json")
+			fd.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+			fd.Show()
+		}
+
+		// Con credenciales en la colección se ocultan por defecto; incluirlas requiere marcarlo
+		redacted, hasSecrets := redactPostmanCollection(collection)
+		if !hasSecrets {
+			save(collection)
+			return
+		}
+		includeSecretsCheck := widget.NewCheck("Incluir los secretos sin ocultar", nil)
+		content := container.NewVBox(
+			widget.NewLabel(fmt.Sprintf("La colección contiene secretos (tokens, API keys, passwords).\nSe exportarán como %q salvo que marques la opción.", RedactedValue)),
+			includeSecretsCheck,
+		)
+		dialog.ShowCustomConfirm("Exportar Postman", "Exportar", "Cancelar", content, func(ok bool) {
+			if !ok {
 				return
 			}
+			if !includeSecretsCheck.Checked {
+				collection = redacted
+			}
+			save(collection)
+		}, myWindow)
+	})
+
+	// Guardar el formulario como request nueva dentro de la colección; la request seleccionada no se toca
+	saveAsNewBtn := widget.NewButtonWithIcon("Guardar como nueva", theme.ContentAddIcon(), func() {
+		nameEntry := widget.NewEntry()
+		if item, ok := treeData[selectedTreeID]; ok && item.Request != nil {
+			nameEntry.SetText(item.Name + " (copia)")
+		} else {
+			nameEntry.SetText(urlEntry.Text)
+		}
+		folders := postmanFolders(collectionItems)
+		var labels []string
+		for _, f := range folders {
+			labels = append(labels, f.Label)
+		}
+		folderSelect := widget.NewSelect(labels, nil)
+		folderSelect.SetSelectedIndex(0)
+
+		formDialog := dialog.NewForm("Guardar como nueva request", "Guardar", "Cancelar",
+			[]*widget.FormItem{
+				widget.NewFormItem("Nombre:", nameEntry),
+				widget.NewFormItem("Carpeta:", folderSelect),
+			}, func(ok bool) {
+				if !ok {
+					return
+				}
+				name := strings.TrimSpace(nameEntry.Text)
+				if name == "" {
+					dialog.ShowError(fmt.Errorf("El nombre de la request no puede estar vacío"), myWindow)
+					return
+				}
+				folder := folders[folderSelect.SelectedIndex()]
+
+				req := &PostmanRequest{}
+				headers := withContentType(headerTable.Rows(), resolveContentType(contentTypeSelect.Text, bodyEntry.Text, bodyFilePath))
+				applyToPostmanRequest(req, methodPicker.Method(), urlEntry.Text, headers, bodyEntry.Text)
+				collectionItems = insertPostmanItem(collectionItems, folder.Path, PostmanItem{Name: name, Request: req})
+				if loadedCollection.Info.Name == "" {
+					loadedCollection.Info.Name = "BenchmarkPro"
+				}
+
+				// Mostrar la colección completa con la nueva request desplegada y seleccionada
+				treeFilterEntry.SetText("")
+				showTree("")
+				siblings := collectionItems
+				for _, i := range folder.Path {
+					siblings = siblings[i].Items
+				}
+				ids := postmanTreeIDs(collectionItems, append(append([]int(nil), folder.Path...), len(siblings)-1))
+				for _, id := range ids[:len(ids)-1] {
+					postmanTree.OpenBranch(id)
+				}
+				postmanTree.Select(ids[len(ids)-1])
+			}, myWindow)
+		formDialog.Resize(fyne.NewSize(500, 200))
+		formDialog.Show()
+	})
+
+	// Botón para importar desde cURL
+	curlBtn := widget.NewButtonWithIcon("Pegar cURL", theme.ContentPasteIcon(), func() {
+		curlEntry := widget.NewMultiLineEntry()
+		curlEntry.SetPlaceHolder("Pega aquí tu comando cURL completo...")
+		curlEntry.SetMinRowsVisible(10)
+
+		// Crear diálogo personalizado con ventana más ancha
+		formDialog := dialog.NewForm("Importar desde cURL", "Importar", "Cancelar",
+			[]*widget.FormItem{
+				widget.NewFormItem("Comando cURL:", curlEntry),
+			}, func(ok bool) {
+				if !ok || curlEntry.Text == "" {
+					return
+				}
+				parseCurlCommand(curlEntry.Text, urlEntry, methodPicker, headerTable, bodyEntry)
+
+				// El Content-Type del comando pasa al selector en lugar de quedar como header libre
+				rows, contentType := splitContentType(headerTable.Rows())
+				if contentType != "" {
+					headerTable.SetRows(rows)
+					contentTypeSelect.SetText(contentType)
+				}
+			}, myWindow)
+
+		formDialog.Resize(fyne.NewSize(800, 400))
+		formDialog.Show()
+	})
+
+	// --- AREA GRAFICA Y EJECUCION ---
+
+	chartWidget := NewChartWidget()
+
+	// Decimales de las latencias mostradas (la medición tiene resolución de microsegundos)
+	latencyDecimals := min(max(myApp.Preferences().IntWithFallback(settingsDecimalsKey, DefaultLatencyDecimals), 0), MaxLatencyDecimals)
+	chartWidget.SetLatencyDecimals(latencyDecimals)
+	decimalsEntry := widget.NewEntry()
+	decimalsEntry.SetText(strconv.Itoa(latencyDecimals))
+	decimalsEntry.SetPlaceHolder(fmt.Sprintf("0 a %d", MaxLatencyDecimals))
+	decimalsEntry.OnChanged = func(text string) {
+		decimals := 0
+		if _, err := fmt.Sscanf(text, "%d", &decimals); err == nil && decimals >= 0 && decimals <= MaxLatencyDecimals {
+			myApp.Preferences().SetInt(settingsDecimalsKey, decimals)
+			latencyDecimals = decimals
+			chartWidget.SetLatencyDecimals(decimals)
+		}
+	}
+
+	// Throughput mostrado: total (sobre todo el tiempo medido) o en régimen estable
+	preferSteadyRPS := myApp.Preferences().Bool(settingsSteadyRPSKey)
+	steadyRPSCheck := widget.NewCheck("Mostrar Req/s en régimen estable (sin arranque ni vaciado final)", func(enabled bool) {
+		myApp.Preferences().SetBool(settingsSteadyRPSKey, enabled)
+		preferSteadyRPS = enabled
+	})
+	steadyRPSCheck.SetChecked(preferSteadyRPS)
+
+	progressBar := widget.NewProgressBar()
+	progressBar.Hide()
+
+	// Requests en curso sobre la concurrencia configurada (solo durante benchmarks)
+	inFlightGauge := NewInFlightGauge()
+	inFlightView := container.NewBorder(nil, nil, widget.NewLabel("Concurrencia:"), nil, inFlightGauge)
+	inFlightView.Hide()
+
+	// Área para mostrar respuesta única
+	responseViewer := widget.NewMultiLineEntry()
+	responseViewer.SetPlaceHolder("Respuesta del servidor aparecerá aquí...")
+	responseViewer.Wrapping = fyne.TextWrapWord
+
+	// Estado de la última respuesta para poder alternar entre raw y formateado
+	var lastResponseHeader, lastResponseBody, lastResponseContentType string
+	prettyCheck := widget.NewCheck("Pretty", nil)
+	prettyCheck.SetChecked(true)
+	renderResponse := func() {
+		body := lastResponseBody
+		if prettyCheck.Checked && isFormattableContentType(lastResponseContentType, body) {
+			if formatted, ok := formatBody(body); ok {
+				body = formatted
+			}
+		}
+		responseViewer.SetText(lastResponseHeader + truncateForDisplay(body))
+	}
+	prettyCheck.OnChanged = func(bool) { renderResponse() }
+
+	// Abrir en navegador: las respuestas HTML o XML se ven renderizadas en lugar de como texto
+	openBrowserBtn := widget.NewButtonWithIcon("Abrir en navegador", theme.ComputerIcon(), func() {
+		ext, ok := browserExtension(lastResponseContentType)
+		if !ok {
+			return
+		}
+		fileURL, err := writeBrowserFile(lastResponseBody, ext)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("no se pudo guardar la respuesta: %w", err), myWindow)
+			return
+		}
+		if err := fyne.CurrentApp().OpenURL(fileURL); err != nil {
+			dialog.ShowError(fmt.Errorf("no se pudo abrir el navegador: %w", err), myWindow)
+		}
+	})
+	openBrowserBtn.Hide()
+	timingBreakdown := container.NewVBox()
+	responsePanel := container.NewBorder(
+		container.NewVBox(
+			container.NewHBox(widget.NewLabelWithStyle("Respuesta", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}), prettyCheck, openBrowserBtn),
+			timingBreakdown,
+		),
+		nil, nil, nil,
+		container.NewScroll(responseViewer),
+	)
+
+	// Variables para control de vistas
+	var isFullScreen bool
+	var originalContent fyne.CanvasObject
+
+	// Botones de control de vista
+	normalViewBtn := widget.NewButtonWithIcon("Vista Normal", theme.HomeIcon(), func() {
+		chartWidget.SetViewMode(ViewModeNormal)
+		if isFullScreen {
+			myWindow.SetContent(originalContent)
+			isFullScreen = false
+			myWindow.Resize(fyne.NewSize(1000, 700))
+		}
+	})
+
+	realTimeViewBtn := widget.NewButtonWithIcon("Tiempo Real", theme.MediaPlayIcon(), func() {
+		chartWidget.SetViewMode(ViewModeRealTime)
+	})
+
+	fullScreenBtn := widget.NewButtonWithIcon("Pantalla Completa", theme.ViewFullScreenIcon(), func() {
+		chartWidget.SetViewMode(ViewModeFullScreen)
+		if !isFullScreen {
+			originalContent = myWindow.Content()
+			isFullScreen = true
+
+			// Crear contenido de pantalla completa
+			fullScreenChart := container.NewStack(
+				canvas.NewRectangle(color.NRGBA{R: 25, G: 25, B: 25, A: 255}),
+				chartWidget,
+			)
+
+			exitBtn := widget.NewButtonWithIcon("Salir Pantalla Completa", theme.CancelIcon(), func() {
+				myWindow.SetContent(originalContent)
+				isFullScreen = false
+				chartWidget.SetViewMode(ViewModeNormal)
+				myWindow.Resize(fyne.NewSize(1000, 700))
+			})
+
+			fullScreenContent := container.NewBorder(
+				container.NewHBox(exitBtn, widget.NewSeparator()),
+				nil, nil, nil,
+				fullScreenChart,
+			)
+
+			myWindow.SetContent(fullScreenContent)
+			myWindow.Resize(fyne.NewSize(1400, 900))
 		}
+	})
 
-		// Si no es JSON, intentar como XML (básico)
-		if strings.HasPrefix(body, "<") {
-			// Formateo básico de XML con indentación
-			formatted := strings.ReplaceAll(body, "><", ">\n<")
-			bodyEntry.SetText(formatted)
-			return
+	clearResultsBtn := widget.NewButtonWithIcon("Limpiar", theme.DeleteIcon(), nil)
+	outliersBtn := widget.NewButtonWithIcon("Top Outliers", theme.ListIcon(), nil)
+	perSecondBtn := widget.NewButtonWithIcon("Por segundo", theme.GridIcon(), nil)
+	influxExportBtn := widget.NewButtonWithIcon("Exportar Influx", theme.UploadIcon(), nil)
+	pushMetricsBtn := widget.NewButtonWithIcon("Push Metrics", theme.UploadIcon(), nil)
+	importCSVBtn := widget.NewButtonWithIcon("Importar CSV", theme.FolderOpenIcon(), nil)
+	scenarioStepsBtn := widget.NewButtonWithIcon("Pasos", theme.ListIcon(), nil)
+
+	// Error rate acumulado o en ventana móvil de las últimas requests
+	errorRateModeSelect := widget.NewSelect([]string{"Error rate acumulado", fmt.Sprintf("Error rate últimas %d", DefaultChartErrorWindow)}, func(selected string) {
+		if selected == "Error rate acumulado" {
+			chartWidget.SetErrorRateWindow(0)
+		} else {
+			chartWidget.SetErrorRateWindow(DefaultChartErrorWindow)
 		}
+	})
+	errorRateModeSelect.SetSelected("Error rate acumulado")
 
-		dialog.ShowInformation("Formateo", "No se pudo formatear. Asegúrate de que sea JSON o XML válido.", myWindow)
+	gradientCheck := widget.NewCheck("Gradiente latencia", func(enabled bool) {
+		chartWidget.SetLatencyGradient(enabled)
 	})
 
-	// Selector de modo de test
-	testModeSelect := widget.NewSelect([]string{"Por Cantidad", "Por Tiempo"}, nil)
-	testModeSelect.SetSelected("Por Cantidad")
+	latencyMetricSelect := widget.NewSelect([]string{"Latencia: headers", "Latencia: último byte"}, func(selected string) {
+		if selected == "Latencia: último byte" {
+			chartWidget.SetLatencyMetric(LatencyMetricTTLB)
+		} else {
+			chartWidget.SetLatencyMetric(LatencyMetricHeaders)
+		}
+	})
+	latencyMetricSelect.SetSelected("Latencia: headers")
 
-	countEntry := widget.NewEntry()
-	countEntry.SetText("10")
-	countEntry.SetPlaceHolder("Ej: 10, 50, 100")
+	xAxisModes := map[string]XAxisMode{
+		"Eje X: automático":   XAxisAuto,
+		"Eje X: secuencia":    XAxisSequence,
+		"Eje X: hora":         XAxisWallClock,
+		"Eje X: transcurrido": XAxisElapsed,
+	}
+	xAxisSelect := widget.NewSelect([]string{"Eje X: automático", "Eje X: secuencia", "Eje X: hora", "Eje X: transcurrido"}, func(selected string) {
+		chartWidget.SetXAxisMode(xAxisModes[selected])
+	})
+	xAxisSelect.SetSelected("Eje X: automático")
 
-	durationEntry := widget.NewEntry()
-	durationEntry.SetText("1")
-	durationEntry.SetPlaceHolder("Ej: 1, 5, 10")
-	durationEntry.Hide()
+	peakSamplingCheck := widget.NewCheck("Conservar picos", func(enabled bool) {
+		chartWidget.SetPeakSampling(enabled)
+	})
 
-	// Selector de unidad de tiempo
-	timeUnitSelect := widget.NewSelect([]string{"Segundos", "Minutos", "Horas"}, nil)
-	timeUnitSelect.SetSelected("Minutos")
-	timeUnitSelect.Hide()
+	rollingP95Check := widget.NewCheck("P95 móvil", func(enabled bool) {
+		chartWidget.SetRollingP95(enabled)
+	})
 
-	usersEntry := widget.NewEntry()
-	usersEntry.SetText("1")
-	usersEntry.SetPlaceHolder("Usuarios concurrentes")
+	// Sparkline con las últimas latencias (visible también en la vista de respuesta)
+	sparkline := NewSparklineWidget()
 
-	// Contenedor dinámico para cantidad/duración con unidad de tiempo
-	durationWithUnit := container.NewHBox(durationEntry, timeUnitSelect)
-	valueContainer := container.NewStack(countEntry, durationWithUnit)
+	// Tema del gráfico (persistido en preferencias)
+	chartWidget.SetTheme(loadChartTheme(myApp.Preferences()))
+	sparkline.SetColor(chartWidget.Theme().ResponseTime)
+	applyChartTheme := func(t ChartTheme) {
+		chartWidget.SetTheme(t)
+		sparkline.SetColor(t.ResponseTime)
+		saveChartTheme(myApp.Preferences(), t)
+	}
+	themeNames := []string{}
+	for _, t := range chartThemePresets {
+		themeNames = append(themeNames, t.Name)
+	}
+	chartThemeSelect := widget.NewSelect(themeNames, nil)
+	chartThemeSelect.SetSelected(chartWidget.Theme().Name)
+	chartThemeSelect.OnChanged = func(name string) {
+		// Al cambiar de preset se restauran también sus colores de series
+		applyChartTheme(chartThemeByName(name))
+	}
 
-	// Cambiar UI según el modo seleccionado
-	testModeSelect.OnChanged = func(mode string) {
-		if mode == "Por Tiempo" {
-			countEntry.Hide()
-			durationEntry.Show()
-			timeUnitSelect.Show()
-			valueContainer.Refresh()
-		} else {
-			durationEntry.Hide()
-			timeUnitSelect.Hide()
-			countEntry.Show()
-			valueContainer.Refresh()
+	// Tooltips: radio de detección configurable y opción de apagarlos, guardados en preferencias
+	chartWidget.SetHoverRadius(float32(myApp.Preferences().IntWithFallback(chartHoverRadiusKey, DefaultHoverRadius)))
+	chartWidget.SetTooltipsEnabled(myApp.Preferences().BoolWithFallback(chartTooltipsKey, true))
+	hoverRadiusEntry := widget.NewEntry()
+	hoverRadiusEntry.SetText(strconv.Itoa(myApp.Preferences().IntWithFallback(chartHoverRadiusKey, DefaultHoverRadius)))
+	hoverRadiusEntry.OnChanged = func(text string) {
+		radius := 0
+		if _, err := fmt.Sscanf(text, "%d", &radius); err == nil && radius > 0 {
+			myApp.Preferences().SetInt(chartHoverRadiusKey, radius)
+			chartWidget.SetHoverRadius(float32(radius))
+		}
+	}
+	tooltipsCheck := widget.NewCheck("Mostrar tooltips al pasar el mouse", func(enabled bool) {
+		myApp.Preferences().SetBool(chartTooltipsKey, enabled)
+		chartWidget.SetTooltipsEnabled(enabled)
+	})
+	tooltipsCheck.SetChecked(myApp.Preferences().BoolWithFallback(chartTooltipsKey, true))
+
+	// Ventana del P95 móvil, guardada en preferencias
+	chartWidget.SetRollingWindow(myApp.Preferences().IntWithFallback(chartRollingWindowKey, DefaultRollingWindow))
+	rollingWindowEntry := widget.NewEntry()
+	rollingWindowEntry.SetText(strconv.Itoa(myApp.Preferences().IntWithFallback(chartRollingWindowKey, DefaultRollingWindow)))
+	rollingWindowEntry.OnChanged = func(text string) {
+		window := 0
+		if _, err := fmt.Sscanf(text, "%d", &window); err == nil && window > 0 {
+			myApp.Preferences().SetInt(chartRollingWindowKey, window)
+			chartWidget.SetRollingWindow(window)
 		}
 	}
 
-	// Consola desplegable para mostrar detalles de la request
-	consoleEntry := widget.NewMultiLineEntry()
-	consoleEntry.Wrapping = fyne.TextWrapWord
-	consoleEntry.SetMinRowsVisible(10)
+	seriesColorsBtn := widget.NewButtonWithIcon("Colores", theme.ColorPaletteIcon(), func() {
+		pickSeriesColor := func(title string, current color.NRGBA, set func(*ChartTheme, color.NRGBA)) fyne.CanvasObject {
+			swatch := canvas.NewRectangle(current)
+			swatch.SetMinSize(fyne.NewSize(16, 16))
+			btn := widget.NewButton(title, nil)
+			btn.OnTapped = func() {
+				picker := dialog.NewColorPicker(title, "Color de la serie", func(c color.Color) {
+					t := chartWidget.Theme()
+					set(&t, color.NRGBAModel.Convert(c).(color.NRGBA))
+					applyChartTheme(t)
+					swatch.FillColor = c
+					swatch.Refresh()
+				}, myWindow)
+				picker.Advanced = true
+				picker.Show()
+			}
+			return container.NewHBox(swatch, btn)
+		}
+		t := chartWidget.Theme()
+		content := container.NewVBox(
+			pickSeriesColor("Avg. response", t.ResponseTime, func(t *ChartTheme, c color.NRGBA) { t.ResponseTime = c }),
+			pickSeriesColor("Requests/second", t.RequestsSec, func(t *ChartTheme, c color.NRGBA) { t.RequestsSec = c }),
+			pickSeriesColor("Error rate", t.ErrorRate, func(t *ChartTheme, c color.NRGBA) { t.ErrorRate = c }),
+		)
+		dialog.ShowCustom("Colores de series", "Cerrar", content, myWindow)
+	})
 
-	// Estilo personalizado para que se vea como consola pero sea seleccionable
-	consoleBg := canvas.NewRectangle(color.NRGBA{R: 20, G: 20, B: 25, A: 255})
+	viewControlsContainer := container.NewHBox(
+		widget.NewLabel("Vista:"),
+		normalViewBtn,
+		realTimeViewBtn,
+		fullScreenBtn,
+		outliersBtn,
+		perSecondBtn,
+		influxExportBtn,
+		pushMetricsBtn,
+		importCSVBtn,
+		scenarioStepsBtn,
+		clearResultsBtn,
+		widget.NewSeparator(),
+		gradientCheck,
+		peakSamplingCheck,
+		rollingP95Check,
+		latencyMetricSelect,
+		xAxisSelect,
+		errorRateModeSelect,
+	)
 
-	consoleDisplay := container.NewStack(consoleBg, container.NewPadded(consoleEntry))
+	statsContainer := container.NewGridWithColumns(10) // 10 columnas = 1 fila compacta
+	// Inicializar con estadísticas vacías usando las métricas básicas
+	statsContainer.Objects = createStatsWidgets(avgBind, minBind, maxBind, successBind, 0)
 
-	consoleVisible := false
-	consoleContainer := container.NewVBox()
-	consoleContainer.Hide()
+	// Aviso visible cuando el P95 de la corrida supera el SLA configurado
+	slaBannerText := canvas.NewText("", color.White)
+	slaBannerText.Alignment = fyne.TextAlignCenter
+	slaBannerText.TextStyle = fyne.TextStyle{Bold: true}
+	slaBanner := container.NewStack(canvas.NewRectangle(color.NRGBA{R: 150, G: 30, B: 30, A: 255}), container.NewPadded(slaBannerText))
+	slaBanner.Hide()
+
+	// showAdvancedStats reemplaza las celdas ajustando las columnas para mantener una sola fila
+	showAdvancedStats := func(stats BenchmarkStats) {
+		cells := createAdvancedStatsWidgets(stats, latencyDecimals, preferSteadyRPS)
+		statsContainer.Layout = layout.NewGridLayoutWithColumns(len(cells))
+		statsContainer.Objects = cells
+		statsContainer.Refresh()
 
-	consoleToggleBtn := widget.NewButtonWithIcon("Mostrar Consola Request", theme.InfoIcon(), nil)
-	consoleToggleBtn.OnTapped = func() {
-		if consoleVisible {
-			consoleContainer.Hide()
-			consoleToggleBtn.SetText("Mostrar Consola Request")
-			consoleToggleBtn.SetIcon(theme.InfoIcon())
-			consoleVisible = false
+		if stats.SLABreached {
+			slaBannerText.Text = fmt.Sprintf("⚠️ SLA incumplido: P95 %s supera el límite de %s", formatDuration(stats.P95Ms, latencyDecimals), formatDuration(stats.SLAP95Ms, latencyDecimals))
+			slaBannerText.Refresh()
+			slaBanner.Show()
 		} else {
-			consoleContainer.Show()
-			consoleToggleBtn.SetText("Ocultar Consola Request")
-			consoleToggleBtn.SetIcon(theme.ContentClearIcon())
-			consoleVisible = true
+			slaBanner.Hide()
 		}
 	}
 
-	updateConsole := func(details RequestDetails) {
-		consoleText := fmt.Sprintf(`=== REQUEST ENVIADA ===
+	// Container dinámico que cambia entre gráfico y respuesta
+	var rightContentArea *fyne.Container
+	chartBg := canvas.NewRectangle(color.NRGBA{R: 25, G: 25, B: 25, A: 255})
+	rightContentArea = container.NewStack(chartBg, chartWidget)
 
-Método: %s
-URL: %s
-Timestamp: %s
+	// showResponse muestra una respuesta completa (status, headers y body) en el visor
+	showResponse := func(single SingleResponse) {
+		result := single.Result
+		lastResponseHeader = fmt.Sprintf("STATUS: %d\nDURATION: %.2f ms\nTTLB: %.2f ms\nTIMESTAMP: %s\n",
+			result.Status, result.Duration, result.TTLBMs, result.Timestamp)
+		if single.Proto != "" {
+			lastResponseHeader += fmt.Sprintf("PROTOCOL: %s\n", single.Proto)
+		}
+		if single.TLS != "" {
+			lastResponseHeader += fmt.Sprintf("TLS: %s\n", single.TLS)
+		}
+		if result.ErrorKind == "schema" {
+			lastResponseHeader += fmt.Sprintf("SCHEMA: %s\n", result.ErrorDetail)
+		}
+		lastResponseHeader += "\n"
+		if len(single.Headers) > 0 {
+			lastResponseHeader += "--- RESPONSE HEADERS ---\n\n" + formatHeaders(single.Headers) + "\n"
+		}
+		lastResponseHeader += "--- RESPONSE BODY ---\n\n"
+		lastResponseBody = single.Body
+		lastResponseContentType = single.ContentType
+		renderResponse()
+		if _, ok := browserExtension(single.ContentType); ok && single.Err == nil {
+			openBrowserBtn.Show()
+		} else {
+			openBrowserBtn.Hide()
+		}
+		timingBreakdown.Objects = []fyne.CanvasObject{createTimingBreakdown(result)}
+		timingBreakdown.Refresh()
 
---- HEADERS ---
-%s
+		// Cambiar a vista de respuesta
+		rightContentArea.Objects = []fyne.CanvasObject{
+			canvas.NewRectangle(color.NRGBA{R: 25, G: 25, B: 25, A: 255}),
+			responsePanel,
+		}
+		rightContentArea.Refresh()
+	}
 
---- BODY ---
-%s
+	runBtn := widget.NewButtonWithIcon("Ejecutar Request", theme.MediaPlayIcon(), nil)
+	cacheCheckBtn := widget.NewButtonWithIcon("Probar caché", theme.SearchIcon(), nil)
+	coldWarmBtn := widget.NewButtonWithIcon("Frío vs. caliente", theme.ViewRefreshIcon(), nil)
 
---- AUTH ---
-%s`,
-			details.Method, details.URL, details.Timestamp, details.Headers, details.Body, details.Auth)
-		consoleEntry.SetText(consoleText)
+	// Variable para controlar cancelación
+	var cancelChan chan bool
+	var isRunning bool
+	var usersConfirmed bool           // El usuario aceptó ejecutar por encima de SafeConcurrentUsers
+	var requestsConfirmed bool        // El usuario aceptó ejecutar más requests que el límite de Ajustes
+	var lastResults []BenchmarkResult // Resultados de la última ejecución, para el listado de outliers
+	var lastRunURL string             // URL principal de la última ejecución (tag url de la exportación a InfluxDB)
+	var lastRunCfg RequestConfig      // Configuración de la última ejecución, para contar errores con su criterio de éxito
+	var lastStats BenchmarkStats      // Resumen de la última ejecución, para el push a Prometheus
+
+	// Top Outliers: las requests más lentas de la última ejecución, para investigar la cola de latencia
+	outliersBtn.OnTapped = func() {
+		if len(lastResults) == 0 {
+			dialog.ShowInformation("Top Outliers", "No hay resultados. Ejecuta un test primero.", myWindow)
+			return
+		}
+		lines := []string{fmt.Sprintf("%-4s %-8s %-10s %-10s %s", "#", "Seq", "Hora", "Duración", "Status")}
+		for i, r := range topOutliers(lastResults, TopOutliersCount) {
+			lines = append(lines, fmt.Sprintf("%-4d %-8d %-10s %-10s %d", i+1, r.Seq, r.Timestamp, formatDuration(r.Duration, latencyDecimals), r.Status))
+			if r.ErrorDetail != "" {
+				lines = append(lines, "     └ schema: "+r.ErrorDetail)
+			}
+		}
+		list := widget.NewLabelWithStyle(strings.Join(lines, "\n"), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		dialog.ShowCustom(fmt.Sprintf("Top %d Outliers", TopOutliersCount), "Cerrar", list, myWindow)
 	}
 
-	consoleScrollContainer := container.NewVScroll(consoleDisplay)
-	consoleScrollContainer.SetMinSize(fyne.NewSize(0, 250))
-	consoleContainer.Objects = []fyne.CanvasObject{
-		widget.NewSeparator(),
-		newBoldLabel("Detalles de la Request Enviada", fyne.TextAlignLeading),
-		consoleScrollContainer,
+	// Pasos: la última request y respuesta de cada paso del escenario, para encontrar dónde se rompe la secuencia
+	scenarioStepsBtn.OnTapped = func() {
+		steps := lastStats.ScenarioSteps
+		if len(steps) == 0 {
+			dialog.ShowInformation("Pasos del escenario", "No hay pasos registrados. Ejecuta un escenario (Importar HAR) primero.", myWindow)
+			return
+		}
+		var stepsDialog dialog.Dialog
+		list := widget.NewList(
+			func() int { return len(steps) },
+			func() fyne.CanvasObject { return widget.NewLabel("") },
+			func(id widget.ListItemID, o fyne.CanvasObject) {
+				step := steps[id]
+				if step.Request == nil {
+					if step.Body != "" {
+						// Paso omitido por su condición
+						o.(*widget.Label).SetText(fmt.Sprintf("%d. %s", id+1, step.Body))
+						return
+					}
+					o.(*widget.Label).SetText(fmt.Sprintf("%d. (sin ejecutar)", id+1))
+					return
+				}
+				outcome := strconv.Itoa(step.Result.Status)
+				if step.Result.ErrorKind != "" {
+					outcome = step.Result.ErrorKind
+				}
+				o.(*widget.Label).SetText(fmt.Sprintf("%d. %s %s → %s (%s)", id+1, step.Request.Method, step.Request.URL.RequestURI(), outcome, formatDuration(step.Result.Duration, latencyDecimals)))
+			},
+		)
+		list.OnSelected = func(id widget.ListItemID) {
+			step := steps[id]
+			if step.Request == nil {
+				list.Unselect(id)
+				return
+			}
+			stepsDialog.Hide()
+			// La respuesta va al visor y la request resuelta a la consola
+			showResponse(step)
+			updateConsole(RequestDetails{
+				Method:    step.Request.Method,
+				URL:       step.Request.URL.String(),
+				Headers:   formatHeaders(step.Request.Header),
+				Body:      step.RequestBody,
+				Timestamp: step.Request.Header.Get("X-Timestamp"),
+				Auth:      step.AuthInfo,
+			})
+		}
+		stepsDialog = dialog.NewCustom("Pasos del escenario (última vuelta)", "Cerrar", list, myWindow)
+		stepsDialog.Resize(fyne.NewSize(640, 400))
+		stepsDialog.Show()
 	}
 
-	// --- BINDING PARA ESTADÍSTICAS ---
-	avgBind := binding.NewString()
-	minBind := binding.NewString()
-	maxBind := binding.NewString()
-	successBind := binding.NewString()
-
-	avgBind.Set("Promedio: -")
-	minBind.Set("Mínimo: -")
-	maxBind.Set("Máximo: -")
-	successBind.Set("Éxito: -")
-
-	// Variables para el Árbol de Postman
-	treeData := make(map[string]PostmanItem)
-	treeRoots := []string{}
+	// Por segundo: requests, errores y latencia promedio de cada segundo de la última ejecución,
+	// para ubicar con exactitud cuándo se degradó el backend
+	perSecondBtn.OnTapped = func() {
+		buckets := aggregatePerSecond(lastResults, lastRunCfg)
+		if len(buckets) == 0 {
+			dialog.ShowInformation("Por segundo", "No hay resultados. Ejecuta un test primero.", myWindow)
+			return
+		}
+		headers := []string{"Segundo", "Requests", "Errores", "Promedio"}
+		table := widget.NewTable(
+			func() (int, int) { return len(buckets) + 1, len(headers) },
+			func() fyne.CanvasObject { return widget.NewLabel("00:00:00.000") },
+			func(id widget.TableCellID, o fyne.CanvasObject) {
+				label := o.(*widget.Label)
+				if id.Row == 0 {
+					label.TextStyle = fyne.TextStyle{Bold: true}
+					label.SetText(headers[id.Col])
+					return
+				}
+				label.TextStyle = fyne.TextStyle{}
+				b := buckets[id.Row-1]
+				switch id.Col {
+				case 0:
+					label.SetText(b.Second.Format("15:04:05"))
+				case 1:
+					label.SetText(strconv.Itoa(b.Requests))
+				case 2:
+					label.SetText(strconv.Itoa(b.Errors))
+				case 3:
+					if b.Requests == 0 {
+						label.SetText("-")
+					} else {
+						label.SetText(formatDuration(b.AvgMs, latencyDecimals))
+					}
+				}
+			},
+		)
+		d := dialog.NewCustom(fmt.Sprintf("Por segundo (%d s)", len(buckets)), "Cerrar", table, myWindow)
+		d.Resize(fyne.NewSize(480, 420))
+		d.Show()
+	}
 
-	var processItems func([]PostmanItem, string)
-	processItems = func(items []PostmanItem, parentID string) {
-		for i, item := range items {
-			id := item.Name + strconv.Itoa(i)
-			if parentID != "" {
-				id = parentID + "/" + id
+	// Exportar a InfluxDB: guarda el line protocol en un archivo o lo envía al endpoint configurado en Ajustes
+	influxExportBtn.OnTapped = func() {
+		if len(lastResults) == 0 {
+			dialog.ShowInformation("Exportar Influx", "No hay resultados. Ejecuta un test primero.", myWindow)
+			return
+		}
+		// Las requests a la URL principal no guardan URL propia
+		results := append([]BenchmarkResult(nil), lastResults...)
+		for i := range results {
+			if results[i].URL == "" && results[i].Endpoint == "" {
+				results[i].URL = lastRunURL
 			}
+		}
+		data := toInfluxLineProtocol(results, lastStats.Note)
 
-			treeData[id] = item
-			if parentID == "" {
-				treeRoots = append(treeRoots, id)
-			}
-			if len(item.Items) > 0 {
-				processItems(item.Items, id)
+		var d dialog.Dialog
+		saveBtn := widget.NewButtonWithIcon("Guardar archivo", theme.DocumentSaveIcon(), func() {
+			d.Hide()
+			fd := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+				if err != nil || writer == nil {
+					return
+				}
+				defer writer.Close()
+				if _, err := writer.Write([]byte(data)); err != nil {
+					dialog.ShowError(fmt.Errorf("Error al guardar el line protocol: %w", err), myWindow)
+				}
+			}, myWindow)
+			fd.SetFileName("benchmark.lp")
+			fd.Show()
+		})
+		sendBtn := widget.NewButtonWithIcon("Enviar a InfluxDB", theme.UploadIcon(), func() {
+			writeURL := strings.TrimSpace(influxURLEntry.Text)
+			if writeURL == "" {
+				dialog.ShowError(errors.New("Configura la URL de escritura de InfluxDB en Ajustes → Exportación"), myWindow)
+				return
 			}
+			d.Hide()
+			token := influxTokenEntry.Text
+			go func() {
+				err := postInfluxLineProtocol(writeURL, token, data)
+				fyne.Do(func() {
+					if err != nil {
+						dialog.ShowError(fmt.Errorf("Error al enviar a InfluxDB: %w", err), myWindow)
+						return
+					}
+					dialog.ShowInformation("Exportar Influx", fmt.Sprintf("%d registros enviados a InfluxDB", len(results)), myWindow)
+				})
+			}()
+		})
+		content := container.NewVBox(
+			widget.NewLabel(fmt.Sprintf("%d registros (measurement %s)", len(results), InfluxMeasurement)),
+			container.NewHBox(saveBtn, sendBtn),
+		)
+		d = dialog.NewCustom("Exportar Influx", "Cancelar", content, myWindow)
+		d.Show()
+	}
+
+	// Push Metrics: envía el resumen de la última ejecución al Pushgateway configurado en Ajustes
+	pushMetricsBtn.OnTapped = func() {
+		if len(lastResults) == 0 {
+			dialog.ShowInformation("Push Metrics", "No hay resultados. Ejecuta un test primero.", myWindow)
+			return
+		}
+		gatewayURL := strings.TrimSpace(pushgatewayEntry.Text)
+		if gatewayURL == "" {
+			dialog.ShowError(errors.New("Configura la URL del Pushgateway en Ajustes → Exportación"), myWindow)
+			return
 		}
+		stats := withPercentiles(lastStats, lastResults, 95, 99)
+		job := strings.TrimSpace(promJobEntry.Text)
+		pushMetricsBtn.Disable()
+		go func() {
+			err := PushToPrometheus(stats, job, gatewayURL)
+			fyne.Do(func() {
+				pushMetricsBtn.Enable()
+				if err != nil {
+					dialog.ShowError(fmt.Errorf("Error al enviar las métricas: %w", err), myWindow)
+					return
+				}
+				dialog.ShowInformation("Push Metrics", "Métricas enviadas al Pushgateway", myWindow)
+			})
+		}()
 	}
 
-	postmanTree := widget.NewTree(
-		func(id widget.TreeNodeID) []widget.TreeNodeID {
-			if id == "" {
-				return treeRoots
+	// resetResults vacía el gráfico, las estadísticas y el visor de respuesta y vuelve a la vista de gráfico
+	resetResults := func() {
+		chartWidget.SetData([]BenchmarkResult{})
+		responseViewer.SetText("")
+		openBrowserBtn.Hide()
+
+		// Resetear estadísticas
+		avgBind.Set("Promedio: -")
+		minBind.Set("Mínimo: -")
+		maxBind.Set("Máximo: -")
+		successBind.Set("Éxito: -")
+		statsContainer.Objects = createStatsWidgets(avgBind, minBind, maxBind, successBind, 0)
+		statsContainer.Refresh()
+		slaBanner.Hide()
+
+		// Cambiar a vista de gráfico
+		rightContentArea.Objects = []fyne.CanvasObject{chartBg, chartWidget}
+		rightContentArea.Refresh()
+	}
+
+	// Importar CSV: carga los resultados de una ejecución anterior en el gráfico y recalcula sus estadísticas
+	importCSVBtn.OnTapped = func() {
+		if isRunning {
+			return
+		}
+		fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
 			}
-			item := treeData[id]
-			children := []string{}
-			for i, child := range item.Items {
-				childID := id + "/" + child.Name + strconv.Itoa(i)
-				treeData[childID] = child
-				children = append(children, childID)
+			defer reader.Close()
+			results, skipped, err := parseResultsCSV(reader)
+			if err != nil {
+				dialog.ShowError(err, myWindow)
+				return
 			}
-			return children
-		},
-		func(id widget.TreeNodeID) bool {
-			return len(treeData[id].Items) > 0
-		},
-		func(branch bool) fyne.CanvasObject {
-			icon := theme.FileTextIcon()
-			if branch {
-				icon = theme.FolderIcon()
+			if len(results) == 0 {
+				dialog.ShowError(fmt.Errorf("el CSV no tiene resultados válidos (%d filas salteadas)", skipped), myWindow)
+				return
 			}
-			return container.NewHBox(widget.NewIcon(icon), widget.NewLabel("Template"))
-		},
-		func(id widget.TreeNodeID, branch bool, o fyne.CanvasObject) {
-			lbl := o.(*fyne.Container).Objects[1].(*widget.Label)
-			lbl.SetText(treeData[id].Name)
-		},
-	)
 
-	postmanTree.OnSelected = func(id widget.TreeNodeID) {
-		item := treeData[id]
-		if item.Request != nil {
-			urlEntry.SetText(item.Request.Url.Raw)
-			methodSelect.SetSelected(item.Request.Method)
+			// Éxito según el rango de status configurado en el formulario
+			var cfg RequestConfig
+			cfg.Percentiles, _ = parsePercentiles(percentilesEntry.Text)
+			if cfg.SuccessStatusMin, cfg.SuccessStatusMax, err = parseSuccessStatusRange(successMinEntry.Text, successMaxEntry.Text); err != nil {
+				dialog.ShowError(err, myWindow)
+				return
+			}
+			stats := summarizeResults(results, csvElapsed(results), cfg)
+
+			resetResults()
+			chartWidget.SetSuccessCriteria(cfg)
+			chartWidget.SetData(results)
+			sparkline.SetData(results)
+			lastResults = results
+			lastRunURL = ""
+			lastRunCfg = cfg
+			lastStats = stats
+			avgBind.Set(formatDuration(stats.Avg, latencyDecimals))
+			minBind.Set(formatDuration(stats.Min, latencyDecimals))
+			maxBind.Set(formatDuration(stats.Max, latencyDecimals))
+			successBind.Set(fmt.Sprintf("%.2f%%", float64(stats.Success)/float64(stats.Total)*100))
+			showAdvancedStats(stats)
+
+			msg := fmt.Sprintf("%d resultados importados desde %s", len(results), reader.URI().Name())
+			if skipped > 0 {
+				msg += fmt.Sprintf("\n%d filas mal formadas se saltearon", skipped)
+			}
+			dialog.ShowInformation("Importar CSV", msg, myWindow)
+		}, myWindow)
+		fd.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+		fd.Show()
+	}
+
+	// Probar caché: diagnóstico de una sola vez (no es un benchmark) para verificar la configuración de CDN/caché
+	cacheCheckBtn.OnTapped = func() {
+		if isRunning {
+			return
+		}
+		if urlEntry.Text == "" {
+			dialog.ShowError(fmt.Errorf("por favor ingresa una URL"), myWindow)
+			return
+		}
+		cfg := RequestConfig{
+			URL: urlEntry.Text, Method: methodPicker.Method(),
+			Headers: headerTable.EnabledText(), Body: bodyEntry.Text, BodyFile: bodyFilePath, BodyDir: bodyDirPath,
+			ContentType: resolveContentType(contentTypeSelect.Text, bodyEntry.Text, bodyFilePath),
+			User:        userEntry.Text, Secret: secretEntry.Text,
+			AuthType: authTypeSelect.Selected, NTLMDomain: ntlmDomainEntry.Text, NTLMUser: ntlmUserEntry.Text, NTLMPassword: ntlmPasswordEntry.Text,
+			UserAgent: userAgentEntry.Text, UnixSocketPath: strings.TrimSpace(unixSocketEntry.Text),
+			DisableRedirects: disableRedirectsCheck.Checked, ForceHTTP1: forceHTTP1Check.Checked,
+			AllowBodyAllMethods: allowBodyCheck.Checked, PreRequestScript: preRequestEntry.Text,
+		}
+		fmt.Sscanf(timeoutEntry.Text, "%d", &cfg.TimeoutSeconds)
+		cfg = expandEnvTokens(cfg, envVars)
+		if isWebSocketURL(cfg.URL) || isGRPCURL(cfg.URL) {
+			dialog.ShowError(errors.New("el detector de caché solo aplica a URLs HTTP"), myWindow)
+			return
+		}
 
-			hStr := ""
-			for _, h := range item.Request.Header {
-				hStr += fmt.Sprintf("%s: %s\n", h.Key, h.Value)
+		cacheCheckBtn.Disable()
+		go func() {
+			var check CacheCheck
+			if cfg, err := loadBodyFile(cfg); err != nil {
+				check = CacheCheck{First: SingleResponse{Err: err}, Reason: fmt.Sprintf("no se pudo leer el body: %v", err)}
+			} else {
+				check = checkResponseCaching(cfg)
 			}
-			headersEntry.SetText(hStr)
-			bodyEntry.SetText(item.Request.Body.Raw)
-		}
+			fyne.Do(func() {
+				cacheCheckBtn.Enable()
+				report := widget.NewLabelWithStyle(formatCacheReport(check, latencyDecimals), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+				dialog.ShowCustom("Detector de caché", "Cerrar", report, myWindow)
+			})
+		}()
 	}
 
-	importBtn := widget.NewButtonWithIcon("Cargar JSON Postman", theme.FolderOpenIcon(), func() {
-		fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
-			if err != nil || reader == nil {
+	// Diagnóstico frío/caliente: N requests seguidas, la primera con una conexión nueva
+	coldWarmBtn.OnTapped = func() {
+		if isRunning {
+			return
+		}
+		if urlEntry.Text == "" {
+			dialog.ShowError(fmt.Errorf("por favor ingresa una URL"), myWindow)
+			return
+		}
+		cfg := RequestConfig{
+			URL: urlEntry.Text, Method: methodPicker.Method(),
+			Headers: headerTable.EnabledText(), Body: bodyEntry.Text, BodyFile: bodyFilePath, BodyDir: bodyDirPath,
+			ContentType: resolveContentType(contentTypeSelect.Text, bodyEntry.Text, bodyFilePath),
+			User:        userEntry.Text, Secret: secretEntry.Text,
+			AuthType: authTypeSelect.Selected, NTLMDomain: ntlmDomainEntry.Text, NTLMUser: ntlmUserEntry.Text, NTLMPassword: ntlmPasswordEntry.Text,
+			UserAgent: userAgentEntry.Text, UnixSocketPath: strings.TrimSpace(unixSocketEntry.Text),
+			DisableRedirects: disableRedirectsCheck.Checked, ForceHTTP1: forceHTTP1Check.Checked,
+			AllowBodyAllMethods: allowBodyCheck.Checked, PreRequestScript: preRequestEntry.Text,
+		}
+		fmt.Sscanf(timeoutEntry.Text, "%d", &cfg.TimeoutSeconds)
+		var err error
+		if cfg.SuccessStatusMin, cfg.SuccessStatusMax, err = parseSuccessStatusRange(successMinEntry.Text, successMaxEntry.Text); err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+		cfg = expandEnvTokens(cfg, envVars)
+		if isWebSocketURL(cfg.URL) || isGRPCURL(cfg.URL) {
+			dialog.ShowError(errors.New("el diagnóstico frío/caliente solo aplica a URLs HTTP"), myWindow)
+			return
+		}
+
+		countEntry := widget.NewEntry()
+		countEntry.SetText(strconv.Itoa(DefaultColdWarmRequests))
+		dialog.ShowForm("Frío vs. caliente", "Medir", "Cancelar", []*widget.FormItem{
+			widget.NewFormItem("Requests:", countEntry),
+		}, func(ok bool) {
+			if !ok {
 				return
 			}
-			defer reader.Close()
-
-			byteValue, _ := io.ReadAll(reader)
-			var collection PostmanCollection
-			err = json.Unmarshal(byteValue, &collection)
-			if err != nil {
-				dialog.ShowError(fmt.Errorf("Error al parsear Postman JSON: %w", err), myWindow)
+			n, err := strconv.Atoi(strings.TrimSpace(countEntry.Text))
+			if err != nil || n < 2 {
+				dialog.ShowError(fmt.Errorf("cantidad de requests inválida: %q (mínimo 2)", countEntry.Text), myWindow)
 				return
 			}
-
-			treeData = make(map[string]PostmanItem)
-			treeRoots = []string{}
-			processItems(collection.Items, "")
-			postmanTree.Refresh()
-
+			coldWarmBtn.Disable()
+			go func() {
+				cfg, err := loadBodyFile(cfg)
+				var report string
+				if err != nil {
+					report = fmt.Sprintf("no se pudo leer el body: %v", err)
+				} else {
+					report = formatColdWarmReport(checkColdWarm(cfg, n), cfg, latencyDecimals)
+				}
+				fyne.Do(func() {
+					coldWarmBtn.Enable()
+					label := widget.NewLabelWithStyle(report, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+					dialog.ShowCustom("Frío vs. caliente", "Cerrar", label, myWindow)
+				})
+			}()
 		}, myWindow)
-		fd.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
-		fd.Show()
-	})
-
-	// Botón para importar desde cURL
-	curlBtn := widget.NewButtonWithIcon("Pegar cURL", theme.ContentPasteIcon(), func() {
-		curlEntry := widget.NewMultiLineEntry()
-		curlEntry.SetPlaceHolder("Pega aquí tu comando cURL completo...")
-		curlEntry.SetMinRowsVisible(10)
+	}
 
-		// Crear diálogo personalizado con ventana más ancha
-		formDialog := dialog.NewForm("Importar desde cURL", "Importar", "Cancelar",
-			[]*widget.FormItem{
-				widget.NewFormItem("Comando cURL:", curlEntry),
-			}, func(ok bool) {
-				if !ok || curlEntry.Text == "" {
-					return
-				}
-				parseCurlCommand(curlEntry.Text, urlEntry, methodSelect, headersEntry, bodyEntry)
-			}, myWindow)
+	// Limpiar deja la pantalla como recién abierta, sin necesidad de ejecutar otro test
+	clearResultsBtn.OnTapped = func() {
+		if isRunning {
+			return
+		}
+		resetResults()
+		lastResults = nil
+		lastRunURL = ""
+		lastRunCfg = RequestConfig{}
+		lastStats = BenchmarkStats{}
+		sparkline.SetData(nil)
+		consoleEntry.SetText("")
+		lastResponseHeader, lastResponseBody, lastResponseContentType = "", "", ""
+		timingBreakdown.Objects = nil
+		timingBreakdown.Refresh()
+		normalViewBtn.OnTapped()
+	}
 
-		formDialog.Resize(fyne.NewSize(800, 400))
-		formDialog.Show()
-	})
+	runBtn.OnTapped = func() {
+		// Si está ejecutando, cancelar
+		if isRunning {
+			if cancelChan != nil {
+				close(cancelChan)
+				runBtn.SetText("Cancelando...")
+				runBtn.Disable()
+			}
+			return
+		}
 
-	// --- AREA GRAFICA Y EJECUCION ---
+		// Validar URL
+		if urlEntry.Text == "" {
+			dialog.ShowError(fmt.Errorf("por favor ingresa una URL"), myWindow)
+			return
+		}
+		if method := methodPicker.Method(); !isHTTPToken(method) {
+			dialog.ShowError(fmt.Errorf("método HTTP inválido: %q (solo letras, dígitos y !#$%%&'*+-.^_`|~, sin espacios)", method), myWindow)
+			return
+		}
 
-	chartWidget := NewChartWidget()
-	progressBar := widget.NewProgressBar()
-	progressBar.Hide()
+		// Validar usuarios concurrentes: cada usuario abre sus propias conexiones,
+		// por lo que valores muy altos pueden agotar los descriptores de archivo
+		requestedUsers := 1
+		fmt.Sscanf(usersEntry.Text, "%d", &requestedUsers)
+		capNotice := ""
+		if requestedUsers > MaxConcurrentUsers {
+			capNotice = fmt.Sprintf("El valor %d supera el máximo permitido y se limitó a %d.\n\n", requestedUsers, MaxConcurrentUsers)
+			requestedUsers = MaxConcurrentUsers
+			usersEntry.SetText(strconv.Itoa(MaxConcurrentUsers))
+		}
+		if requestedUsers > SafeConcurrentUsers && !usersConfirmed {
+			dialog.ShowConfirm("Muchos usuarios concurrentes",
+				fmt.Sprintf("%s%d usuarios concurrentes pueden agotar las conexiones o descriptores de archivo del sistema "+
+					"(límite recomendado: %d). ¿Deseas continuar?", capNotice, requestedUsers, SafeConcurrentUsers),
+				func(proceed bool) {
+					if proceed {
+						usersConfirmed = true
+						runBtn.OnTapped()
+					}
+				}, myWindow)
+			return
+		}
 
-	// Área para mostrar respuesta única
-	responseViewer := widget.NewMultiLineEntry()
-	responseViewer.SetPlaceHolder("Respuesta del servidor aparecerá aquí...")
-	responseViewer.Wrapping = fyne.TextWrapWord
+		// Confirmar volúmenes muy grandes: una cantidad con ceros de más puede saturar el destino o la memoria
+		if testModeSelect.Selected != "Por Tiempo" && !requestsConfirmed {
+			requestedTotal, safeTotal := 0, 0
+			fmt.Sscanf(countEntry.Text, "%d", &requestedTotal)
+			fmt.Sscanf(safeTotalEntry.Text, "%d", &safeTotal)
+			if countModeSelect.Selected == "Por usuario" {
+				requestedTotal *= max(requestedUsers, 1)
+			}
+			if safeTotal > 0 && requestedTotal > safeTotal {
+				dialog.ShowConfirm("Muchas requests",
+					fmt.Sprintf("Se van a enviar %d requests (límite configurado en Ajustes: %d). "+
+						"Un volumen así puede saturar el servidor de destino o la memoria de esta máquina. ¿Deseas continuar?",
+						requestedTotal, safeTotal),
+					func(proceed bool) {
+						if proceed {
+							requestsConfirmed = true
+							runBtn.OnTapped()
+						}
+					}, myWindow)
+				return
+			}
+		}
+		// Las confirmaciones valen solo para esta ejecución
+		usersConfirmed, requestsConfirmed = false, false
 
-	// Variables para control de vistas
-	var isFullScreen bool
-	var originalContent fyne.CanvasObject
+		addRecentURL(myApp.Preferences(), urlEntry.Text)
 
-	// Botones de control de vista
-	normalViewBtn := widget.NewButtonWithIcon("Vista Normal", theme.HomeIcon(), func() {
-		chartWidget.SetViewMode(ViewModeNormal)
-		if isFullScreen {
-			myWindow.SetContent(originalContent)
-			isFullScreen = false
-			myWindow.Resize(fyne.NewSize(1000, 700))
+		// Limpiar datos de ejecución anterior
+		resetResults()
+
+		// failRun muestra el error de una validación posterior al cambio a "Cancelar" y restaura el botón
+		failRun := func(err error) {
+			dialog.ShowError(err, myWindow)
+			runBtn.SetText("Ejecutar Request")
+			runBtn.SetIcon(theme.MediaPlayIcon())
+			runBtn.Enable()
+			isRunning = false
+			progressBar.Hide()
 		}
-	})
 
-	realTimeViewBtn := widget.NewButtonWithIcon("Tiempo Real", theme.MediaPlayIcon(), func() {
-		chartWidget.SetViewMode(ViewModeRealTime)
-	})
+		// Cambiar botón a Cancelar
+		runBtn.SetText("Cancelar")
+		runBtn.SetIcon(theme.CancelIcon())
+		isRunning = true
+		cancelChan = make(chan bool)
+		progressBar.Show()
+		progressBar.SetValue(0)
 
-	fullScreenBtn := widget.NewButtonWithIcon("Pantalla Completa", theme.ViewFullScreenIcon(), func() {
-		chartWidget.SetViewMode(ViewModeFullScreen)
-		if !isFullScreen {
-			originalContent = myWindow.Content()
-			isFullScreen = true
+		// Leer configuración según el modo
+		count := 1
+		duration := 0
+		warmup := 0
+		users := 1
 
-			// Crear contenido de pantalla completa
-			fullScreenChart := container.NewStack(
-				canvas.NewRectangle(color.NRGBA{R: 25, G: 25, B: 25, A: 255}),
-				chartWidget,
-			)
+		if testModeSelect.Selected == "Por Tiempo" {
+			var durationValue int
+			fmt.Sscanf(durationEntry.Text, "%d", &durationValue)
+			if durationValue <= 0 {
+				failRun(fmt.Errorf("ingresa una duración válida"))
+				return
+			}
 
-			exitBtn := widget.NewButtonWithIcon("Salir Pantalla Completa", theme.CancelIcon(), func() {
-				myWindow.SetContent(originalContent)
-				isFullScreen = false
-				chartWidget.SetViewMode(ViewModeNormal)
-				myWindow.Resize(fyne.NewSize(1000, 700))
-			})
+			// Convertir a segundos según la unidad seleccionada
+			switch timeUnitSelect.Selected {
+			case "Minutos":
+				duration = durationValue * 60
+			case "Horas":
+				duration = durationValue * 3600
+			default: // Segundos
+				duration = durationValue
+			}
 
-			fullScreenContent := container.NewBorder(
-				container.NewHBox(exitBtn, widget.NewSeparator()),
-				nil, nil, nil,
-				fullScreenChart,
-			)
+			if strings.TrimSpace(warmupEntry.Text) != "" {
+				if _, err := fmt.Sscanf(warmupEntry.Text, "%d", &warmup); err != nil || warmup < 0 {
+					failRun(fmt.Errorf("warmup inválido: %q (usa segundos, vacío = sin calentamiento)", warmupEntry.Text))
+					return
+				}
+				if warmup >= duration {
+					failRun(fmt.Errorf("el warmup debe ser menor que la duración del test (%d s)", duration))
+					return
+				}
+			}
+		} else {
+			fmt.Sscanf(countEntry.Text, "%d", &count)
+			if count <= 0 {
+				failRun(fmt.Errorf("ingresa una cantidad válida de peticiones"))
+				return
+			}
+		}
 
-			myWindow.SetContent(fullScreenContent)
-			myWindow.Resize(fyne.NewSize(1400, 900))
+		fmt.Sscanf(usersEntry.Text, "%d", &users)
+		if users <= 0 {
+			users = 1
 		}
-	})
 
-	viewControlsContainer := container.NewHBox(
-		widget.NewLabel("Vista:"),
-		normalViewBtn,
-		realTimeViewBtn,
-		fullScreenBtn,
-		widget.NewSeparator(),
-	)
+		// En modo por usuario el total esperado es cantidad × usuarios
+		countMode := CountModeTotal
+		totalRequests := count
+		if duration == 0 && countModeSelect.Selected == "Por usuario" {
+			countMode = CountModePerUser
+			totalRequests = count * users
+		}
 
-	statsContainer := container.NewGridWithColumns(10) // 10 columnas = 1 fila compacta
-	// Inicializar con estadísticas vacías usando las métricas básicas
-	statsContainer.Objects = createStatsWidgets(avgBind, minBind, maxBind, successBind, 0)
+		// Validar que el archivo de log se pueda abrir antes de empezar
+		logFile := strings.TrimSpace(logFileEntry.Text)
+		if logFile != "" {
+			f, err := openLogFile(logFile)
+			if err != nil {
+				failRun(fmt.Errorf("no se pudo abrir el archivo de log: %w", err))
+				return
+			}
+			f.Close()
+		}
+		if isGRPCURL(expandEnvString(urlEntry.Text, envVars)) && strings.TrimSpace(grpcMethodEntry.Text) == "" {
+			failRun(fmt.Errorf("ingresa el método gRPC (paquete.Servicio/Metodo)"))
+			return
+		}
 
-	// Container dinámico que cambia entre gráfico y respuesta
-	var rightContentArea *fyne.Container
-	chartBg := canvas.NewRectangle(color.NRGBA{R: 25, G: 25, B: 25, A: 255})
-	rightContentArea = container.NewStack(chartBg, chartWidget)
+		// Endpoints ponderados (vacío = solo la URL principal)
+		endpoints, err := parseWeightedEndpoints(endpointsEntry.Text)
+		if err != nil {
+			failRun(fmt.Errorf("endpoints ponderados: %w", err))
+			return
+		}
 
-	runBtn := widget.NewButtonWithIcon("Ejecutar Request", theme.MediaPlayIcon(), nil)
+		if _, err := compileResponseSchema(responseSchemaEntry.Text); err != nil {
+			failRun(fmt.Errorf("JSON Schema inválido: %w", err))
+			return
+		}
+		if _, err := compilePreRequestScript(preRequestEntry.Text); err != nil {
+			failRun(fmt.Errorf("script pre-request inválido: %w", err))
+			return
+		}
 
-	// Variable para controlar cancelación
-	var cancelChan chan bool
-	var isRunning bool
+		percentiles, err := parsePercentiles(percentilesEntry.Text)
+		if err != nil {
+			failRun(err)
+			return
+		}
 
-	runBtn.OnTapped = func() {
-		// Si está ejecutando, cancelar
-		if isRunning {
-			if cancelChan != nil {
-				close(cancelChan)
-				runBtn.SetText("Cancelando...")
-				runBtn.Disable()
+		// Validar que el archivo de body siga disponible
+		if bodyFilePath != "" {
+			if _, err := os.Stat(bodyFilePath); err != nil {
+				failRun(fmt.Errorf("no se pudo leer el archivo de body: %w", err))
+				return
+			}
+		}
+		if bodyDirPath != "" {
+			if _, err := loadBodyDir(bodyDirPath); err != nil {
+				failRun(fmt.Errorf("no se pudo cargar la carpeta de bodies: %w", err))
+				return
 			}
-			return
 		}
 
-		// Validar URL
-		if urlEntry.Text == "" {
-			dialog.ShowError(fmt.Errorf("por favor ingresa una URL"), myWindow)
-			return
+		logBodyMax := 0
+		if strings.TrimSpace(logBodyMaxEntry.Text) != "" {
+			if _, err := fmt.Sscanf(logBodyMaxEntry.Text, "%d", &logBodyMax); err != nil || logBodyMax < 0 {
+				failRun(fmt.Errorf("máximo de bytes del body en el log inválido: %q (vacío = %d)", logBodyMaxEntry.Text, DefaultLogBodyMaxBytes))
+				return
+			}
 		}
 
-		// Limpiar datos de ejecución anterior
-		chartWidget.SetData([]BenchmarkResult{})
-		responseViewer.SetText("")
+		stopErrorRate := 0.0
+		if strings.TrimSpace(stopErrorRateEntry.Text) != "" {
+			if _, err := fmt.Sscanf(stopErrorRateEntry.Text, "%g", &stopErrorRate); err != nil || stopErrorRate < 0 || stopErrorRate > 100 {
+				failRun(fmt.Errorf("error rate para detener inválido: %q (porcentaje entre 0 y 100, vacío = no detener)", stopErrorRateEntry.Text))
+				return
+			}
+		}
+		errorWindow := 0
+		if strings.TrimSpace(errorWindowEntry.Text) != "" {
+			if _, err := fmt.Sscanf(errorWindowEntry.Text, "%d", &errorWindow); err != nil || errorWindow < 0 {
+				failRun(fmt.Errorf("ventana del circuit breaker inválida: %q (requests, vacío = %d)", errorWindowEntry.Text, DefaultErrorRateWindow))
+				return
+			}
+		}
 
-		// Resetear estadísticas
-		avgBind.Set("Promedio: -")
-		minBind.Set("Mínimo: -")
-		maxBind.Set("Máximo: -")
-		successBind.Set("Éxito: -")
-		statsContainer.Objects = createStatsWidgets(avgBind, minBind, maxBind, successBind, 0)
-		statsContainer.Refresh()
+		timeoutSeconds := 0
+		fmt.Sscanf(timeoutEntry.Text, "%d", &timeoutSeconds)
 
-		// Cambiar a vista de gráfico
-		rightContentArea.Objects = []fyne.CanvasObject{chartBg, chartWidget}
-		rightContentArea.Refresh()
+		connectTimeoutMs := 0
+		if strings.TrimSpace(connectTimeoutEntry.Text) != "" {
+			if _, err := fmt.Sscanf(connectTimeoutEntry.Text, "%d", &connectTimeoutMs); err != nil || connectTimeoutMs < 0 {
+				failRun(fmt.Errorf("timeout de conexión inválido: %q (usa ms, vacío = por defecto)", connectTimeoutEntry.Text))
+				return
+			}
+		}
 
-		// Cambiar botón a Cancelar
-		runBtn.SetText("Cancelar")
-		runBtn.SetIcon(theme.CancelIcon())
-		isRunning = true
-		cancelChan = make(chan bool)
-		progressBar.Show()
-		progressBar.SetValue(0)
+		maxResults := 0
+		if strings.TrimSpace(maxResultsEntry.Text) != "" {
+			if _, err := fmt.Sscanf(maxResultsEntry.Text, "%d", &maxResults); err != nil || maxResults < 0 {
+				failRun(fmt.Errorf("cantidad de resultados conservados inválida: %q (0 = sin límite)", maxResultsEntry.Text))
+				return
+			}
+		}
 
-		// Leer configuración según el modo
-		count := 1
-		duration := 0
-		users := 1
+		maxBodyKB := 0
+		if strings.TrimSpace(maxBodyEntry.Text) != "" {
+			if _, err := fmt.Sscanf(maxBodyEntry.Text, "%d", &maxBodyKB); err != nil || maxBodyKB < 0 {
+				failRun(fmt.Errorf("tamaño máximo de body inválido: %q (usa KB, vacío = %d)", maxBodyEntry.Text, DefaultMaxBodyCaptureBytes/1024))
+				return
+			}
+		}
+		deadlineMs := 0
+		if strings.TrimSpace(deadlineEntry.Text) != "" {
+			if _, err := fmt.Sscanf(deadlineEntry.Text, "%d", &deadlineMs); err != nil || deadlineMs < 0 {
+				failRun(fmt.Errorf("deadline por request inválido: %q (usa ms, vacío = sin deadline)", deadlineEntry.Text))
+				return
+			}
+		}
+		maxRunSeconds := 0
+		if strings.TrimSpace(maxRunDurationEntry.Text) != "" {
+			if _, err := fmt.Sscanf(maxRunDurationEntry.Text, "%d", &maxRunSeconds); err != nil || maxRunSeconds < 0 {
+				failRun(fmt.Errorf("timeout global inválido: %q (usa segundos, vacío = no)", maxRunDurationEntry.Text))
+				return
+			}
+		}
 
-		if testModeSelect.Selected == "Por Tiempo" {
-			var durationValue int
-			fmt.Sscanf(durationEntry.Text, "%d", &durationValue)
-			if durationValue <= 0 {
-				dialog.ShowError(fmt.Errorf("ingresa una duración válida"), myWindow)
-				// Restaurar botón
-				runBtn.SetText("Ejecutar Request")
-				runBtn.SetIcon(theme.MediaPlayIcon())
-				runBtn.Enable()
-				isRunning = false
-				progressBar.Hide()
+		var sweepLevels []int // nil = test normal
+		var sweep []SweepPoint
+		if sweepCheck.Checked {
+			levels, err := parseSweepLevels(sweepLevelsEntry.Text)
+			if err != nil {
+				failRun(err)
 				return
 			}
+			sweepLevels = levels
+		}
 
-			// Convertir a segundos según la unidad seleccionada
-			switch timeUnitSelect.Selected {
-			case "Minutos":
-				duration = durationValue * 60
-			case "Horas":
-				duration = durationValue * 3600
-			default: // Segundos
-				duration = durationValue
+		targetRPS := 0
+		if strings.TrimSpace(targetRPSEntry.Text) != "" {
+			if _, err := fmt.Sscanf(targetRPSEntry.Text, "%d", &targetRPS); err != nil || targetRPS < 0 || targetRPS > MaxTargetRPS {
+				failRun(fmt.Errorf("límite de requests/s inválido: %q (entre 0 y %d, vacío = sin límite)", targetRPSEntry.Text, MaxTargetRPS))
+				return
 			}
-		} else {
-			fmt.Sscanf(countEntry.Text, "%d", &count)
-			if count <= 0 {
-				dialog.ShowError(fmt.Errorf("ingresa una cantidad válida de peticiones"), myWindow)
-				// Restaurar botón
-				runBtn.SetText("Ejecutar Request")
-				runBtn.SetIcon(theme.MediaPlayIcon())
-				runBtn.Enable()
-				isRunning = false
-				progressBar.Hide()
+		}
+		var maxRPSStart, maxRPSStep int // 0 = sin barrido de throughput
+		var rpsSteps []RPSStep
+		rpsKnee := -1
+		if maxRPSCheck.Checked {
+			var err error
+			_, startErr := fmt.Sscanf(maxRPSStartEntry.Text, "%d", &maxRPSStart)
+			_, stepErr := fmt.Sscanf(maxRPSStepEntry.Text, "%d", &maxRPSStep)
+			switch target := expandEnvString(urlEntry.Text, envVars); {
+			case startErr != nil || stepErr != nil || maxRPSStart < 1 || maxRPSStep < 1 || maxRPSStart > MaxTargetRPS || maxRPSStep > MaxTargetRPS:
+				err = fmt.Errorf("el barrido de RPS necesita un valor inicial y un escalón entre 1 y %d req/s", MaxTargetRPS)
+			case sweepLevels != nil:
+				err = errors.New("el barrido de usuarios y la búsqueda del RPS máximo no se pueden combinar")
+			case isWebSocketURL(target) || isGRPCURL(target):
+				err = errors.New("la búsqueda del RPS máximo solo aplica a URLs HTTP")
+			}
+			if err != nil {
+				failRun(err)
 				return
 			}
 		}
 
-		fmt.Sscanf(usersEntry.Text, "%d", &users)
-		if users <= 0 {
-			users = 1
+		unixSocket := strings.TrimSpace(unixSocketEntry.Text)
+		if unixSocket != "" {
+			if err := checkUnixSocket(unixSocket); err != nil {
+				failRun(fmt.Errorf("socket Unix inválido: %w", err))
+				return
+			}
+		}
+
+		successMin, successMax, err := parseSuccessStatusRange(successMinEntry.Text, successMaxEntry.Text)
+		if err != nil {
+			failRun(err)
+			return
+		}
+
+		slowThreshold := 0
+		fmt.Sscanf(slaEntry.Text, "%d", &slowThreshold)
+		if slowThreshold < 0 {
+			slowThreshold = 0
+		}
+		chartWidget.SetSlowThreshold(slowThreshold)
+
+		slaP95 := 0.0
+		if strings.TrimSpace(slaP95Entry.Text) != "" {
+			if _, err := fmt.Sscanf(slaP95Entry.Text, "%f", &slaP95); err != nil || slaP95 < 0 {
+				failRun(fmt.Errorf("SLA P95 inválido: %q (usa un valor en ms)", slaP95Entry.Text))
+				return
+			}
 		}
 
+		liveChart := !noLiveChartCheck.Checked // Se lee acá: el callback corre fuera del hilo de la UI
+
 		cfg := RequestConfig{
-			URL: urlEntry.Text, Method: methodSelect.Selected,
-			Headers: headersEntry.Text, Body: bodyEntry.Text,
-			Count: count, Duration: duration, ConcurrentUsers: users,
+			URL: urlEntry.Text, Method: methodPicker.Method(),
+			Headers: headerTable.EnabledText(), Body: bodyEntry.Text,
+			ContentType: resolveContentType(contentTypeSelect.Text, bodyEntry.Text, bodyFilePath),
+			Count:       count, Duration: duration, ConcurrentUsers: users,
 			User: userEntry.Text, Secret: secretEntry.Text,
+			AuthType: authTypeSelect.Selected, NTLMDomain: ntlmDomainEntry.Text, NTLMUser: ntlmUserEntry.Text, NTLMPassword: ntlmPasswordEntry.Text,
+			LogFile: logFile, LogBodies: logBodiesCheck.Checked, LogBodyMaxBytes: logBodyMax,
+			SlowThresholdMs:        slowThreshold,
+			SLAP95Ms:               slaP95,
+			UseCookieJar:           cookieJarCheck.Checked,
+			BodyFile:               bodyFilePath,
+			BodyDir:                bodyDirPath,
+			RandomizeBodies:        randomizeBodiesCheck.Checked,
+			URLs:                   urlList,
+			RandomizeURLs:          randomizeURLsCheck.Checked,
+			Note:                   strings.TrimSpace(noteEntry.Text),
+			StopIfErrorRateExceeds: stopErrorRate, ErrorRateWindow: errorWindow,
+			Endpoints:      endpoints,
+			Scenario:       harScenario,
+			CountMode:      countMode,
+			TagRequests:    tagRequestsCheck.Checked,
+			TimeoutSeconds: timeoutSeconds, DisableRedirects: disableRedirectsCheck.Checked,
+			ConnectTimeoutMs: connectTimeoutMs, UnixSocketPath: unixSocket,
+			ResponseSchema: responseSchemaEntry.Text, MaxRetainedResults: maxResults,
+			PreRequestScript: preRequestEntry.Text,
+			GRPCMethod:       strings.TrimSpace(grpcMethodEntry.Text),
+			Percentiles:      percentiles, RequestDeadlineMs: deadlineMs, MaxRunDurationSeconds: maxRunSeconds, TargetRPS: targetRPS,
+			AllowBodyAllMethods: allowBodyCheck.Checked,
+			SuccessStatusMin:    successMin, SuccessStatusMax: successMax,
+			UserAgent: userAgentEntry.Text, AbortOnFirstError: abortOnFirstErrorCheck.Checked,
+			SeparateRateLimited: separateRateLimitedCheck.Checked,
+			HonorRetryAfter:     honorRetryAfterCheck.Checked,
+			ForceHTTP1:          forceHTTP1Check.Checked, WarmupSeconds: warmup,
+			MaxBodyCaptureBytes: maxBodyKB * 1024,
 		}
+		cfg = expandEnvTokens(cfg, envVars)
+		if isWebSocketURL(cfg.URL) || isGRPCURL(cfg.URL) {
+			if err := sessionOptionsError(cfg); err != nil {
+				failRun(err)
+				return
+			}
+		}
+		chartWidget.SetSuccessCriteria(cfg)
 
 		// La consola se actualizará DESPUÉS de construir la request real con todos los headers
 
@@ -1698,11 +8774,19 @@ Timestamp: %s
 		statsChan := make(chan BenchmarkStats)
 		progressChan := make(chan float64)
 
-		// Goroutine para manejar progreso
+		// Goroutine para manejar progreso. El porcentaje y el throughput se muestran también en el título
+		// de la ventana (visible en la barra de tareas), con un mínimo de TitleUpdateInterval entre cambios
+		baseTitle := myWindow.Title()
+		var liveRPS float64 // Último throughput parcial (se lee y escribe en el hilo de la UI)
+		var lastTitleUpdate time.Time
 		go func() {
 			for progress := range progressChan {
 				fyne.Do(func() {
 					progressBar.SetValue(progress)
+					if isRunning && time.Since(lastTitleUpdate) >= TitleUpdateInterval {
+						lastTitleUpdate = time.Now()
+						myWindow.SetTitle(fmt.Sprintf("%.0f%% · %.1f req/s - %s", min(progress, 1)*100, liveRPS, baseTitle))
+					}
 				})
 			}
 		}()
@@ -1713,190 +8797,158 @@ Timestamp: %s
 			defer close(resultChan)
 			defer close(statsChan)
 
-			// Si count == 1 Y es modo "Por Cantidad", ejecutar request única y capturar respuesta completa
-			if count == 1 && duration == 0 {
-				client := &http.Client{Timeout: 10 * time.Second}
-				var bodyReader io.Reader
-				if cfg.Body != "" {
-					bodyReader = strings.NewReader(cfg.Body)
-				}
-
-				req, err := http.NewRequest(cfg.Method, cfg.URL, bodyReader)
-				if err == nil {
-					timestamp := time.Now().Format(time.RFC3339)
-					req.Header.Set("X-Timestamp", timestamp)
-
-					if cfg.ContentType != "" {
-						req.Header.Set("Content-Type", cfg.ContentType)
-					}
-
-					if cfg.Headers != "" {
-						for _, line := range strings.Split(cfg.Headers, "\n") {
-							parts := strings.SplitN(line, ":", 2)
-							if len(parts) == 2 {
-								req.Header.Set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
-							}
-						}
+			// Barrido de concurrencia: un test por nivel; se muestran los resultados del último
+			if sweepLevels != nil {
+				points, results := runConcurrencySweep(cfg, sweepLevels, func(p float64) {
+					select {
+					case progressChan <- p:
+					default:
 					}
+				}, cancelChan)
+				var stats BenchmarkStats
+				if len(points) > 0 {
+					stats = points[len(points)-1].Stats
+				}
+				stats.Note = cfg.Note
+				sweep = points
+				resultChan <- results
+				statsChan <- stats
+				return
+			}
 
-					var authInfo string
-					if cfg.User != "" && cfg.Secret != "" {
-						sig := generateHMACSignature(cfg.Secret, timestamp)
-						req.Header.Set("Authorization", fmt.Sprintf("HMAC %s:%s", cfg.User, sig))
-						authInfo = fmt.Sprintf("HMAC - User: %s, Signature: %s", cfg.User, sig)
-					} else {
-						authInfo = "Sin autenticación"
+			// Búsqueda del RPS máximo: un test por escalón de TargetRPS; se muestran los resultados del último
+			if maxRPSStart > 0 {
+				steps, knee, results := findMaxRPS(cfg, maxRPSStart, maxRPSStep, slaP95, func(p float64) {
+					select {
+					case progressChan <- p:
+					default:
 					}
+				}, cancelChan)
+				var stats BenchmarkStats
+				if len(steps) > 0 {
+					stats = steps[len(steps)-1].Stats
+				}
+				stats.Note = cfg.Note
+				rpsSteps, rpsKnee = steps, knee
+				resultChan <- results
+				statsChan <- stats
+				return
+			}
 
-					// Capturar headers reales construidos
-					var actualHeaders strings.Builder
-					for name, values := range req.Header {
-						for _, value := range values {
-							actualHeaders.WriteString(fmt.Sprintf("%s: %s\n", name, value))
-						}
-					}
+			// Si se espera 1 sola request Y es modo "Por Cantidad", ejecutar request única y capturar respuesta completa
+			if totalRequests == 1 && duration == 0 && !isWebSocketURL(cfg.URL) && !isGRPCURL(cfg.URL) {
+				cfg, err := loadBodyFile(cfg)
+				if err != nil {
+					resultChan <- nil
+					statsChan <- BenchmarkStats{Aborted: true, AbortReason: fmt.Sprintf("archivo de body: %v", err)}
+					return
+				}
+				single := executeSingleRequest(cfg, 1)
+				result := single.Result
+				duration := result.Duration
 
-					// Actualizar consola con datos reales DESPUÉS de construir la request
+				// Actualizar consola con la request realmente enviada
+				if single.Request != nil {
 					fyne.Do(func() {
 						updateConsole(RequestDetails{
-							Method:    req.Method,
-							URL:       req.URL.String(),
-							Headers:   actualHeaders.String(),
-							Body:      cfg.Body,
-							Timestamp: timestamp,
-							Auth:      authInfo,
+							Method:    single.Request.Method,
+							URL:       single.Request.URL.String(),
+							Headers:   formatHeaders(single.Request.Header),
+							Body:      describeBody(cfg),
+							Timestamp: single.Request.Header.Get("X-Timestamp"),
+							Auth:      single.AuthInfo,
 						})
 					})
+				}
 
-					start := time.Now()
-					resp, err := client.Do(req)
-					duration := float64(time.Since(start).Milliseconds())
-
-					status := 0
-					var responseBody string
-					if err == nil {
-						status = resp.StatusCode
-						bodyBytes, _ := io.ReadAll(resp.Body)
-						resp.Body.Close()
-						responseBody = string(bodyBytes)
-					} else {
-						responseBody = fmt.Sprintf("Error: %v", err)
-					}
-
-					// Enviar resultado
-					result := BenchmarkResult{
-						Seq:       1,
-						Timestamp: start.Format("15:04:05"),
-						Duration:  duration,
-						Status:    status,
-					}
-
-					// Guardar responseBody en un canal separado
-					responseChan := make(chan string, 1)
-					responseChan <- responseBody
-					close(responseChan)
+				success := 0
+				if succeeded(result, cfg) {
+					success = 1
+				}
+				slowCount := 0
+				if cfg.SlowThresholdMs > 0 && duration > float64(cfg.SlowThresholdMs) {
+					slowCount = 1
+				}
+				rps := 0.0
+				if duration > 0 {
+					rps = 1.0 / (duration / 1000.0)
+				}
 
-					// Actualizar UI
-					fyne.Do(func() {
-						responseText := fmt.Sprintf("STATUS: %d\nDURATION: %.2f ms\nTIMESTAMP: %s\n\n--- RESPONSE BODY ---\n\n%s",
-							status, duration, start.Format("15:04:05"), <-responseChan)
-						responseViewer.SetText(responseText)
-
-						// Cambiar a vista de respuesta
-						rightContentArea.Objects = []fyne.CanvasObject{
-							canvas.NewRectangle(color.NRGBA{R: 25, G: 25, B: 25, A: 255}),
-							container.NewScroll(responseViewer),
-						}
-						rightContentArea.Refresh()
-					})
+				// Actualizar UI
+				fyne.Do(func() {
+					showResponse(single)
+				})
 
-					resultChan <- []BenchmarkResult{result}
-					statsChan <- BenchmarkStats{
-						Avg:               duration,
-						Min:               duration,
-						Max:               duration,
-						P90:               duration,
-						P95:               duration,
-						P99:               duration,
-						Success:           1,
-						Total:             1,
-						ErrorRate:         0,
-						RequestsPerSecond: 1.0 / (duration / 1000.0),
-						TotalDuration:     duration,
-					}
+				resultChan <- []BenchmarkResult{result}
+				statsChan <- BenchmarkStats{
+					Avg:               duration,
+					Min:               duration,
+					Max:               duration,
+					PercentileValues:  computePercentiles([]float64{duration}, cfg.Percentiles),
+					Success:           success,
+					Total:             1,
+					ErrorRate:         (1 - success) * 100,
+					RequestsPerSecond: rps,
+					TotalDuration:     duration,
+					SlowThresholdMs:   cfg.SlowThresholdMs,
+					SlowCount:         slowCount,
 				}
 			} else {
 				// Modo benchmark (múltiples requests)
+				// El sparkline se llena con los resultados parciales de esta ejecución
+				fyne.Do(func() {
+					sparkline.SetData(nil)
+					inFlightGauge.SetValue(0, cfg.ConcurrentUsers)
+					inFlightView.Show()
+				})
 				// Construir una request de ejemplo para mostrar en consola
-				var bodyReader io.Reader
-				if cfg.Body != "" {
-					bodyReader = strings.NewReader(cfg.Body)
-				}
-				sampleReq, _ := http.NewRequest(cfg.Method, cfg.URL, bodyReader)
-				if sampleReq != nil {
-					timestamp := time.Now().Format(time.RFC3339)
-					sampleReq.Header.Set("X-Timestamp", timestamp)
-					if cfg.ContentType != "" {
-						sampleReq.Header.Set("Content-Type", cfg.ContentType)
-					}
-					if cfg.Headers != "" {
-						for _, line := range strings.Split(cfg.Headers, "\n") {
-							parts := strings.SplitN(line, ":", 2)
-							if len(parts) == 2 {
-								sampleReq.Header.Set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
-							}
-						}
-					}
-					var authInfo string
-					if cfg.User != "" && cfg.Secret != "" {
-						sig := generateHMACSignature(cfg.Secret, timestamp)
-						sampleReq.Header.Set("Authorization", fmt.Sprintf("HMAC %s:%s", cfg.User, sig))
-						authInfo = fmt.Sprintf("HMAC - User: %s, Signature: %s", cfg.User, sig)
-					} else {
-						authInfo = "Sin autenticación"
-					}
-
-					// Capturar headers reales
-					var actualHeaders strings.Builder
-					for name, values := range sampleReq.Header {
-						for _, value := range values {
-							actualHeaders.WriteString(fmt.Sprintf("%s: %s\n", name, value))
-						}
-					}
-
-					// Actualizar consola con datos reales
+				if sampleReq, authInfo, err := buildRequest(cfg); err == nil {
 					fyne.Do(func() {
 						updateConsole(RequestDetails{
 							Method:    sampleReq.Method,
 							URL:       sampleReq.URL.String(),
-							Headers:   actualHeaders.String(),
-							Body:      cfg.Body,
-							Timestamp: timestamp,
+							Headers:   formatHeaders(sampleReq.Header),
+							Body:      describeBody(cfg),
+							Timestamp: sampleReq.Header.Get("X-Timestamp"),
 							Auth:      authInfo,
 						})
 					})
 				}
 
-				results, stats := runLoadTest(cfg, func(p float64) {
+				results, stats := testRunner(cfg.URL)(cfg, func(p float64) {
 					select {
 					case progressChan <- p:
 					default:
 					}
 				}, cancelChan, func(partialResults []BenchmarkResult, partialStats BenchmarkStats) {
-					// Actualizar UI en tiempo real
 					fyne.Do(func() {
-						chartWidget.SetData(partialResults)
+						inFlightGauge.SetValue(partialStats.InFlight, cfg.ConcurrentUsers)
+						liveRPS = partialStats.RequestsPerSecond
+					})
+					if !liveChart {
+						fyne.Do(func() {
+							liveCounter.SetText(fmt.Sprintf("%d requests completadas\n(el gráfico se dibuja al terminar)", partialStats.Total))
+							if len(rightContentArea.Objects) < 2 || rightContentArea.Objects[1] != liveCounterView {
+								rightContentArea.Objects = []fyne.CanvasObject{chartBg, liveCounterView}
+								rightContentArea.Refresh()
+							}
+						})
+						return
+					}
+					// Actualizar UI en tiempo real (partialResults son solo los resultados nuevos)
+					fyne.Do(func() {
+						chartWidget.AppendData(partialResults, cfg.MaxRetainedResults)
+						sparkline.Append(partialResults...)
 
 						// Actualizar estadísticas
-						avgBind.Set(fmt.Sprintf("%.0f ms", partialStats.Avg))
-						minBind.Set(fmt.Sprintf("%.0f ms", partialStats.Min))
-						maxBind.Set(fmt.Sprintf("%.0f ms", partialStats.Max))
+						avgBind.Set(formatDuration(partialStats.Avg, latencyDecimals))
+						minBind.Set(formatDuration(partialStats.Min, latencyDecimals))
+						maxBind.Set(formatDuration(partialStats.Max, latencyDecimals))
 						if partialStats.Total > 0 {
 							successBind.Set(fmt.Sprintf("%.2f%%", float64(partialStats.Success)/float64(partialStats.Total)*100))
 						}
 
-						statsContainer.Objects = createAdvancedStatsWidgets(partialStats)
-						statsContainer.Refresh()
+						showAdvancedStats(partialStats)
 
 						// Asegurar que está en vista de gráfico
 						if len(rightContentArea.Objects) == 0 || rightContentArea.Objects[0] != chartBg {
@@ -1906,6 +8958,7 @@ Timestamp: %s
 					})
 				})
 
+				stats.Note = cfg.Note
 				resultChan <- results
 				statsChan <- stats
 			}
@@ -1918,8 +8971,20 @@ Timestamp: %s
 
 			// Usar fyne.Do para actualizar UI en el main thread
 			fyne.Do(func() {
-				// Solo actualizar gráfico si hay más de 1 request
-				if count > 1 {
+				lastResults = results
+				lastRunURL = cfg.URL
+				lastRunCfg = cfg
+				lastStats = stats
+
+				// El sparkline acumula las requests únicas para ver su tendencia entre ejecuciones
+				if totalRequests == 1 && duration == 0 {
+					sparkline.Append(results...)
+				} else {
+					sparkline.SetData(results)
+				}
+
+				// Solo actualizar gráfico si hay más de 1 request (en modo por tiempo siempre)
+				if totalRequests > 1 || duration > 0 {
 					chartWidget.SetData(results)
 
 					// Cambiar a vista de gráfico
@@ -1930,14 +8995,38 @@ Timestamp: %s
 					rightContentArea.Refresh()
 				}
 
-				// Si hay muchos datos y no estamos en pantalla completa, sugerir el cambio
-				if len(results) >= 30 && chartWidget.GetViewMode() != ViewModeFullScreen && !isFullScreen {
+				// Modo debug: mostrar la respuesta que detuvo el test y la request que la produjo
+				if failure := stats.FirstFailure; failure != nil {
+					showResponse(*failure)
+					if failure.Request != nil {
+						updateConsole(RequestDetails{
+							Method:    failure.Request.Method,
+							URL:       failure.Request.URL.String(),
+							Headers:   formatHeaders(failure.Request.Header),
+							Body:      describeBody(cfg),
+							Timestamp: failure.Request.Header.Get("X-Timestamp"),
+							Auth:      failure.AuthInfo,
+						})
+					}
+				}
+
+				// Si hay muchos datos y no estamos en pantalla completa, sugerir el cambio (umbral configurable)
+				suggestThreshold := myApp.Preferences().IntWithFallback(fullScreenSuggestKey, DefaultFullScreenSuggestThreshold)
+				if suggestThreshold > 0 && len(results) >= suggestThreshold && chartWidget.GetViewMode() != ViewModeFullScreen && !isFullScreen {
 					go func() {
 						time.Sleep(500 * time.Millisecond) // Esperar un poco antes de mostrar el diálogo
 						fyne.Do(func() {
-							dialog.ShowConfirm("Pantalla Completa Recomendada",
-								"Se detectó un test con muchos datos. ¿Deseas cambiar a vista de pantalla completa para mejor visualización?",
+							dontAskCheck := widget.NewCheck("No volver a preguntar", nil)
+							content := container.NewVBox(
+								widget.NewLabel("Se detectó un test con muchos datos. ¿Deseas cambiar a vista de pantalla completa para mejor visualización?"),
+								dontAskCheck,
+							)
+							dialog.ShowCustomConfirm("Pantalla Completa Recomendada", "Sí", "No", content,
 								func(response bool) {
+									if dontAskCheck.Checked {
+										myApp.Preferences().SetInt(fullScreenSuggestKey, 0)
+										fullScreenSuggestEntry.SetText("0")
+									}
 									if response {
 										fullScreenBtn.OnTapped() // Activar pantalla completa
 									}
@@ -1947,13 +9036,12 @@ Timestamp: %s
 				}
 
 				// Actualizar estadísticas con más detalle
-				avgBind.Set(fmt.Sprintf("%.0f ms", stats.Avg))
-				minBind.Set(fmt.Sprintf("%.0f ms", stats.Min))
-				maxBind.Set(fmt.Sprintf("%.0f ms", stats.Max))
+				avgBind.Set(formatDuration(stats.Avg, latencyDecimals))
+				minBind.Set(formatDuration(stats.Min, latencyDecimals))
+				maxBind.Set(formatDuration(stats.Max, latencyDecimals))
 				successBind.Set(fmt.Sprintf("%.2f%%", float64(stats.Success)/float64(stats.Total)*100))
 
-				statsContainer.Objects = createAdvancedStatsWidgets(stats)
-				statsContainer.Refresh()
+				showAdvancedStats(stats)
 
 				// Restaurar botón
 				runBtn.SetText("Ejecutar Request")
@@ -1961,19 +9049,129 @@ Timestamp: %s
 				runBtn.Enable()
 				isRunning = false
 				progressBar.Hide()
+				inFlightView.Hide()
+				myWindow.SetTitle(baseTitle)
 
 				// Mostrar resumen solo si es más de 1 request
-				if count > 1 || duration > 0 {
-					modeDesc := fmt.Sprintf("%d peticiones", stats.Total)
+				if maxRPSStart > 0 {
+					if len(rpsSteps) == 0 {
+						dialog.ShowInformation("RPS máximo sostenible", "El barrido no completó ningún escalón.", myWindow)
+					} else {
+						curve := make([]SaturationPoint, len(rpsSteps))
+						for i, step := range rpsSteps {
+							curve[i] = SaturationPoint{Load: float64(step.TargetRPS), P95: step.P95, Failed: step.Failure != ""}
+						}
+						chart := NewSaturationChart("RPS objetivo", curve, rpsKnee, latencyDecimals)
+						table := widget.NewLabelWithStyle(formatRPSTable(rpsSteps, rpsKnee, latencyDecimals), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+						dialog.ShowCustom("RPS máximo sostenible", "Cerrar", container.NewVBox(chart, table), myWindow)
+					}
+				} else if sweepLevels != nil {
+					if len(sweep) == 0 {
+						dialog.ShowInformation("Curva de saturación", "El barrido no completó ningún nivel.", myWindow)
+					} else {
+						title := "Curva de saturación"
+						if len(sweep) < len(sweepLevels) {
+							title += fmt.Sprintf(" (%d de %d niveles)", len(sweep), len(sweepLevels))
+						}
+						table := widget.NewLabelWithStyle(formatSweepTable(sweep, latencyDecimals), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+						curve := make([]SaturationPoint, len(sweep))
+						for i, p := range sweep {
+							curve[i] = SaturationPoint{Load: float64(p.Users), P95: p.P95}
+						}
+						chart := NewSaturationChart("Usuarios concurrentes", curve, -1, latencyDecimals)
+						dialog.ShowCustom(title, "Cerrar", container.NewVBox(chart, table), myWindow)
+					}
+				} else if stats.Aborted && stats.Total == 0 {
+					// El test no llegó a ejecutar ninguna request (ej. método gRPC no encontrado)
+					dialog.ShowError(fmt.Errorf("el test no pudo iniciarse: %s", stats.AbortReason), myWindow)
+				} else if totalRequests > 1 || duration > 0 {
+					modeDesc := fmt.Sprintf("%d peticiones (cantidad total)", stats.Total)
+					if countMode == CountModePerUser {
+						modeDesc = fmt.Sprintf("%d peticiones (%d por usuario)", stats.Total, count)
+					}
 					if duration > 0 {
 						modeDesc = fmt.Sprintf("%d segundos - %d peticiones realizadas", duration, stats.Total)
 					}
 
-					summary := fmt.Sprintf("Test completado:\n\n%s\nUsuarios concurrentes: %d\nSuccessful: %d (%.1f%%)\nFailed: %d\nAvg response: %.1f ms\nRequests/sec: %.1f",
+					notePrefix := ""
+					if stats.Note != "" {
+						notePrefix = "📝 " + stats.Note + "\n\n"
+					}
+					summary := notePrefix + fmt.Sprintf("Test completado:\n\n%s\nUsuarios concurrentes: %d\nSuccessful: %d (%.1f%%)\nFailed: %d\nAvg response: %.1f ms\nRequests/sec: %.1f",
 						modeDesc, users, stats.Success, float64(stats.Success)/float64(stats.Total)*100,
-						stats.Total-stats.Success, stats.Avg, stats.RequestsPerSecond)
-					dialog.ShowInformation("Benchmark Completado", summary, myWindow)
-				} else {
+						stats.Total-stats.Success-stats.RateLimited, stats.Avg, stats.RequestsPerSecond)
+					if stats.SteadyRequestsPerSecond > 0 {
+						summary += fmt.Sprintf("\nRequests/sec (régimen estable): %.1f", stats.SteadyRequestsPerSecond)
+					}
+					if stats.RateLimited > 0 {
+						summary += fmt.Sprintf("\nRate limited (429): %d", stats.RateLimited)
+					}
+					if stats.Throttled > 0 {
+						summary += fmt.Sprintf("\nThrottled (Retry-After respetado): %d", stats.Throttled)
+					}
+					for _, p := range sortedPercentiles(stats.PercentileValues) {
+						summary += fmt.Sprintf("\n%s: %.1f ms", formatPercentileLabel(p), stats.PercentileValues[p])
+					}
+					if buckets := formatLatencyBuckets(results, SummaryBucketBounds); buckets != "" {
+						summary += "\nDistribución: " + buckets
+					}
+					// Desglose por endpoint en modo multi-endpoint (o por URL con una lista de URLs),
+					// del peor al mejor P95, señalando el más lento y el de más errores
+					if len(stats.Endpoints) > 0 {
+						var breakdown strings.Builder
+						if len(cfg.Endpoints) == 0 && len(cfg.Scenario) == 0 {
+							breakdown.WriteString("\n\nPor URL (peor P95 primero):")
+						} else {
+							breakdown.WriteString("\n\nPor endpoint (peor P95 primero):")
+						}
+						ranked := rankEndpoints(stats.Endpoints)
+						worstErrors := ranked[0]
+						for i, es := range ranked {
+							breakdown.WriteString(fmt.Sprintf("\n%d. %s: %d req, %d OK, P95 %s, errores %.1f%%, avg %s (min %s / max %s)",
+								i+1, es.Name, es.Total, es.Success, formatDuration(es.P95, latencyDecimals), es.ErrorRate, formatDuration(es.Avg, latencyDecimals), formatDuration(es.Min, latencyDecimals), formatDuration(es.Max, latencyDecimals)))
+							if es.ErrorRate > worstErrors.ErrorRate {
+								worstErrors = es
+							}
+						}
+						if len(ranked) > 1 {
+							breakdown.WriteString(fmt.Sprintf("\n🐢 Peor P95: %s (%s)", ranked[0].Name, formatDuration(ranked[0].P95, latencyDecimals)))
+							if worstErrors.ErrorRate > 0 {
+								breakdown.WriteString(fmt.Sprintf("\n❌ Mayor error rate: %s (%.1f%%)", worstErrors.Name, worstErrors.ErrorRate))
+							}
+						}
+						summary += breakdown.String()
+					}
+					if stats.ConnLimitHit {
+						summary += "\n\n⚠️ El sistema operativo rechazó conexiones (too many open files). " +
+							"Reduce los usuarios concurrentes o aumenta el límite de descriptores (ulimit -n)."
+					}
+					if stats.LogError != "" {
+						summary += fmt.Sprintf("\n\n⚠️ El log de requests quedó incompleto: %s", stats.LogError)
+					}
+					if stats.Total > len(results) {
+						summary += fmt.Sprintf("\n\n📦 Se conservan los últimos %d de %d resultados (las estadísticas cubren todos)", len(results), stats.Total)
+					}
+					if stats.ConnectTimeoutCount > 0 {
+						summary += fmt.Sprintf("\n\n🔌 %d requests no lograron conectar (timeout de conexión)", stats.ConnectTimeoutCount)
+					}
+					if stats.TimeoutCount > 0 {
+						summary += fmt.Sprintf("\n\n⏱️ %d requests cortadas por timeout", stats.TimeoutCount)
+					}
+					if stats.AvgTTFBMs > 0 {
+						summary += fmt.Sprintf("\n\nDesglose promedio: DNS %.1f ms, TCP %.1f ms, TLS %.1f ms (en %d conexiones nuevas), TTFB %.1f ms, TTLB %.1f ms",
+							stats.AvgDNSMs, stats.AvgConnectMs, stats.AvgTLSMs, stats.NewConnections, stats.AvgTTFBMs, stats.AvgTTLBMs)
+					}
+					title := "Benchmark Completado"
+					if stats.Aborted {
+						title = "Benchmark Abortado"
+						summary = fmt.Sprintf("⚠️ Test abortado automáticamente por %s\n\n%s", stats.AbortReason, summary)
+					}
+					// El resumen se puede copiar tal cual (ej. para pegarlo en un chat)
+					copyBtn := widget.NewButtonWithIcon("Copiar resumen", theme.ContentCopyIcon(), func() {
+						myApp.Clipboard().SetContent(fmt.Sprintf("%s\n\n%s", title, summary))
+					})
+					dialog.ShowCustom(title, "Cerrar", container.NewVBox(widget.NewLabel(summary), copyBtn), myWindow)
+				} else if len(results) > 0 {
 					dialog.ShowInformation("Request Completado", fmt.Sprintf("Status: %d\nDuration: %.2f ms", results[0].Status, results[0].Duration), myWindow)
 				}
 			})
@@ -1983,8 +9181,11 @@ Timestamp: %s
 	leftPanel := container.NewBorder(
 		container.NewVBox(
 			importBtn,
+			exportBtn,
+			saveAsNewBtn,
 			curlBtn,
 			widget.NewSeparator(),
+			treeFilterEntry,
 		),
 		nil, nil, nil,
 		postmanTree,
@@ -1995,7 +9196,7 @@ Timestamp: %s
 		nil, nil,
 		container.NewHBox(
 			widget.NewLabelWithStyle("🔧 Método:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
-			methodSelect,
+			methodPicker.Container(),
 			widget.NewSeparator(),
 			widget.NewLabelWithStyle("⏱️ Modo:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 			testModeSelect,
@@ -2004,31 +9205,46 @@ Timestamp: %s
 			widget.NewSeparator(),
 			widget.NewLabelWithStyle("👥 Usuarios:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 			usersEntry,
+			widget.NewSeparator(),
+			widget.NewLabelWithStyle("🐢 SLA:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			slaEntry,
+			slaP95Entry,
 		),
 		container.NewHBox(
+			cacheCheckBtn,
+			coldWarmBtn,
 			runBtn,
 		),
-		urlEntry,
+		container.NewBorder(nil, nil, nil, recentURLsBtn, urlEntry),
+		container.NewBorder(nil, nil, urlListBtn, container.NewHBox(randomizeURLsCheck, urlListClearBtn), urlListLabel),
+		container.NewBorder(nil, nil, widget.NewLabel("📝"), nil, noteEntry),
 	)
 
 	// Contenedor de configuración con mejor organización visual
 	// Card para Auth
 	authCard := container.NewVBox(
 		container.NewHBox(
-			widget.NewLabelWithStyle("• Autenticación HMAC", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle("• Autenticación", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			authTypeSelect,
 		),
-		container.NewGridWithColumns(2, userEntry, secretEntry),
+		hmacFields,
+		ntlmFields,
 	)
 	authBg := canvas.NewRectangle(color.NRGBA{R: 45, G: 45, B: 50, A: 255})
 	authSection := container.NewStack(authBg, container.NewPadded(authCard))
 
 	// Card para Headers
 	headersCard := container.NewVBox(
-		container.NewHBox(
-			widget.NewLabelWithStyle("• Headers", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
-			widget.NewLabel("(uno por línea)"),
+		container.NewBorder(
+			nil, nil,
+			container.NewHBox(
+				widget.NewLabelWithStyle("• Headers", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabel("(desmarca para no enviar)"),
+			),
+			headersTextBtn,
+			nil,
 		),
-		headersEntry,
+		headerTable.Container(),
 	)
 	headersBg := canvas.NewRectangle(color.NRGBA{R: 45, G: 45, B: 50, A: 255})
 	headersSection := container.NewStack(headersBg, container.NewPadded(headersCard))
@@ -2042,19 +9258,149 @@ Timestamp: %s
 			nil, nil,
 			container.NewHBox(
 				widget.NewLabelWithStyle("• Body", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
-				widget.NewLabel("(JSON, XML, etc.)"),
+				widget.NewLabel("(JSON, XML, etc. — mensaje en ws://)"),
 			),
 			formatBtn,
-			nil,
+			bodySyntaxLabel,
 		),
+		container.NewBorder(nil, nil, widget.NewLabel("Content-Type:"), nil, contentTypeSelect),
 		bodyScroll,
+		container.NewBorder(nil, nil, bodyFileBtn, bodyFileClearBtn, bodyFileLabel),
+		container.NewBorder(nil, nil, bodyDirBtn, container.NewHBox(randomizeBodiesCheck, bodyDirClearBtn), bodyDirLabel),
+		allowBodyCheck,
 	)
 	bodyBg := canvas.NewRectangle(color.NRGBA{R: 45, G: 45, B: 50, A: 255})
 	bodySection := container.NewStack(bodyBg, container.NewPadded(bodyCard))
 
+	// Card para validación de respuestas con JSON Schema
+	schemaCard := container.NewVBox(
+		container.NewHBox(
+			widget.NewLabelWithStyle("• Validación de respuesta", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabel("(JSON Schema; las respuestas 2xx que no lo cumplen cuentan como error)"),
+		),
+		responseSchemaEntry,
+	)
+	schemaBg := canvas.NewRectangle(color.NRGBA{R: 45, G: 45, B: 50, A: 255})
+	schemaSection := container.NewStack(schemaBg, container.NewPadded(schemaCard))
+
+	// Card para el script pre-request
+	preRequestCard := container.NewVBox(
+		container.NewHBox(
+			widget.NewLabelWithStyle("• Script pre-request", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabel("(JavaScript; modifica request.headers y request.body; hmacSHA256 y sha256 para firmar)"),
+		),
+		preRequestEntry,
+	)
+	preRequestBg := canvas.NewRectangle(color.NRGBA{R: 45, G: 45, B: 50, A: 255})
+	preRequestSection := container.NewStack(preRequestBg, container.NewPadded(preRequestCard))
+
+	// Card para Log de requests
+	logCard := container.NewVBox(
+		container.NewHBox(
+			widget.NewLabelWithStyle("• Log de Requests", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabel("(JSON por línea)"),
+		),
+		container.NewBorder(nil, nil, nil, logBrowseBtn, logFileEntry),
+		container.NewBorder(nil, nil, logBodiesCheck, nil, logBodyMaxEntry),
+	)
+	logBg := canvas.NewRectangle(color.NRGBA{R: 45, G: 45, B: 50, A: 255})
+	logSection := container.NewStack(logBg, container.NewPadded(logCard))
+
+	// Card para Multi-endpoint
+	endpointsCard := container.NewVBox(
+		container.NewHBox(
+			widget.NewLabelWithStyle("• Multi-endpoint", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabel("(peso MÉTODO URL [body], uno por línea)"),
+		),
+		endpointsEntry,
+		container.NewBorder(nil, nil, harBtn, container.NewHBox(harStepsBtn, harClearBtn), harLabel),
+	)
+	endpointsBg := canvas.NewRectangle(color.NRGBA{R: 45, G: 45, B: 50, A: 255})
+	endpointsSection := container.NewStack(endpointsBg, container.NewPadded(endpointsCard))
+
+	// Card para gRPC
+	grpcCard := container.NewVBox(
+		container.NewHBox(
+			widget.NewLabelWithStyle("• gRPC", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabel("(URL grpc:// o grpcs://, Body en JSON)"),
+		),
+		grpcMethodEntry,
+	)
+	grpcBg := canvas.NewRectangle(color.NRGBA{R: 45, G: 45, B: 50, A: 255})
+	grpcSection := container.NewStack(grpcBg, container.NewPadded(grpcCard))
+
+	// Card para Opciones de ejecución
+	// Ajustes persistentes agrupados en pestañas, fuera del panel principal
+	settingsTabs := container.NewAppTabs(
+		container.NewTabItem("Red", container.NewVBox(
+			container.NewHBox(widget.NewLabel("Timeout por request (s):"), timeoutEntry),
+			container.NewHBox(widget.NewLabel("Timeout de conexión (ms):"), connectTimeoutEntry),
+			container.NewBorder(nil, nil, widget.NewLabel("User-Agent:"), nil, userAgentEntry),
+			container.NewHBox(widget.NewLabel("Body capturado máx. (KB):"), maxBodyEntry),
+			container.NewHBox(widget.NewLabel("Confirmar ejecuciones de más de"), safeTotalEntry, widget.NewLabel("requests")),
+		)),
+		container.NewTabItem("Estadísticas", container.NewVBox(
+			container.NewBorder(nil, nil, widget.NewLabel("Percentiles:"), nil, percentilesEntry),
+			container.NewHBox(widget.NewLabel("Resultados conservados en memoria:"), maxResultsEntry),
+			container.NewHBox(widget.NewLabel("Decimales en latencias (ms):"), decimalsEntry),
+			steadyRPSCheck,
+		)),
+		container.NewTabItem("Gráfico", container.NewVBox(
+			container.NewHBox(widget.NewLabel("Tema:"), chartThemeSelect, seriesColorsBtn),
+			container.NewHBox(widget.NewLabel("Sugerir pantalla completa desde"), fullScreenSuggestEntry, widget.NewLabel("resultados")),
+			tooltipsCheck,
+			container.NewHBox(widget.NewLabel("Radio de detección del tooltip (px):"), hoverRadiusEntry),
+			container.NewHBox(widget.NewLabel("Ventana del P95 móvil:"), rollingWindowEntry, widget.NewLabel("requests")),
+		)),
+		container.NewTabItem("Exportación", container.NewVBox(
+			newBoldLabel("InfluxDB", fyne.TextAlignLeading),
+			container.NewBorder(nil, nil, widget.NewLabel("URL de escritura:"), nil, influxURLEntry),
+			container.NewBorder(nil, nil, widget.NewLabel("Token:"), nil, influxTokenEntry),
+			newBoldLabel("Prometheus Pushgateway", fyne.TextAlignLeading),
+			container.NewBorder(nil, nil, widget.NewLabel("URL:"), nil, pushgatewayEntry),
+			container.NewBorder(nil, nil, widget.NewLabel("Job:"), nil, promJobEntry),
+		)),
+	)
+	settingsBtn := widget.NewButtonWithIcon("Ajustes", theme.SettingsIcon(), func() {
+		d := dialog.NewCustom("Ajustes", "Cerrar", settingsTabs, myWindow)
+		d.Resize(fyne.NewSize(560, 340))
+		d.Show()
+	})
+
+	optionsCard := container.NewVBox(
+		widget.NewLabelWithStyle("• Opciones de Ejecución", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		cookieJarCheck,
+		tagRequestsCheck,
+		disableRedirectsCheck,
+		forceHTTP1Check,
+		noLiveChartCheck,
+		abortOnFirstErrorCheck,
+		separateRateLimitedCheck,
+		honorRetryAfterCheck,
+		container.NewBorder(nil, nil, sweepCheck, nil, sweepLevelsEntry),
+		container.NewHBox(widget.NewLabel("Límite de requests/s:"), targetRPSEntry),
+		container.NewBorder(nil, nil, maxRPSCheck, nil, maxRPSFields),
+		container.NewHBox(widget.NewLabel("Deadline duro por request:"), deadlineEntry),
+		container.NewHBox(widget.NewLabel("Abortar la ejecución después de:"), maxRunDurationEntry),
+		container.NewBorder(nil, nil, widget.NewLabel("Socket Unix:"), nil, unixSocketEntry),
+		container.NewBorder(nil, nil, envBtn, envClearBtn, envLabel),
+		container.NewHBox(widget.NewLabel("Status exitoso: de"), successMinEntry, widget.NewLabel("a"), successMaxEntry),
+		container.NewHBox(
+			widget.NewLabel("Abortar si error rate >"),
+			stopErrorRateEntry,
+			widget.NewLabel("% en las últimas"),
+			errorWindowEntry,
+			widget.NewLabel("requests"),
+		),
+	)
+	optionsBg := canvas.NewRectangle(color.NRGBA{R: 45, G: 45, B: 50, A: 255})
+	optionsSection := container.NewStack(optionsBg, container.NewPadded(optionsCard))
+
 	formPanel := container.NewVBox(
 		container.NewPadded(
-			widget.NewLabelWithStyle("⚙️ Configuración Request", fyne.TextAlignCenter, fyne.TextStyle{Bold: true, Italic: false}),
+			container.NewBorder(nil, nil, nil, settingsBtn,
+				widget.NewLabelWithStyle("⚙️ Configuración Request", fyne.TextAlignCenter, fyne.TextStyle{Bold: true, Italic: false}),
+			),
 		),
 		widget.NewSeparator(),
 		authSection,
@@ -2062,6 +9408,17 @@ Timestamp: %s
 		headersSection,
 		widget.NewLabel(""), // Espaciado
 		bodySection,
+		widget.NewLabel(""), // Espaciado
+		schemaSection,
+		preRequestSection,
+		widget.NewLabel(""), // Espaciado
+		endpointsSection,
+		widget.NewLabel(""), // Espaciado
+		grpcSection,
+		widget.NewLabel(""), // Espaciado
+		optionsSection,
+		widget.NewLabel(""), // Espaciado
+		logSection,
 	)
 
 	// Envolver en scroll con tamaño mínimo
@@ -2072,9 +9429,12 @@ Timestamp: %s
 	rightPanel := container.NewBorder(
 		container.NewVBox(
 			container.NewPadded(
-				widget.NewLabelWithStyle("📊 Reporte Estadístico", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+				container.NewBorder(nil, nil, nil, sparkline,
+					widget.NewLabelWithStyle("📊 Reporte Estadístico", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+				),
 			),
 			statsContainer,
+			slaBanner,
 			widget.NewSeparator(),
 			container.NewPadded(viewControlsContainer),
 		),
@@ -2101,6 +9461,7 @@ Timestamp: %s
 		container.NewVBox(
 			topBar,
 			progressBar,
+			inFlightView,
 			consoleToggleBtn,
 			consoleContainer,
 			widget.NewSeparator(),
@@ -2113,6 +9474,75 @@ Timestamp: %s
 	myWindow.ShowAndRun()
 }
 
+// proportionalLayout reparte el ancho entre los objetos según sus pesos (barra apilada)
+type proportionalLayout struct {
+	weights []float64
+}
+
+func (p *proportionalLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	total := 0.0
+	for _, w := range p.weights {
+		total += w
+	}
+	x := float32(0)
+	for i, o := range objects {
+		w := float32(0)
+		if total > 0 && i < len(p.weights) {
+			w = size.Width * float32(p.weights[i]/total)
+		}
+		o.Resize(fyne.NewSize(w, size.Height))
+		o.Move(fyne.NewPos(x, 0))
+		x += w
+	}
+}
+
+func (p *proportionalLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	return fyne.NewSize(100, 14)
+}
+
+// createTimingBreakdown genera la barra apilada DNS / TCP / TLS / servidor / resto de una request
+func createTimingBreakdown(r BenchmarkResult) fyne.CanvasObject {
+	server := r.TTFBMs - r.DNSMs - r.ConnectMs - r.TLSMs
+	if server < 0 {
+		server = 0
+	}
+	rest := r.Duration - r.TTFBMs
+	if rest < 0 || r.TTFBMs == 0 {
+		rest = 0
+	}
+
+	phases := []struct {
+		name  string
+		value float64
+		color color.NRGBA
+	}{
+		{"DNS", r.DNSMs, color.NRGBA{R: 0, G: 150, B: 136, A: 255}},
+		{"TCP", r.ConnectMs, color.NRGBA{R: 255, G: 152, B: 0, A: 255}},
+		{"TLS", r.TLSMs, color.NRGBA{R: 156, G: 39, B: 176, A: 255}},
+		{"Servidor (TTFB)", server, color.NRGBA{R: 0, G: 162, B: 232, A: 255}},
+		{"Resto", rest, color.NRGBA{R: 120, G: 120, B: 120, A: 255}},
+	}
+
+	weights := []float64{}
+	bars := []fyne.CanvasObject{}
+	legend := container.NewHBox()
+	for _, ph := range phases {
+		weights = append(weights, ph.value)
+		bars = append(bars, canvas.NewRectangle(ph.color))
+		txt := canvas.NewText(fmt.Sprintf("■ %s: %.1f ms", ph.name, ph.value), ph.color)
+		txt.TextSize = 10
+		legend.Add(txt)
+	}
+	// DNS, TCP y TLS en 0 no son fases instantáneas: la request usó una conexión ya abierta
+	if r.TTFBMs > 0 && r.DNSMs == 0 && r.ConnectMs == 0 && r.TLSMs == 0 {
+		txt := canvas.NewText("(conexión reutilizada)", color.NRGBA{R: 120, G: 120, B: 120, A: 255})
+		txt.TextSize = 10
+		legend.Add(txt)
+	}
+
+	return container.NewVBox(container.New(&proportionalLayout{weights: weights}, bars...), legend)
+}
+
 // createStatsWidgets genera las etiquetas para la tabla de estadísticas
 // Recibe los bindings y el total para la lógica de color
 func createStatsWidgets(avg, min, max, success binding.String, total int) []fyne.CanvasObject {
@@ -2153,7 +9583,7 @@ func createStatsWidgets(avg, min, max, success binding.String, total int) []fyne
 }
 
 // createAdvancedStatsWidgets genera estadísticas avanzadas como en la imagen
-func createAdvancedStatsWidgets(stats BenchmarkStats) []fyne.CanvasObject {
+func createAdvancedStatsWidgets(stats BenchmarkStats, decimals int, preferSteadyRPS bool) []fyne.CanvasObject {
 	makeAdvancedCell := func(title string, value string, bgColor color.NRGBA) *fyne.Container {
 		// Usar canvas.Text para control de tamaño de fuente
 		txtTitle := canvas.NewText(title, color.White)
@@ -2202,16 +9632,61 @@ func createAdvancedStatsWidgets(stats BenchmarkStats) []fyne.CanvasObject {
 		errorRateColor = errorColor
 	}
 
-	return []fyne.CanvasObject{
+	// Throughput total o, con preferSteadyRPS y si ya se calculó, el del régimen estable
+	rpsTitle, rps := "Requests/second", stats.RequestsPerSecond
+	if preferSteadyRPS && stats.SteadyRequestsPerSecond > 0 {
+		rpsTitle, rps = "Req/s estable", stats.SteadyRequestsPerSecond
+	}
+
+	cells := []fyne.CanvasObject{
 		makeAdvancedCell("Total requests", fmt.Sprintf("%d", stats.Total), neutralColor),
-		makeAdvancedCell("Requests/second", fmt.Sprintf("%.1f", stats.RequestsPerSecond), neutralColor),
-		makeAdvancedCell("Avg response time", fmt.Sprintf("%.0f ms", stats.Avg), avgColor),
-		makeAdvancedCell("P90", fmt.Sprintf("%.0f ms", stats.P90), neutralColor),
-		makeAdvancedCell("P95", fmt.Sprintf("%.0f ms", stats.P95), neutralColor),
-		makeAdvancedCell("P99", fmt.Sprintf("%.0f ms", stats.P99), neutralColor),
-		makeAdvancedCell("Min response", fmt.Sprintf("%.0f ms", stats.Min), goodColor),
-		makeAdvancedCell("Max response", fmt.Sprintf("%.0f ms", stats.Max), warningColor),
+		makeAdvancedCell(rpsTitle, fmt.Sprintf("%.1f", rps), neutralColor),
+		makeAdvancedCell("Avg response time", formatDuration(stats.Avg, decimals), avgColor),
+	}
+
+	// Una celda por cada percentil calculado, en orden ascendente ("n/a" si hay pocas muestras)
+	for _, p := range sortedPercentiles(stats.PercentileValues) {
+		value := "n/a"
+		if stats.Total >= MinPercentileSamples {
+			value = formatDuration(stats.PercentileValues[p], decimals)
+		}
+		cellColor := neutralColor
+		if p == 95 && stats.SLABreached {
+			cellColor = errorColor
+		}
+		cells = append(cells, makeAdvancedCell(formatPercentileLabel(p), value, cellColor))
+	}
+
+	cells = append(cells,
+		makeAdvancedCell("Min response", formatDuration(stats.Min, decimals), goodColor),
+		makeAdvancedCell("Max response", formatDuration(stats.Max, decimals), warningColor),
+	)
+
+	// Durante la ejecución: latencia actual frente a la de todo el test (rojo si viene subiendo)
+	if stats.RecentMax > 0 {
+		recentColor := goodColor
+		if stats.RecentAvg > stats.Avg*1.5 {
+			recentColor = errorColor
+		} else if stats.RecentAvg > stats.Avg*1.1 {
+			recentColor = warningColor
+		}
+		cells = append(cells, makeAdvancedCell(fmt.Sprintf("Últimas %d avg (min/max)", RecentStatsWindow),
+			fmt.Sprintf("%s (%s / %s)", formatDuration(stats.RecentAvg, decimals), formatDuration(stats.RecentMin, decimals), formatDuration(stats.RecentMax, decimals)), recentColor))
+	}
+
+	cells = append(cells,
 		makeAdvancedCell("Success rate", fmt.Sprintf("%.2f%%", successRate), successColor),
 		makeAdvancedCell("Error rate", fmt.Sprintf("%.2f%%", errorRate), errorRateColor),
+	)
+
+	// Requests que superaron el SLA definido por el usuario
+	if stats.SlowThresholdMs > 0 {
+		slowColor := goodColor
+		if stats.SlowCount > 0 {
+			slowColor = warningColor
+		}
+		cells = append(cells, makeAdvancedCell("> SLA "+formatDuration(float64(stats.SlowThresholdMs), decimals), fmt.Sprintf("%d", stats.SlowCount), slowColor))
 	}
+
+	return cells
 }
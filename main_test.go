@@ -0,0 +1,1176 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+func TestParseWeightedEndpoints(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []string // "peso MÉTODO URL body"
+		wantErr bool
+	}{
+		{name: "vacío y comentarios", text: "\n# comentario\n  \n"},
+		{
+			name: "método en minúsculas y body con espacios",
+			text: "3 get https://api.test/users\n# comentario\n\n1 POST https://api.test/users {\"name\": \"ana\"}",
+			want: []string{`3 GET https://api.test/users ""`, `1 POST https://api.test/users "{\"name\": \"ana\"}"`},
+		},
+		{name: "sin URL", text: "1 GET", wantErr: true},
+		{name: "peso no numérico", text: "x GET https://api.test/", wantErr: true},
+		{name: "peso cero", text: "0 GET https://api.test/", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoints, err := parseWeightedEndpoints(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, ep := range endpoints {
+				got = append(got, fmt.Sprintf("%d %s %s %q", ep.Weight, ep.Config.Method, ep.Config.URL, ep.Config.Body))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("endpoints = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePercentiles(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    []float64
+		wantErr bool
+	}{
+		{text: ""},
+		{text: "99, p50, P99.9", want: []float64{50, 99, 99.9}},
+		{text: "90,", want: []float64{90}},
+		{text: "100", want: []float64{100}},
+		{text: "0", wantErr: true},
+		{text: "101", wantErr: true},
+		{text: "p", wantErr: true},
+		{text: "50, abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parsePercentiles(tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: error = %v, se esperaba error: %v", tt.text, err, tt.wantErr)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q: percentiles = %v, se esperaba %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestToInfluxLineProtocol(t *testing.T) {
+	start := time.Unix(1700000000, 500)
+	tests := []struct {
+		name   string
+		result BenchmarkResult
+		note   string
+		want   string
+	}{
+		{
+			name:   "sin tags",
+			result: BenchmarkResult{Duration: 12.5, Status: 200, StartedAt: start},
+			want:   "http_request duration=12.5,status=200i 1700000000000000500\n",
+		},
+		{
+			name:   "tags escapados y sin timestamp",
+			result: BenchmarkResult{URL: "https://api.test/a b,c=d", Endpoint: "GET /users", Duration: 3, Status: 500},
+			want:   `http_request,url=https://api.test/a\ b\,c\=d,endpoint=GET\ /users duration=3,status=500i` + "\n",
+		},
+		{
+			name:   "con nota",
+			result: BenchmarkResult{Duration: 1, Status: 200},
+			note:   "antes del cambio",
+			want:   `http_request,note=antes\ del\ cambio duration=1,status=200i` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toInfluxLineProtocol([]BenchmarkResult{tt.result}, tt.note); got != tt.want {
+				t.Errorf("línea = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	tests := []struct {
+		name    string
+		stats   BenchmarkStats
+		want    []string
+		notWant []string
+	}{
+		{
+			name:  "con percentiles",
+			stats: BenchmarkStats{Total: 4, Success: 3, Avg: 12.5, RequestsPerSecond: 40, PercentileValues: map[float64]float64{99.9: 30, 50: 10}},
+			want: []string{
+				"# HELP benchmark_requests_total Requests ejecutadas en la última ejecución.\n# TYPE benchmark_requests_total gauge\nbenchmark_requests_total 4\n",
+				"benchmark_latency_avg_ms 12.5\n",
+				"benchmark_error_ratio 0.25\n",
+				"benchmark_requests_per_second 40\n",
+				"# TYPE benchmark_latency_ms gauge\nbenchmark_latency_ms{quantile=\"0.5\"} 10\nbenchmark_latency_ms{quantile=\"0.999\"} 30\n",
+			},
+		},
+		{
+			name:    "sin requests",
+			stats:   BenchmarkStats{},
+			want:    []string{"benchmark_requests_total 0\n", "benchmark_error_ratio 0\n"},
+			notWant: []string{"benchmark_latency_ms"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := prometheusMetrics(tt.stats)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("falta %q en:\n%s", want, got)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("sobra %q en:\n%s", notWant, got)
+				}
+			}
+		})
+	}
+}
+
+func TestParseHAR(t *testing.T) {
+	type step struct{ Method, URL, Headers, Body, ContentType string }
+	tests := []struct {
+		name    string
+		har     string
+		want    []step
+		wantErr bool
+	}{
+		{
+			name: "headers calculados y postData",
+			har: `{"log": {"entries": [
+				{"request": {"method": "get", "url": "https://api.test/users", "headers": [
+					{"name": ":authority", "value": "api.test"},
+					{"name": "Accept", "value": "application/json"},
+					{"name": "content-length", "value": "12"},
+					{"name": "Authorization", "value": "Bearer abc"}]}},
+				{"request": {"method": "POST", "url": "https://api.test/users",
+					"headers": [{"name": "Content-Type", "value": "application/json"}],
+					"postData": {"mimeType": "application/json", "text": "{\"name\":\"ana\"}"}}}]}}`,
+			want: []step{
+				{Method: "GET", URL: "https://api.test/users", Headers: "Accept: application/json\nAuthorization: Bearer abc"},
+				{Method: "POST", URL: "https://api.test/users", Body: `{"name":"ana"}`, ContentType: "application/json"},
+			},
+		},
+		{name: "JSON inválido", har: `{"log":`, wantErr: true},
+		{name: "entrada sin URL", har: `{"log": {"entries": [{"request": {"method": "GET"}}]}}`, wantErr: true},
+		{name: "sin entradas", har: `{"log": {"entries": []}}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps, err := parseHAR([]byte(tt.har))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+			var got []step
+			for _, s := range steps {
+				got = append(got, step{s.Method, s.URL, s.Headers, s.Body, s.ContentType})
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("pasos = %+v, se esperaba %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResultAggregatePercentile(t *testing.T) {
+	durations := []float64{120, 5, 42, 5, 300, 17, 42, 8, 99, 42}
+	a := newResultAggregate(RequestConfig{})
+	for _, d := range durations {
+		a.add(BenchmarkResult{Duration: d, Status: 200}, RequestConfig{})
+	}
+	sorted := slices.Sorted(slices.Values(durations))
+	// El histograma debe dar lo mismo que el percentil sobre la lista completa ordenada
+	for _, p := range []float64{0, 0.1, 0.5, 0.9, 0.95, 0.99, 1} {
+		if got, want := a.percentile(p), percentile(sorted, p); got != want {
+			t.Errorf("P%v = %v, se esperaba %v", p*100, got, want)
+		}
+	}
+	if got := newResultAggregate(RequestConfig{}).percentile(0.5); got != 0 {
+		t.Errorf("percentil sin resultados = %v, se esperaba 0", got)
+	}
+}
+
+func TestNearestPointPicksClosest(t *testing.T) {
+	c := &ChartWidget{hoverRadius: 15, points: []PointInfo{
+		{X: 100, Y: 100, Index: 1},
+		{X: 104, Y: 104, Index: 2},
+		{X: 300, Y: 300, Index: 3},
+	}}
+	tests := []struct {
+		name   string
+		pos    fyne.Position
+		want   int // Index del punto esperado (0 = ninguno)
+		wantOK bool
+	}{
+		// Los dos primeros puntos están dentro del radio: gana el más cercano, no el primero del recorrido
+		{name: "puntos superpuestos", pos: fyne.NewPos(106, 106), want: 2, wantOK: true},
+		{name: "más cerca del primero", pos: fyne.NewPos(90, 100), want: 1, wantOK: true},
+		{name: "fuera del radio", pos: fyne.NewPos(200, 200)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			point, ok := c.nearestPoint(tt.pos)
+			if ok != tt.wantOK || point.Index != tt.want {
+				t.Errorf("punto %d (%v), se esperaba %d (%v)", point.Index, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseResultsCSV(t *testing.T) {
+	tests := []struct {
+		name        string
+		csv         string
+		want        []BenchmarkResult
+		wantSkipped int
+		wantErr     bool
+	}{
+		{
+			name: "columnas en otro orden, extra y filas inválidas",
+			csv:  "Status,Seq,Timestamp,Duration,URL\n200,1,10:00:00,12.5,x\n500,2,10:00:01,abc,y\n404,3,10:00:02,7,z\n201,4,10:00:03,-1,w\n",
+			want: []BenchmarkResult{
+				{Seq: 1, Timestamp: "10:00:00", Duration: 12.5, Status: 200},
+				{Seq: 3, Timestamp: "10:00:02", Duration: 7, Status: 404},
+			},
+			wantSkipped: 2,
+		},
+		{
+			name:        "fila con columnas de menos",
+			csv:         "Seq, Timestamp, Duration, Status\n1,10:00:00\n2,10:00:01,3,200\n",
+			want:        []BenchmarkResult{{Seq: 2, Timestamp: "10:00:01", Duration: 3, Status: 200}},
+			wantSkipped: 1,
+		},
+		{name: "falta una columna", csv: "Seq,Timestamp,Duration\n1,10:00:00,3\n", wantErr: true},
+		{name: "vacío", csv: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, skipped, err := parseResultsCSV(strings.NewReader(tt.csv))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) || skipped != tt.wantSkipped {
+				t.Errorf("resultados = %+v (%d salteadas), se esperaba %+v (%d)", got, skipped, tt.want, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "formatos aceptados",
+			data: "# comentario\n\nexport TOKEN=abc\nHOST = api.test # comentario\nQ=\"a\\nb \\\"x\\\"\"\nS='lit $x \\n'\nVACIO=\n",
+			want: map[string]string{"TOKEN": "abc", "HOST": "api.test", "Q": "a\nb \"x\"", "S": `lit $x \n`, "VACIO": ""},
+		},
+		{name: "sin igual", data: "SIN_IGUAL", wantErr: true},
+		{name: "sin clave", data: "=valor", wantErr: true},
+		{name: "clave con espacio", data: "A B=1", wantErr: true},
+		{name: "comillas dobles sin cerrar", data: `Q="abierta`, wantErr: true},
+		{name: "comillas simples sin cerrar", data: `S='abierta`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEnvFile([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, se esperaba error: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !maps.Equal(got, tt.want) {
+				t.Errorf("entorno = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandEnvString(t *testing.T) {
+	t.Setenv("BM_TEST_FROM_OS", "proceso")
+	env := map[string]string{"HOST": "api.test", "TOKEN": "abc", "BM_TEST_SHADOWED": "archivo"}
+	t.Setenv("BM_TEST_SHADOWED", "proceso")
+	tests := []struct{ in, want string }{
+		{"https://${HOST}/v1?t=${TOKEN}", "https://api.test/v1?t=abc"},
+		{"${BM_TEST_FROM_OS}", "proceso"},
+		{"${BM_TEST_SHADOWED}", "archivo"},
+		{"${BM_TEST_MISSING}/${HOST}", "${BM_TEST_MISSING}/api.test"},
+		{"${HOST", "${HOST"},
+		{"$HOST", "$HOST"},
+	}
+	for _, tt := range tests {
+		if got := expandEnvString(tt.in, env); got != tt.want {
+			t.Errorf("expandEnvString(%q) = %q, se esperaba %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHistogramPercentile(t *testing.T) {
+	hist := map[float64]int{10: 2, 20: 1, 30: 1}
+	tests := []struct {
+		name  string
+		hist  map[float64]int
+		total int
+		p     float64
+		want  float64
+	}{
+		{name: "sin valores", hist: nil, total: 0, p: 0.5, want: 0},
+		{name: "mínimo", hist: hist, total: 4, p: 0, want: 10},
+		{name: "valor repetido", hist: hist, total: 4, p: 0.25, want: 10},
+		{name: "mediana", hist: hist, total: 4, p: 0.5, want: 20},
+		{name: "P75", hist: hist, total: 4, p: 0.75, want: 30},
+		{name: "máximo", hist: hist, total: 4, p: 1, want: 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := histogramPercentile(tt.hist, tt.total, tt.p); got != tt.want {
+				t.Errorf("percentil %v = %v, se esperaba %v", tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStepCondition(t *testing.T) {
+	tests := []struct {
+		text    string
+		index   int
+		want    *StepCondition
+		wantErr bool
+	}{
+		{text: "", index: 2},
+		{text: " 1 = 200 ", index: 2, want: &StepCondition{Step: 0, Status: 200}},
+		{text: "2=0", index: 2, want: &StepCondition{Step: 1, Status: 0}},
+		{text: "3=200", index: 2, wantErr: true}, // El paso 3 no es anterior al paso de índice 2
+		{text: "0=200", index: 2, wantErr: true},
+		{text: "1:200", index: 2, wantErr: true},
+		{text: "a=200", index: 2, wantErr: true},
+		{text: "1=", index: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseStepCondition(tt.text, tt.index)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: error = %v, se esperaba error: %v", tt.text, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: condición = %+v, se esperaba %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRetryAfterDelay(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		status int
+		header string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "segundos", status: 429, header: "5", want: 5 * time.Second, wantOK: true},
+		{name: "segundos con espacios en 503", status: 503, header: " 2 ", want: 2 * time.Second, wantOK: true},
+		{name: "fecha HTTP", status: 503, header: now.Add(10 * time.Second).Format(http.TimeFormat), want: 10 * time.Second, wantOK: true},
+		{name: "fecha pasada", status: 429, header: now.Add(-time.Minute).Format(http.TimeFormat), want: 0, wantOK: true},
+		{name: "negativo", status: 429, header: "-5", want: 0, wantOK: true},
+		{name: "tope", status: 429, header: "3600", want: MaxRetryAfter, wantOK: true},
+		{name: "status que no aplica", status: 200, header: "5"},
+		{name: "sin header", status: 429, header: ""},
+		{name: "header inválido", status: 429, header: "pronto"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := retryAfterDelay(tt.status, tt.header, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("retryAfterDelay = %v, %v; se esperaba %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestAggregateAllStatusZero(t *testing.T) {
+	tests := []struct {
+		name      string
+		durations []float64
+		wantMin   float64
+		wantMax   float64
+	}{
+		{"sin resultados", nil, 0, 0},
+		{"un resultado", []float64{12.5}, 12.5, 12.5},
+		{"varios resultados", []float64{30, 5, 18}, 5, 30},
+		{"falla inmediata", []float64{0, 0}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := RequestConfig{}
+			agg := newResultAggregate(cfg)
+			for _, d := range tt.durations {
+				agg.add(BenchmarkResult{Status: 0, Duration: d}, cfg)
+			}
+			stats := agg.counters(cfg, time.Second)
+			if stats.Min != tt.wantMin || stats.Max != tt.wantMax {
+				t.Errorf("Min/Max = %v/%v, se esperaba %v/%v", stats.Min, stats.Max, tt.wantMin, tt.wantMax)
+			}
+			if stats.Success != 0 {
+				t.Errorf("Success = %d, se esperaba 0", stats.Success)
+			}
+			if len(tt.durations) > 0 && stats.ErrorRate != 100 {
+				t.Errorf("ErrorRate = %d, se esperaba 100", stats.ErrorRate)
+			}
+		})
+	}
+}
+
+func TestPostmanRoundTripKeepsUnknownFields(t *testing.T) {
+	const collection = `{
+		"info": {"_postman_id": "abc", "name": "API", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+		"auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}", "type": "string"}]},
+		"variable": [{"key": "base", "value": "https://api.test"}],
+		"event": [{"listen": "prerequest", "script": {"exec": ["console.log(1)"]}}],
+		"item": [
+			{"name": "Carpeta", "description": "docs", "item": [
+				{"name": "Subir", "request": {
+					"method": "POST",
+					"url": {"raw": "{{base}}/files/:id", "host": ["{{base}}"], "path": ["files", ":id"], "variable": [{"key": "id", "value": "1"}]},
+					"header": [{"key": "X-Trace", "value": "1", "type": "text", "description": "traza"}],
+					"body": {"mode": "formdata", "formdata": [{"key": "file", "type": "file", "src": "/tmp/a.txt"}]}
+				}}
+			]},
+			{"name": "Ping", "request": "https://api.test/ping"}
+		]
+	}`
+	var c PostmanCollection
+	if err := json.Unmarshal([]byte(collection), &c); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Items[1].Request.Url.Raw; got != "https://api.test/ping" {
+		t.Fatalf("URL de la request abreviada = %q", got)
+	}
+
+	// Aplicar el formulario sin cambios no debe perder nada
+	req := c.Items[0].Items[0].Request
+	applyToPostmanRequest(req, req.Method, req.Url.Raw, []HeaderRow{{Key: "X-Trace", Value: "1", Enabled: true}}, req.Body.Raw)
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"_postman_id":"abc"`, `"auth":{`, `"variable":[{"key":"base"`, `"event":[`,
+		`"description":"docs"`, `"path":["files",":id"]`, `"variable":[{"key":"id"`, `"description":"traza"`, `"formdata":[`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("falta %s en la exportación:\n%s", want, data)
+		}
+	}
+
+	// Cambiar la URL descarta las partes derivadas de la anterior pero conserva las variables
+	applyToPostmanRequest(req, req.Method, "{{base}}/docs/:id", nil, "")
+	if _, ok := req.Url.Extra["path"]; ok {
+		t.Error("path de la URL anterior conservado")
+	}
+	if _, ok := req.Url.Extra["variable"]; !ok {
+		t.Error("variables de la URL perdidas")
+	}
+}
+
+func TestRedactPostmanCollection(t *testing.T) {
+	const collection = `{
+		"info": {"name": "API"},
+		"auth": {"type": "apikey", "apikey": [{"key": "key", "value": "X-API-Key"}, {"key": "value", "value": "s3cr3t"}]},
+		"variable": [{"key": "api_token", "value": "t0k3n"}, {"key": "base", "value": "https://api.test"}],
+		"item": [{"name": "Login", "request": {
+			"method": "GET",
+			"url": "https://api.test/login?api_key=k3y",
+			"auth": {"type": "basic", "basic": [{"key": "username", "value": "ana"}, {"key": "password", "value": "pw"}]},
+			"header": [{"key": "Authorization", "value": "Bearer abc"}]
+		}}]
+	}`
+	var c PostmanCollection
+	if err := json.Unmarshal([]byte(collection), &c); err != nil {
+		t.Fatal(err)
+	}
+	redacted, found := redactPostmanCollection(c)
+	if !found {
+		t.Fatal("no se detectaron secretos")
+	}
+	data, err := json.Marshal(redacted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"s3cr3t", "t0k3n", "k3y", `"pw"`, "Bearer abc"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("%s sigue en la exportación:\n%s", secret, data)
+		}
+	}
+	for _, public := range []string{"X-API-Key", "https://api.test\"", `"ana"`} {
+		if !strings.Contains(string(data), public) {
+			t.Errorf("falta %s en la exportación:\n%s", public, data)
+		}
+	}
+	// La colección original no se modifica
+	if original, _ := json.Marshal(c); !strings.Contains(string(original), "s3cr3t") {
+		t.Error("se modificó la colección original")
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         RequestConfig
+		wantURL     string
+		wantHeaders string
+	}{
+		{
+			name:        "sin secretos",
+			cfg:         RequestConfig{URL: "https://api.test/users?page=2", Headers: "Accept: application/json"},
+			wantURL:     "https://api.test/users?page=2",
+			wantHeaders: "Accept: application/json",
+		},
+		{
+			name:    "password en la URL",
+			cfg:     RequestConfig{URL: "https://ana:pw@api.test/"},
+			wantURL: "https://ana:" + RedactedValue + "@api.test/",
+		},
+		{
+			name:    "query sensible",
+			cfg:     RequestConfig{URL: "https://api.test/?api_key=k3y&page=2"},
+			wantURL: "https://api.test/?api_key=" + RedactedValue + "&page=2",
+		},
+		{
+			name:        "headers sensibles, también deshabilitados",
+			cfg:         RequestConfig{URL: "https://api.test/", Headers: "Authorization: Bearer abc\nAccept: */*\n# X-Auth-Token: t0k3n"},
+			wantURL:     "https://api.test/",
+			wantHeaders: "Authorization: " + RedactedValue + "\nAccept: */*\n# X-Auth-Token: " + RedactedValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := redactSecrets(tt.cfg)
+			if got.URL != tt.wantURL {
+				t.Errorf("URL = %q, se esperaba %q", got.URL, tt.wantURL)
+			}
+			if got.Headers != tt.wantHeaders {
+				t.Errorf("Headers = %q, se esperaba %q", got.Headers, tt.wantHeaders)
+			}
+		})
+	}
+}
+
+func TestApplyHeadersDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing http.Header
+		text     string
+		key      string
+		want     []string
+	}{
+		{"clave repetida", nil, "X-Forwarded-For: 10.0.0.1\nX-Forwarded-For: 10.0.0.2", "X-Forwarded-For", []string{"10.0.0.1", "10.0.0.2"}},
+		{"mayúsculas distintas", nil, "cookie: a=1\nCookie: b=2", "Cookie", []string{"a=1", "b=2"}},
+		{"reemplaza el valor existente", http.Header{"Content-Type": {"text/plain"}}, "Content-Type: application/json", "Content-Type", []string{"application/json"}},
+		{"reemplaza y luego agrega", http.Header{"Accept": {"*/*"}}, "Accept: text/html\nAccept: application/xml", "Accept", []string{"text/html", "application/xml"}},
+		{"valor con dos puntos", nil, "X-Url: http://a:8080\nX-Url: http://b:8080", "X-Url", []string{"http://a:8080", "http://b:8080"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := tt.existing.Clone()
+			if h == nil {
+				h = http.Header{}
+			}
+			applyHeaders(h, tt.text)
+			if got := h.Values(tt.key); !slices.Equal(got, tt.want) {
+				t.Errorf("%s = %q, se esperaba %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRollingErrorCountsIgnoresSampling(t *testing.T) {
+	var all []BenchmarkResult
+	for seq := 1; seq <= 10; seq++ {
+		status := 200
+		if seq%2 == 0 {
+			status = 500
+		}
+		all = append(all, BenchmarkResult{Seq: seq, Status: status})
+	}
+	tests := []struct {
+		name        string
+		visible     []BenchmarkResult
+		wantErrors  []int
+		wantSamples []int
+	}{
+		{"todos los puntos", all[7:], []int{2, 2, 2}, []int{4, 4, 4}},
+		{"muestreado", []BenchmarkResult{all[0], all[4], all[9]}, []int{0, 2, 2}, []int{1, 4, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs, samples := rollingErrorCounts(all, tt.visible, 4, RequestConfig{})
+			if !slices.Equal(errs, tt.wantErrors) || !slices.Equal(samples, tt.wantSamples) {
+				t.Errorf("errores/muestras = %v/%v, se esperaba %v/%v", errs, samples, tt.wantErrors, tt.wantSamples)
+			}
+		})
+	}
+}
+
+func TestNTLMTransportForcesHTTP1(t *testing.T) {
+	for _, force := range []bool{false, true} {
+		base := newNTLMTransport(RequestConfig{ForceHTTP1: force}).base
+		if base.ForceAttemptHTTP2 || base.TLSNextProto == nil || len(base.TLSNextProto) != 0 {
+			t.Errorf("ForceHTTP1=%v: el transport NTLM puede negociar HTTP/2", force)
+		}
+		if base.TLSClientConfig == nil {
+			t.Fatalf("ForceHTTP1=%v: sin configuración TLS", force)
+		}
+		if got := base.TLSClientConfig.NextProtos; !slices.Equal(got, []string{"http/1.1"}) {
+			t.Errorf("ForceHTTP1=%v: ALPN = %v, se esperaba solo http/1.1", force, got)
+		}
+	}
+}
+
+func TestCurlURLEncodedData(t *testing.T) {
+	tests := []struct {
+		name string
+		curl string
+		want []string
+	}{
+		{"nombre=contenido", `curl https://api.test --data-urlencode "q=hello world"`, []string{"q=hello%20world"}},
+		{"comillas simples", `curl https://api.test --data-urlencode 'q=a&b'`, []string{"q=a%26b"}},
+		{"sin nombre", `curl https://api.test --data-urlencode "hello world"`, []string{"hello%20world"}},
+		{"= inicial", `curl https://api.test --data-urlencode "=a=b"`, []string{"a%3Db"}},
+		{"sin comillas", `curl https://api.test --data-urlencode q=1+1`, []string{"q=1%2B1"}},
+		{"varios", `curl https://api.test --data-urlencode "a=x y" --data-urlencoded "b=ñ"`, []string{"a=x%20y", "b=%C3%B1"}},
+		{"sin la opción", `curl https://api.test -d 'q=hello world'`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := curlURLEncodedData(tt.curl); !slices.Equal(got, tt.want) {
+				t.Errorf("curlURLEncodedData = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurlBodyKeepsEscapes(t *testing.T) {
+	tests := []struct {
+		name string
+		curl string
+		want string
+	}{
+		{"comillas simples", "curl https://api.test \\\n  -H 'Content-Type: application/json' \\\n  --data-raw '{\"msg\":\"dijo \\\"hola\\\"\"}'",
+			`{"msg":"dijo \"hola\""}`},
+		{"comillas dobles", "curl https://api.test \\\n  -d \"{\\\"msg\\\":\\\"dijo \\\\\\\"hola\\\\\\\"\\\"}\"",
+			`{"msg":"dijo \"hola\""}`},
+		{"regex con barras", "curl https://api.test --data-raw '{\"re\":\"\\\\d+\\\\.\\\\d+\"}'",
+			`{"re":"\\d+\\.\\d+"}`},
+		{"fin de línea Windows", "curl https://api.test \\\r\n  --data-raw '{\"a\":\"x\\\"y\"}'",
+			`{"a":"x\"y"}`},
+		{"cmd de Windows", "curl ^\"https://api.test^\" ^\n  --data-raw ^\"{^\\^\"a^\\^\":1}^\"",
+			`{"a":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := curlDataBody(normalizeCurlCommand(tt.curl)); got != tt.want {
+				t.Errorf("body = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatXML(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		want   string
+		wantOK bool
+	}{
+		{"elementos anidados", `<a><b><c>1</c></b></a>`, "<a>\n  <b>\n    <c>1</c>\n  </b>\n</a>", true},
+		{"atributos y autocerrados", `<a x="1" y='2'><b/><c z="&amp;"></c></a>`, "<a x=\"1\" y=\"2\">\n  <b/>\n  <c z=\"&amp;\"/>\n</a>", true},
+		{"namespaces y declaración",
+			`<?xml version="1.0"?><soap:Env xmlns:soap="urn:x"><soap:Body><m:Get xmlns:m="urn:m">v</m:Get></soap:Body></soap:Env>`,
+			"<?xml version=\"1.0\"?>\n<soap:Env xmlns:soap=\"urn:x\">\n  <soap:Body>\n    <m:Get xmlns:m=\"urn:m\">v</m:Get>\n  </soap:Body>\n</soap:Env>", true},
+		{"comentario", `<a><!-- c --><b>t</b></a>`, "<a>\n  <!-- c -->\n  <b>t</b>\n</a>", true},
+		{"ya indentado", "<a>\n    <b>t</b>\n</a>", "<a>\n  <b>t</b>\n</a>", true},
+		{"cierre que no coincide", `<a><b></a>`, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := formatXML(tt.body)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("formatXML = %q, %v; se esperaba %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCheckColdWarmReusesConnection(t *testing.T) {
+	var conns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	// Con ForceHTTP1 el cliente usa un transport propio en vez del transport por defecto
+	for _, force := range []bool{false, true} {
+		conns.Store(0)
+		cfg := RequestConfig{URL: srv.URL, Method: "GET", ForceHTTP1: force}
+		for range 2 {
+			check := checkColdWarm(cfg, 5)
+			if check.Cold.Status != http.StatusOK || check.Failed != 0 {
+				t.Fatalf("ForceHTTP1=%v: status %d, %d fallidas", force, check.Cold.Status, check.Failed)
+			}
+			if check.Reused != len(check.Warm) {
+				t.Errorf("ForceHTTP1=%v: reutilizadas %d de %d", force, check.Reused, len(check.Warm))
+			}
+		}
+		// Cada diagnóstico abre una sola conexión, y el segundo no hereda la del primero
+		if got := conns.Load(); got != 2 {
+			t.Errorf("ForceHTTP1=%v: conexiones abiertas = %d, se esperaban 2", force, got)
+		}
+	}
+}
+
+func TestParseSweepLevels(t *testing.T) {
+	got, err := parseSweepLevels("10, 1, 5, 10, 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 5, 10}; !slices.Equal(got, want) {
+		t.Errorf("niveles = %v, se esperaba %v", got, want)
+	}
+	if _, err := parseSweepLevels("1, 0"); err == nil {
+		t.Error("se aceptó un nivel 0")
+	}
+}
+
+func TestRunConcurrencySweepDropsCancelledLevel(t *testing.T) {
+	const firstLevelRequests = 3
+	cancelChan := make(chan bool)
+	var requests atomic.Int32
+	var once sync.Once
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Se cancela durante el segundo nivel, después de su primera request
+		if requests.Add(1) > firstLevelRequests {
+			once.Do(func() { close(cancelChan) })
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	cfg := RequestConfig{URL: srv.URL, Method: "GET", Count: firstLevelRequests}
+	points, results := runConcurrencySweep(cfg, []int{1, 2}, nil, cancelChan)
+	var levels []int
+	for _, p := range points {
+		levels = append(levels, p.Users)
+	}
+	if !slices.Equal(levels, []int{1}) {
+		t.Fatalf("niveles en la curva = %v, se esperaba solo el de 1 usuario", levels)
+	}
+	if points[0].Stats.Total != firstLevelRequests || len(results) != firstLevelRequests {
+		t.Errorf("total %d, %d resultados: se esperaban los %d del primer nivel", points[0].Stats.Total, len(results), firstLevelRequests)
+	}
+}
+
+func TestRPSStepFailureAborted(t *testing.T) {
+	step := RPSStep{TargetRPS: 10, Stats: BenchmarkStats{Total: 100, Success: 100, RequestsPerSecond: 10, Aborted: true, AbortReason: "circuit breaker"}}
+	if got := rpsStepFailure(step, 0); !strings.Contains(got, "circuit breaker") {
+		t.Errorf("un escalón abortado se consideró sostenible (%q)", got)
+	}
+}
+
+func TestFindMaxRPSDropsCancelledStep(t *testing.T) {
+	cancelChan := make(chan bool)
+	var once sync.Once
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { close(cancelChan) }) // Se cancela durante el primer escalón
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	cfg := RequestConfig{URL: srv.URL, Method: "GET", Count: 5}
+	steps, knee, results := findMaxRPS(cfg, 100, 100, 0, nil, cancelChan)
+	if len(steps) != 0 || knee != -1 || len(results) != 0 {
+		t.Errorf("%d escalones, codo %d, %d resultados: el escalón cancelado no debe contar", len(steps), knee, len(results))
+	}
+}
+
+func TestRunHeadlessRejectsRPSOutOfRange(t *testing.T) {
+	for _, rps := range []string{"-1", "2000000"} {
+		if code := runHeadless([]string{"-url", "http://127.0.0.1:1/", "-rps", rps}); code != 2 {
+			t.Errorf("-rps %s: código %d, se esperaba 2", rps, code)
+		}
+	}
+}
+
+func TestPreRequestScript(t *testing.T) {
+	var mu sync.Mutex
+	var signatures, bodies []string
+	var staleHeader bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		signatures = append(signatures, r.Header.Get("X-Signature"))
+		bodies = append(bodies, string(body))
+		staleHeader = staleHeader || r.Header.Get("X-Borrar") != ""
+		mu.Unlock()
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	script := `
+var payload = JSON.parse(request.body);
+payload.method = request.method;
+request.body = JSON.stringify(payload);
+request.headers["X-Signature"] = hmacSHA256("secreto", request.body);
+delete request.headers["X-Borrar"];
+`
+	cfg := RequestConfig{URL: srv.URL, Method: "POST", Body: `{"id":1}`, ContentType: "application/json",
+		Headers: "X-Borrar: 1", Count: 4, ConcurrentUsers: 2, PreRequestScript: script}
+	_, stats := runLoadTest(cfg, nil, nil, nil)
+	if stats.Aborted || stats.Success != 4 {
+		t.Fatalf("éxitos %d, abortado %v (%s)", stats.Success, stats.Aborted, stats.AbortReason)
+	}
+	wantBody := `{"id":1,"method":"POST"}`
+	wantSig := generateHMACSignature("secreto", wantBody)
+	for i := range bodies {
+		if bodies[i] != wantBody || signatures[i] != wantSig {
+			t.Errorf("request %d: body %q, firma %q", i, bodies[i], signatures[i])
+		}
+	}
+	if staleHeader {
+		t.Error("el header borrado por el script llegó al servidor")
+	}
+}
+
+func TestPreRequestScriptErrorAbortsRun(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer srv.Close()
+
+	for name, script := range map[string]string{
+		"sintaxis":  `request.headers[`,
+		"ejecución": `request.headers["X-Firma"] = noExiste()`,
+	} {
+		cfg := RequestConfig{URL: srv.URL, Method: "GET", Count: 5, PreRequestScript: script}
+		_, stats := runLoadTest(cfg, nil, nil, nil)
+		if !stats.Aborted || !strings.Contains(stats.AbortReason, "pre-request") {
+			t.Errorf("%s: abortado %v (%q)", name, stats.Aborted, stats.AbortReason)
+		}
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("llegaron %d requests al servidor con un script que falla", n)
+	}
+}
+
+func TestRPSStepFailureRateLimited(t *testing.T) {
+	// Con SeparateRateLimited los 429 no suman al error rate, pero el escalón no es sostenible
+	step := RPSStep{TargetRPS: 10, Stats: BenchmarkStats{Total: 100, Success: 10, RateLimited: 90, RequestsPerSecond: 10}}
+	if got := rpsStepFailure(step, 0); !strings.Contains(got, "429") {
+		t.Errorf("un escalón con 90%% de 429 se consideró sostenible (%q)", got)
+	}
+	step.Stats.Success, step.Stats.RateLimited = 100, 0
+	if got := rpsStepFailure(step, 0); got != "" {
+		t.Errorf("un escalón sin errores falló: %q", got)
+	}
+}
+
+func TestRunLoadTestPacerSkipsPause(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	// Con la pausa fija de 10 ms un solo usuario no pasa de ~100 req/s: 50 requests tardarían 500 ms
+	cfg := RequestConfig{URL: srv.URL, Method: "GET", Count: 50, ConcurrentUsers: 1, TargetRPS: 1000}
+	start := time.Now()
+	_, stats := runLoadTest(cfg, nil, nil, nil)
+	if elapsed := time.Since(start); stats.Success != 50 || elapsed > 400*time.Millisecond {
+		t.Errorf("%d éxitos en %v: con límite de RPS el pacer debe reemplazar la pausa fija", stats.Success, elapsed)
+	}
+}
+
+func TestSeparateRateLimitedDoesNotAbort(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	// Con los 429 contados aparte, ni el circuit breaker ni "abortar al primer error" los toman como error
+	for name, cfg := range map[string]RequestConfig{
+		"circuit breaker": {StopIfErrorRateExceeds: 50, ErrorRateWindow: 5},
+		"primer error":    {AbortOnFirstError: true},
+	} {
+		cfg.URL, cfg.Method, cfg.Count, cfg.SeparateRateLimited = srv.URL, "GET", 10, true
+		_, stats := runLoadTest(cfg, nil, nil, nil)
+		if stats.Aborted || stats.RateLimited != 10 {
+			t.Errorf("%s: abortado %v (%q), %d limitadas", name, stats.Aborted, stats.AbortReason, stats.RateLimited)
+		}
+	}
+}
+
+func TestRetryAfterHonoredDuringWarmup(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	// Sin respetar Retry-After en el calentamiento, el usuario enviaría ~100 requests en ese segundo
+	cfg := RequestConfig{URL: srv.URL, Method: "GET", Duration: 2, WarmupSeconds: 1, ConcurrentUsers: 1, HonorRetryAfter: true}
+	runLoadTest(cfg, nil, nil, nil)
+	if n := requests.Load(); n > 3 {
+		t.Errorf("%d requests en 2 s con Retry-After: 1; el calentamiento no respetó la espera", n)
+	}
+}
+
+func TestRunLoadTestMissingBodyFile(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer srv.Close()
+
+	cfg := RequestConfig{URL: srv.URL, Method: "POST", Count: 3, BodyFile: filepath.Join(t.TempDir(), "no-existe.json")}
+	_, stats := runLoadTest(cfg, nil, nil, nil)
+	if !stats.Aborted || !strings.Contains(stats.AbortReason, "archivo de body") {
+		t.Errorf("abortado %v (%q): un body que no se puede leer debe abortar la ejecución", stats.Aborted, stats.AbortReason)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("se enviaron %d requests sin body", n)
+	}
+}
+
+func TestRunLoadTestLogErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	cfg := RequestConfig{URL: srv.URL, Method: "GET", Count: 3, LogFile: filepath.Join(t.TempDir(), "no-existe", "log.jsonl")}
+	if _, stats := runLoadTest(cfg, nil, nil, nil); !stats.Aborted || !strings.Contains(stats.AbortReason, "log de requests") {
+		t.Errorf("log que no se puede abrir: abortado %v (%q)", stats.Aborted, stats.AbortReason)
+	}
+
+	// /dev/full acepta abrir pero falla cada escritura (ENOSPC)
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("sin /dev/full")
+	}
+	cfg.LogFile = "/dev/full"
+	_, stats := runLoadTest(cfg, nil, nil, nil)
+	if stats.Aborted || stats.Success != 3 || stats.LogError == "" {
+		t.Errorf("log sin espacio: abortado %v, %d éxitos, error de log %q", stats.Aborted, stats.Success, stats.LogError)
+	}
+}
+
+func TestParseSuccessStatusRange(t *testing.T) {
+	tests := []struct {
+		min, max         string
+		wantMin, wantMax int
+		wantErr          bool
+	}{
+		{"", "", 0, 0, false},
+		{"200", "399", 200, 399, false},
+		{" 100 ", "599", 100, 599, false},
+		{"", "204", 0, 204, false},
+		{"abc", "299", 0, 0, true},
+		{"200", "x", 0, 0, true},
+		{"99", "299", 0, 0, true},
+		{"200", "600", 0, 0, true},
+		{"-1", "", 0, 0, true},
+		{"300", "200", 0, 0, true},
+		{"", "150", 0, 0, true}, // El mínimo por defecto (200) supera al máximo
+	}
+	for _, tt := range tests {
+		gotMin, gotMax, err := parseSuccessStatusRange(tt.min, tt.max)
+		if (err != nil) != tt.wantErr || gotMin != tt.wantMin || gotMax != tt.wantMax {
+			t.Errorf("parseSuccessStatusRange(%q, %q) = %d, %d, %v", tt.min, tt.max, gotMin, gotMax, err)
+		}
+	}
+}
+
+func TestRequestContextClassifiesRunEnd(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		deadlineMs int
+		runEnd     time.Duration // 0 = sin fin de ejecución
+		wantRunEnd bool
+	}{
+		{"fin de la ejecución", 0, 50 * time.Millisecond, true},
+		{"deadline por request", 50, time.Second, false},
+		{"deadline sin fin de ejecución", 50, 0, false},
+	}
+	for _, tt := range tests {
+		var runEnd time.Time
+		if tt.runEnd > 0 {
+			runEnd = time.Now().Add(tt.runEnd)
+		}
+		req, _ := http.NewRequest("GET", srv.URL, nil)
+		req, cancel := requestContext(req, RequestConfig{RequestDeadlineMs: tt.deadlineMs}, runEnd)
+		_, err := http.DefaultClient.Do(req)
+		if got := endedWithRun(req); got != tt.wantRunEnd {
+			t.Errorf("%s: endedWithRun = %v (error %v)", tt.name, got, err)
+		}
+		// Solo el deadline por request es un timeout del endpoint; el fin de la ejecución se descarta
+		if kind := classifyError(err); !tt.wantRunEnd && kind != "timeout" {
+			t.Errorf("%s: classifyError = %q, se esperaba timeout", tt.name, kind)
+		}
+		cancel()
+	}
+}
+
+func TestMD4Sum(t *testing.T) {
+	// Vectores de prueba de RFC 1320, sección A.5
+	tests := []struct{ input, want string }{
+		{"", "31d6cfe0d16ae931b73c59d7e0c089c0"},
+		{"a", "bde52cb31de33e46245e05fbdbd6fb24"},
+		{"abc", "a448017aaf21d8525fc10ae87aa6729d"},
+		{"message digest", "d9130a8164549fe818874806e1c7014b"},
+		{"abcdefghijklmnopqrstuvwxyz", "d79e1c308aa5bbcdeea8ed63df412da9"},
+		{"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789", "043f8582f241db351ce627e153e7f0e4"},
+		{strings.Repeat("1234567890", 8), "e33b4ddc9c38f2199c3e7b164fcc0536"},
+	}
+	for _, tt := range tests {
+		sum := md4Sum([]byte(tt.input))
+		if got := hex.EncodeToString(sum[:]); got != tt.want {
+			t.Errorf("md4Sum(%q) = %s, se esperaba %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNTLMv2KnownValues(t *testing.T) {
+	// Ejemplo de MS-NLMP 4.2.4 (usuario "User", dominio "Domain", password "Password")
+	unhex := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	ntHash := md4Sum(utf16LE("Password"))
+	if got := hex.EncodeToString(ntHash[:]); got != "a4f49c406510bdcab6824ee7c30fd852" {
+		t.Errorf("NTOWFv1 = %s", got)
+	}
+	key := ntowfV2("Domain", "User", "Password")
+	if got := hex.EncodeToString(key); got != "0c868a403bfd7a93a3001ef22ef02e3f" {
+		t.Errorf("NTOWFv2 = %s", got)
+	}
+
+	serverChallenge := unhex("0123456789abcdef")
+	clientChallenge := unhex("aaaaaaaaaaaaaaaa")
+	timestamp := make([]byte, 8)
+	targetInfo := unhex("02000c0044006f006d00610069006e0001000c0053006500720076006500720000000000")
+	response := ntlmV2Response(key, serverChallenge, clientChallenge, timestamp, targetInfo)
+	if got := hex.EncodeToString(response[:16]); got != "68cd0ab851e51c96aabc927bebef6a1c" {
+		t.Errorf("NTProofStr = %s", got)
+	}
+	lmResponse := hmacMD5(key, serverChallenge, clientChallenge)
+	if got := hex.EncodeToString(lmResponse); got != "86c35097ac9cec102554764a57cccc19" {
+		t.Errorf("LMv2 = %s", got)
+	}
+}
+
+func TestSessionTestRejectsHTTPOnlyOptions(t *testing.T) {
+	open := func() (callSession, error) { return nil, errors.New("no se debería abrir una sesión") }
+	for name, cfg := range map[string]RequestConfig{
+		"calentamiento":        {Duration: 2, WarmupSeconds: 1},
+		"límite de requests/s": {Count: 5, TargetRPS: 10},
+		"error rate":           {Count: 5, StopIfErrorRateExceeds: 50},
+		"log":                  {Count: 5, LogFile: filepath.Join(t.TempDir(), "log.jsonl")},
+	} {
+		_, stats := runSessionTest(cfg, nil, nil, nil, open)
+		if !stats.Aborted || !strings.Contains(stats.AbortReason, "no admiten") {
+			t.Errorf("%s: abortado %v (%q)", name, stats.Aborted, stats.AbortReason)
+		}
+	}
+	if code := runHeadless([]string{"-url", "ws://127.0.0.1:1/", "-rps", "10"}); code != 2 {
+		t.Errorf("headless WebSocket con -rps: código %d, se esperaba 2", code)
+	}
+}
+
+// okSession es una sesión que responde 200 a cada llamada
+type okSession struct{}
+
+func (okSession) Call(context.Context) (int, error) { return 200, nil }
+func (okSession) Close()                            {}
+
+func TestSessionTestRetainsLatestResults(t *testing.T) {
+	cfg := RequestConfig{Count: 10, ConcurrentUsers: 1, MaxRetainedResults: 3}
+	results, stats := runSessionTest(cfg, nil, nil, nil, func() (callSession, error) { return okSession{}, nil })
+	if stats.Total != 10 || stats.Success != 10 {
+		t.Errorf("total %d, éxitos %d: las estadísticas deben cubrir los resultados descartados", stats.Total, stats.Success)
+	}
+	var seqs []int
+	for _, r := range results {
+		seqs = append(seqs, r.Seq)
+	}
+	if !slices.Equal(seqs, []int{8, 9, 10}) {
+		t.Errorf("resultados conservados %v, se esperaban los últimos 3", seqs)
+	}
+}
+
+func TestResultAggregateTimingSkipsReusedConnections(t *testing.T) {
+	a := newResultAggregate(RequestConfig{})
+	// Una conexión nueva y tres reutilizadas (sin DNS, TCP ni TLS)
+	a.add(BenchmarkResult{Status: 200, Duration: 40, DNSMs: 4, ConnectMs: 8, TLSMs: 12, TTFBMs: 30}, RequestConfig{})
+	for range 3 {
+		a.add(BenchmarkResult{Status: 200, Duration: 10, TTFBMs: 6}, RequestConfig{})
+	}
+	var stats BenchmarkStats
+	a.apply(&stats, RequestConfig{})
+	if stats.AvgDNSMs != 4 || stats.AvgConnectMs != 8 || stats.AvgTLSMs != 12 || stats.NewConnections != 1 {
+		t.Errorf("DNS %v, TCP %v, TLS %v, %d conexiones nuevas: se esperaban 4, 8, 12 y 1",
+			stats.AvgDNSMs, stats.AvgConnectMs, stats.AvgTLSMs, stats.NewConnections)
+	}
+	if stats.AvgTTFBMs != 12 {
+		t.Errorf("TTFB promedio %v, se esperaba 12 (sobre todas las requests)", stats.AvgTTFBMs)
+	}
+}